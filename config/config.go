@@ -3,13 +3,25 @@ package config
 import (
 	"github.com/libreria/config/reader"
 	"github.com/libreria/server/http"
+	"github.com/libreria/service/book"
 	"github.com/libreria/storage/postgres"
+	"github.com/libreria/storage/sqlite"
 )
 
 type Config struct {
 	LogLevel   string          `mapstructure:"log_level" default:"DEBUG"`
 	HTTPServer http.Config     `mapstructure:"http_server"`
+	Storage    Storage         `mapstructure:"storage"`
 	Postgres   postgres.Config `mapstructure:"postgres"`
+	Sqlite     sqlite.Config   `mapstructure:"sqlite"`
+	Book       book.Config     `mapstructure:"book"`
+}
+
+// Storage selects where the library is kept: "postgres", "sqlite" for a
+// single node without postgres, or "memory" which loses the library on
+// shutdown and is meant for tests and demos.
+type Storage struct {
+	Driver string `mapstructure:"driver" default:"postgres"`
 }
 
 func New() (*Config, error) {
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"strings"
@@ -10,9 +11,14 @@ import (
 
 	"github.com/libreria/config"
 	"github.com/libreria/server/http"
+	"github.com/libreria/server/http/auth"
 	"github.com/libreria/server/http/handlers"
 	"github.com/libreria/service/book"
+	"github.com/libreria/storage/memory"
 	"github.com/libreria/storage/postgres"
+	"github.com/libreria/storage/postgres/migrations"
+	"github.com/libreria/storage/sqlite"
+	"github.com/libreria/worker"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,6 +30,15 @@ func main() { // nolint:funlen
 	}
 	// init logger
 	initLogger(cfg.LogLevel)
+
+	// libreria migrate up|down|version|force N|steps N migrates the postgres database
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = migrations.Migrate(cfg.Postgres.ConnString(), os.Stdout, os.Args[2:]...)
+		if err != nil {
+			log.WithError(err).Fatal("migration error")
+		}
+		return
+	}
 	log.Info("service starting...")
 
 	// prepare main context
@@ -31,19 +46,40 @@ func main() { // nolint:funlen
 	setupGracefulShutdown(cancel)
 	var wg = &sync.WaitGroup{}
 
-	// create DB connection
-	pg, err := postgres.New(ctx, wg, cfg.Postgres)
+	// create storage
+	storage, err := newStorage(ctx, wg, cfg)
 	if err != nil {
-		log.WithError(err).Fatal("postgres init error")
+		log.WithError(err).Fatal("storage init error")
 	}
 
 	// create service
-	bookSrv := book.New(pg)
+	bookSrv := book.New(cfg.Book, storage)
+
+	// run background jobs
+	jobs := []worker.Job{
+		{Name: "hold_expiry", Interval: cfg.Book.HoldExpiryInterval, Run: bookSrv.ExpireHolds},
+		{Name: "overdue_loans", Interval: cfg.Book.OverdueInterval, Run: bookSrv.ProcessOverdueLoans},
+	}
+	// deleted books are purged only when a retention is configured
+	if cfg.Book.DeletedRetention > 0 {
+		jobs = append(jobs, worker.Job{Name: "deleted_books_purge", Interval: cfg.Book.PurgeInterval, Run: bookSrv.PurgeDeletedBooks})
+	}
+	worker.New(jobs...).Run(ctx, wg)
+
+	// create authenticators
+	authenticators, err := auth.New(cfg.HTTPServer.Auth)
+	if err != nil {
+		log.WithError(err).Fatal("auth init error")
+	}
+	if !cfg.HTTPServer.Auth.Enabled {
+		log.Warn("authentication is disabled, every route is open to anyone")
+	}
 
 	// initializing http server
 	httpSrv := http.New(
 		cfg.HTTPServer,
 		handlers.New(bookSrv),
+		authenticators...,
 	)
 	// run srv
 	httpSrv.Run(ctx, wg)
@@ -55,6 +91,29 @@ func main() { // nolint:funlen
 	log.Info("service stopped")
 }
 
+// newStorage creates the storage selected by the storage driver.
+func newStorage(ctx context.Context, wg *sync.WaitGroup, cfg *config.Config) (book.StorageManager, error) {
+	switch cfg.Storage.Driver {
+	case "postgres":
+		pg, err := postgres.New(ctx, wg, cfg.Postgres)
+		if err != nil {
+			return nil, err
+		}
+		return pg, nil
+	case "sqlite":
+		lite, err := sqlite.New(ctx, wg, cfg.Sqlite)
+		if err != nil {
+			return nil, err
+		}
+		return lite, nil
+	case "memory":
+		log.Warn("memory storage is used, the library is lost on shutdown")
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q, use 'postgres', 'sqlite' or 'memory'", cfg.Storage.Driver)
+	}
+}
+
 func initLogger(logLevel string) {
 	log.SetFormatter(&log.JSONFormatter{})
 	log.SetOutput(os.Stderr)
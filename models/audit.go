@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate   AuditAction = "create"
+	AuditUpdate   AuditAction = "update"
+	AuditDelete   AuditAction = "delete"
+	AuditCheckout AuditAction = "checkout"
+	AuditCheckin  AuditAction = "checkin"
+	AuditRate     AuditAction = "rate"
+	AuditRestore  AuditAction = "restore"
+	AuditPurge    AuditAction = "purge"
+)
+
+// AuditEntry records a mutation of a book: who made it, when, and the fields of
+// the book it changed with their values before and after. Before is empty for
+// created books, After for deleted ones.
+type AuditEntry struct {
+	tableName struct{} `pg:"book_audit"` // nolint
+
+	ID        int                    `json:"id" pg:",pk"`
+	BookID    int                    `json:"book_id" pg:"book_id"`
+	Actor     string                 `json:"actor" pg:"actor"`
+	Action    AuditAction            `json:"action" pg:"action"`
+	Before    map[string]interface{} `json:"before" pg:"before,type:jsonb"`
+	After     map[string]interface{} `json:"after" pg:"after,type:jsonb"`
+	CreatedAt time.Time              `json:"created_at" pg:"created_at,default:now()"`
+}
+
+// BookDiff returns the fields of the JSON representation of the book that
+// differ between before and after, with their values on each side. All the
+// fields are returned for a book that is created or deleted.
+func BookDiff(before, after *Book) (map[string]interface{}, map[string]interface{}, error) {
+	b, err := bookFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := bookFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b == nil || a == nil {
+		return b, a, nil
+	}
+	for k, v := range b {
+		if reflect.DeepEqual(v, a[k]) {
+			delete(b, k)
+			delete(a, k)
+		}
+	}
+	return b, a, nil
+}
+
+func bookFields(b *Book) (map[string]interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var res map[string]interface{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
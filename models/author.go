@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+type Author struct {
+	ID        int        `json:"id" pg:",pk"`
+	Name      string     `json:"name" pg:"name"`
+	CreatedAt *time.Time `pg:"default:now()" json:"-" `
+	UpdatedAt *time.Time `pg:"default:now()" json:"-" `
+}
+
+type Publisher struct {
+	ID        int        `json:"id" pg:",pk"`
+	Name      string     `json:"name" pg:"name"`
+	CreatedAt *time.Time `pg:"default:now()" json:"-" `
+	UpdatedAt *time.Time `pg:"default:now()" json:"-" `
+}
+
+// BookAuthor links a book to one of its authors, Position orders the authors of a book.
+type BookAuthor struct {
+	BookID   int `pg:",pk"`
+	AuthorID int `pg:",pk"`
+	Position int `pg:"position,use_zero"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "fmt"
+
+type BookOperationType int
+
+const (
+	OpCreate BookOperationType = iota
+	OpUpdate
+	OpDelete
+	// OpCheckout and OpCheckin change the status of a copy of the book.
+	OpCheckout
+	OpCheckin
+)
+
+// BookOperation is an operation of a batch. Create and update operations
+// carry the book, delete operations the id and version of the book. Status
+// changes carry the book, copy, borrower and due time of the loan as the
+// single checkouts and returns take them.
+type BookOperation struct {
+	Type BookOperationType
+	Book *Book
+	Loan *Loan
+}
+
+// BookOperationResult is the book written by an operation of a batch, with
+// the loan opened or closed by a status change.
+type BookOperationResult struct {
+	BookID  int
+	Version int
+	Loan    *Loan
+}
+
+// BatchError is the error of the operation at Index that rolled its batch back.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e BatchError) Unwrap() error {
+	return e.Err
+}
@@ -2,27 +2,108 @@ package models
 
 import "time"
 
+// Book is a title of the library. Author and Publisher hold the names of Authors
+// and of the publisher, they are maintained by the storage for search, sorting
+// and older clients. The copy counts and Status are maintained from the copies
+// of the book, retired copies are not counted and the book is checked out
+// when no copy is available. Version is incremented by every write of the
+// book, a version given to an update or a delete must be the stored one.
 type Book struct {
-	ID          int       `json:"id" pg:",pk"`
-	Title       string    `json:"title" pg:"title"`
-	Author      string    `json:"author" pg:"author"`
-	Publisher   string    `json:"publisher" pg:"publisher"`
-	PublishDate time.Time `json:"publish_date" pg:"publish_date"`
-	Rating      float64   `json:"rating" pg:"rating"`
-	Status      int       `json:"status" pg:"status"`
-	DeletedAt   *time.Time `pg:",soft_delete" json:"-" `
-	CreatedAt   *time.Time `pg:"default:now()" json:"-" `
-	UpdatedAt   *time.Time `pg:"default:now()" json:"-" `
+	ID              int        `json:"id" pg:",pk"`
+	Title           string     `json:"title" pg:"title"`
+	ISBN            string     `json:"isbn" pg:"isbn"`
+	Author          string     `json:"author" pg:"author"`
+	Authors         []Author   `json:"authors" pg:"-"`
+	Publisher       string     `json:"publisher" pg:"publisher"`
+	PublisherID     int        `json:"publisher_id" pg:"publisher_id"`
+	PublishDate     time.Time  `json:"publish_date" pg:"publish_date"`
+	Rating          float64    `json:"rating" pg:"rating"`
+	RatingCount     int        `json:"rating_count" pg:"rating_count"`
+	Status          BookStatus `json:"status" pg:"status"`
+	TotalCopies     int        `json:"total_copies" pg:"total_copies"`
+	AvailableCopies int        `json:"available_copies" pg:"available_copies"`
+	Version         int        `json:"version" pg:"version"`
+	DeletedAt       *time.Time `pg:",soft_delete" json:"-" `
+	CreatedAt       *time.Time `pg:"default:now()" json:"-" `
+	UpdatedAt       *time.Time `pg:"default:now()" json:"-" `
+}
+
+// BookStatus is the circulation status of a book copy. It is maintained by the loan
+// operations: opening a loan checks the copy out, closing it checks the copy back in.
+type BookStatus int
+
+const (
+	StatusCheckedIn BookStatus = iota
+	StatusCheckedOut
+	// StatusOnHold copies are kept for the patron of a ready hold.
+	StatusOnHold
+)
+
+// bookTransitions lists the statuses a book is allowed to move to from each status
+// by checkouts and returns. Copies are put on hold and released by the hold queue.
+var bookTransitions = map[BookStatus][]BookStatus{
+	StatusCheckedIn:  {StatusCheckedOut},
+	StatusCheckedOut: {StatusCheckedIn},
+	StatusOnHold:     {StatusCheckedOut},
+}
+
+// CanTransitionTo reports whether a book in status s may be moved to status to.
+func (s BookStatus) CanTransitionTo(to BookStatus) bool {
+	for _, st := range bookTransitions[s] {
+		if st == to {
+			return true
+		}
+	}
+	return false
+}
+
+// BookSortFields are the fields books can be sorted by.
+var BookSortFields = []string{"id", "title", "author", "publisher", "publish_date", "rating"}
+
+// SortRelevance sorts books found by a full-text query by how well they match it.
+const SortRelevance = "relevance"
+
+// SortValue returns the value of one of BookSortFields.
+func (b *Book) SortValue(field string) interface{} {
+	switch field {
+	case "title":
+		return b.Title
+	case "author":
+		return b.Author
+	case "publisher":
+		return b.Publisher
+	case "publish_date":
+		return b.PublishDate
+	case "rating":
+		return b.Rating
+	default:
+		return b.ID
+	}
 }
 
 type BookSearch struct {
+	// Query is a full-text query matched against title, author and publisher.
+	Query             string
 	Title             string
 	Author            string
 	Publisher         string
-	Status            *int
+	Status            *BookStatus
 	PublishDateSearch *PublishDateSearch
+	AuthorID          *int
+	PublisherID       *int
+	Deleted           DeletedFilter
 }
 
+// DeletedFilter selects books by whether they are soft-deleted, deleted books
+// are excluded by default.
+type DeletedFilter int
+
+const (
+	DeletedExclude DeletedFilter = iota
+	DeletedInclude
+	DeletedOnly
+)
+
 type PublishDateSearch struct {
 	PublishDate string
 	Condition   string
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Copy is a physical item of a book. Loans are made on copies, the status of
+// a copy changes the same way the status of a book used to.
+type Copy struct {
+	ID        int        `json:"id" pg:",pk"`
+	BookID    int        `json:"book_id" pg:"book_id"`
+	Barcode   string     `json:"barcode" pg:"barcode"`
+	Condition string     `json:"condition" pg:"condition"`
+	Location  string     `json:"location" pg:"location"`
+	Status    BookStatus `json:"status" pg:"status"`
+	RetiredAt *time.Time `json:"retired_at" pg:"retired_at"`
+	CreatedAt *time.Time `pg:"default:now()" json:"-" `
+	UpdatedAt *time.Time `pg:"default:now()" json:"-" `
+}
+
+// CopyConditions are the conditions a copy can be in.
+var CopyConditions = []interface{}{"new", "good", "fair", "poor", "damaged"}
+
+// IsAvailable reports whether the copy can be checked out.
+func (c *Copy) IsAvailable() bool {
+	return c.RetiredAt == nil && c.Status == StatusCheckedIn
+}
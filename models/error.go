@@ -26,3 +26,37 @@ type ErrBadRequest apiError
 func (e ErrBadRequest) Error() string {
 	return e.Message
 }
+
+type ErrConflict apiError
+
+func (e ErrConflict) Error() string {
+	return e.Message
+}
+
+type ErrUnauthorized apiError
+
+func (e ErrUnauthorized) Error() string {
+	return e.Message
+}
+
+type ErrForbidden apiError
+
+func (e ErrForbidden) Error() string {
+	return e.Message
+}
+
+// ErrPreconditionFailed is returned when the resource changed since the
+// version the client based its request on.
+type ErrPreconditionFailed apiError
+
+func (e ErrPreconditionFailed) Error() string {
+	return e.Message
+}
+
+// ErrUnsupportedMediaType is returned for request bodies of a content type
+// the endpoint does not accept.
+type ErrUnsupportedMediaType apiError
+
+func (e ErrUnsupportedMediaType) Error() string {
+	return e.Message
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Fine is charged for a loan returned after its due date. Amounts are in the
+// smallest currency unit, e.g. cents. AccruedUntil is the time the amount was
+// computed for, the return time once the loan is returned.
+type Fine struct {
+	ID           int        `json:"id" pg:",pk"`
+	LoanID       int        `json:"loan_id" pg:"loan_id"`
+	PatronID     int        `json:"patron_id" pg:"patron_id"`
+	Amount       int        `json:"amount" pg:"amount"`
+	Status       FineStatus `json:"status" pg:"status"`
+	AccruedUntil time.Time  `json:"accrued_until" pg:"accrued_until"`
+	SettledAt    *time.Time `json:"settled_at" pg:"settled_at"`
+	CreatedAt    time.Time  `json:"created_at" pg:"created_at,default:now()"`
+	UpdatedAt    *time.Time `pg:"default:now()" json:"-" `
+}
+
+type FineStatus int
+
+const (
+	FineOpen FineStatus = iota
+	FinePaid
+	FineWaived
+)
+
+// FinePolicy is how fines accrue: DailyRate for every started day a loan is
+// overdue after the GracePeriod, up to MaxAmount per loan. A MaxAmount that is
+// not positive sets no maximum.
+type FinePolicy struct {
+	DailyRate   int           `mapstructure:"daily_rate"   default:"25"`
+	MaxAmount   int           `mapstructure:"max_amount"   default:"1000"`
+	GracePeriod time.Duration `mapstructure:"grace_period" default:"0s"`
+}
+
+// Amount returns the fine of a loan overdue for the given started days.
+func (p FinePolicy) Amount(days int) int {
+	amount := p.DailyRate * days
+	if p.MaxAmount > 0 && amount > p.MaxAmount {
+		return p.MaxAmount
+	}
+	return amount
+}
+
+type FineSearch struct {
+	PatronID *int
+	Status   *FineStatus
+}
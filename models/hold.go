@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Hold queues a patron for a book that has no available copy. Holds of a book
+// are served in the order they were placed: a returned copy is kept for the
+// first waiting hold until the hold expires.
+type Hold struct {
+	ID        int        `json:"id" pg:",pk"`
+	BookID    int        `json:"book_id" pg:"book_id"`
+	PatronID  int        `json:"patron_id" pg:"patron_id"`
+	CopyID    *int       `json:"copy_id" pg:"copy_id"`
+	Status    HoldStatus `json:"status" pg:"status"`
+	ReadyAt   *time.Time `json:"ready_at" pg:"ready_at"`
+	ExpiresAt *time.Time `json:"expires_at" pg:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" pg:"created_at,default:now()"`
+	UpdatedAt *time.Time `pg:"default:now()" json:"-" `
+}
+
+type HoldStatus int
+
+const (
+	// HoldWaiting holds wait in the queue of the book.
+	HoldWaiting HoldStatus = iota
+	// HoldReady holds have a copy kept for the patron until they expire.
+	HoldReady
+	HoldFulfilled
+	HoldCancelled
+	HoldExpired
+)
+
+// IsActive reports whether the hold is still waiting for or keeping a copy.
+func (s HoldStatus) IsActive() bool {
+	return s == HoldWaiting || s == HoldReady
+}
+
+type HoldSearch struct {
+	BookID   *int
+	PatronID *int
+	CopyID   *int
+	Status   *HoldStatus
+	Active   *bool
+}
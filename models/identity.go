@@ -0,0 +1,42 @@
+package models
+
+import "context"
+
+type Role string
+
+const (
+	RoleAnonymous Role = "anonymous"
+	RolePatron    Role = "patron"
+	RoleLibrarian Role = "librarian"
+	RoleAdmin     Role = "admin"
+)
+
+// Identity describes the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Method  string
+	Role    Role
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying the caller identity.
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the caller identity stored in ctx, if any.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// ActorFromContext returns the subject of the caller identity stored in ctx,
+// or the anonymous role if the caller is not identified.
+func ActorFromContext(ctx context.Context) string {
+	id, ok := IdentityFromContext(ctx)
+	if !ok || id.Subject == "" {
+		return string(RoleAnonymous)
+	}
+	return id.Subject
+}
@@ -0,0 +1,19 @@
+package models
+
+// ImportResult is the outcome of importing a book: the id of the created
+// book, or why the book is a duplicate or was rejected.
+type ImportResult struct {
+	Status  ImportStatus
+	BookID  int
+	Message string
+}
+
+type ImportStatus int
+
+const (
+	ImportCreated ImportStatus = iota
+	// ImportDuplicate books have the ISBN of a stored book or of a book
+	// imported before them.
+	ImportDuplicate
+	ImportRejected
+)
@@ -0,0 +1,56 @@
+package models
+
+import "strings"
+
+// NormalizeISBN validates the checksum of an ISBN-10 or ISBN-13, with or
+// without hyphens and spaces, and returns it as ISBN-13 digits.
+func NormalizeISBN(isbn string) (string, error) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+	switch {
+	case len(s) == 10 && validISBN10(s):
+		s = "978" + s[:9]
+		return s + string(isbn13CheckDigit(s)), nil
+	case len(s) == 13 && validISBN13(s):
+		return s, nil
+	}
+	return "", ErrBadRequest{Message: "invalid isbn, use a valid ISBN-10 or ISBN-13"}
+}
+
+func validISBN10(s string) bool {
+	sum := 0
+	for i, c := range s {
+		var d int
+		switch {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case c == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0
+}
+
+func validISBN13(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return isbn13CheckDigit(s[:12]) == rune(s[12])
+}
+
+// isbn13CheckDigit computes the check digit of the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(s string) rune {
+	sum := 0
+	for i, c := range s[:12] {
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return rune('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,32 @@
+//go:build unit
+// +build unit
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	a := assert.New(t) // assertion object for comparing values
+
+	for _, tc := range []struct {
+		isbn, want string
+	}{
+		{"978-0-306-40615-7", "9780306406157"},
+		{"9780306406157", "9780306406157"},
+		{"0-306-40615-2", "9780306406157"},
+		{"0 8044 2957 x", "9780804429573"},
+		{"080442957X", "9780804429573"},
+	} {
+		got, err := NormalizeISBN(tc.isbn)
+		a.NoError(err, tc.isbn)
+		a.Equal(tc.want, got, tc.isbn)
+	}
+	for _, isbn := range []string{"", "978-0-306-40615-8", "0-306-40615-3", "03064061X2", "97803064061", "abcdefghij"} {
+		_, err := NormalizeISBN(isbn)
+		a.IsType(ErrBadRequest{}, err, isbn)
+	}
+}
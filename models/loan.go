@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Loan of a copy to a borrower. OverdueAt is when the overdue scan found the
+// loan open after its due date.
+type Loan struct {
+	ID           int        `json:"id" pg:",pk"`
+	BookID       int        `json:"book_id" pg:"book_id"`
+	CopyID       int        `json:"copy_id" pg:"copy_id"`
+	BorrowerID   int        `json:"borrower_id" pg:"borrower_id"`
+	CheckedOutAt time.Time  `json:"checked_out_at" pg:"checked_out_at,default:now()"`
+	DueAt        time.Time  `json:"due_at" pg:"due_at"`
+	ReturnedAt   *time.Time `json:"returned_at" pg:"returned_at"`
+	OverdueAt    *time.Time `json:"overdue_at" pg:"overdue_at"`
+}
+
+// IsOverdue reports whether the loan is still open after its due date.
+func (l *Loan) IsOverdue(now time.Time) bool {
+	return l.ReturnedAt == nil && l.DueAt.Before(now)
+}
+
+type LoanSearch struct {
+	BookID     *int
+	BorrowerID *int
+	Open       *bool
+	Overdue    bool
+}
@@ -0,0 +1,33 @@
+package models
+
+// Page selects a page of a listing. A page either continues after a cursor
+// returned with the previous page or starts at an offset.
+type Page struct {
+	Limit     int
+	Offset    int
+	After     *Cursor
+	WithTotal bool
+	Sort      []SortField
+}
+
+// SortField orders a listing by a field. Rows with equal values of all sort
+// fields are ordered by id.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor is the keyset position of the last row of a page: the values of
+// the sort fields and the id of the row.
+type Cursor struct {
+	ID     int           `json:"id"`
+	Values []interface{} `json:"values,omitempty"`
+}
+
+type BookList struct {
+	Books []Book
+	// Next is the cursor of the following page, nil on the last page.
+	Next *Cursor
+	// Total is the number of books matching the search, counted only if requested.
+	Total int
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Patron is a member of the library. Suspended patrons may not borrow books,
+// active ones may have at most LoanLimit books checked out at a time.
+type Patron struct {
+	ID               int          `json:"id" pg:",pk"`
+	MembershipNumber string       `json:"membership_number" pg:"membership_number"`
+	Name             string       `json:"name" pg:"name"`
+	Email            string       `json:"email" pg:"email"`
+	Phone            string       `json:"phone" pg:"phone"`
+	Address          string       `json:"address" pg:"address"`
+	Status           PatronStatus `json:"status" pg:"status,use_zero"`
+	LoanLimit        int          `json:"loan_limit" pg:"loan_limit,use_zero"`
+	CreatedAt        time.Time    `json:"created_at" pg:"created_at,default:now()"`
+	UpdatedAt        *time.Time   `pg:"default:now()" json:"-" `
+}
+
+type PatronStatus int
+
+const (
+	PatronActive PatronStatus = iota
+	PatronSuspended
+)
+
+// PatronSearch filters patrons, Query matches the name, email or membership
+// number. MembershipNumber selects the patron with exactly that number.
+type PatronSearch struct {
+	Query            string
+	MembershipNumber string
+	Status           *PatronStatus
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Rating is the rating of a book by a user, identified by the subject the
+// user authenticates as.
+type Rating struct {
+	tableName struct{} `pg:"book_ratings"` // nolint
+
+	BookID    int        `json:"book_id" pg:",pk"`
+	UserID    string     `json:"user_id" pg:",pk"`
+	Rating    int        `json:"rating" pg:"rating"`
+	CreatedAt *time.Time `pg:"default:now()" json:"-" `
+	UpdatedAt *time.Time `pg:"default:now()" json:"-" `
+}
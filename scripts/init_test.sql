@@ -1,2 +1,4 @@
 SELECT 'CREATE DATABASE libreria_test'
 WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'libreria_test')\gexec
+SELECT 'CREATE DATABASE libreria_storage_test'
+WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'libreria_storage_test')\gexec
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyScheme = "ApiKey"
+
+	MethodAPIKey = "api_key"
+)
+
+// APIKeys authenticates callers by a static key sent either in the X-API-Key
+// header or as "Authorization: ApiKey <key>".
+type APIKeys struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	key     []byte
+	subject string
+}
+
+func NewAPIKeys(pairs []string) (*APIKeys, error) {
+	a := &APIKeys{}
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid api key %q, use 'key=subject'", p)
+		}
+		a.keys = append(a.keys, apiKey{key: []byte(kv[0]), subject: kv[1]})
+	}
+	return a, nil
+}
+
+func (a *APIKeys) Authenticate(r *http.Request) (*models.Identity, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		var ok bool
+		if key, ok = authorizationValue(r, apiKeyScheme); !ok {
+			return nil, ErrNoCredentials
+		}
+	}
+	var subject string
+	// compare with every key so the response time does not reveal which one matched
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			subject = k.subject
+		}
+	}
+	if subject == "" {
+		return nil, models.ErrUnauthorized{Message: "invalid api key"}
+	}
+	return &models.Identity{Subject: subject, Method: MethodAPIKey}, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// none of the credentials it understands, so the next one can be tried.
+var ErrNoCredentials = errors.New("no credentials")
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*models.Identity, error)
+}
+
+type Config struct {
+	Enabled       bool     `mapstructure:"enabled"        default:"false"`
+	AnonymousRead bool     `mapstructure:"anonymous_read" default:"true"`
+	APIKeys       []string `mapstructure:"api_keys"`
+	JWTSecret     string   `mapstructure:"jwt_secret"`
+	JWTIssuer     string   `mapstructure:"jwt_issuer"`
+	JWTAudience   string   `mapstructure:"jwt_audience"`
+	Roles         []string `mapstructure:"roles"`
+	DefaultRole   string   `mapstructure:"default_role"   default:"patron"`
+}
+
+// New builds the authenticators configured in cfg. API keys are given as
+// "key=subject" pairs, JWT validation is enabled by setting the HMAC secret.
+// Roles are assigned to subjects by "subject=role" pairs, subjects without
+// an assignment get the default role.
+func New(cfg Config) ([]Authenticator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	ra, err := newRoleAssignment(cfg.Roles, cfg.DefaultRole)
+	if err != nil {
+		return nil, err
+	}
+	var res []Authenticator
+	if len(cfg.APIKeys) > 0 {
+		keys, err := NewAPIKeys(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, withRoles{Authenticator: keys, ra: ra})
+	}
+	if cfg.JWTSecret != "" {
+		res = append(res, withRoles{Authenticator: NewJWT([]byte(cfg.JWTSecret), cfg.JWTIssuer, cfg.JWTAudience), ra: ra})
+	}
+	if len(res) == 0 {
+		return nil, errors.New("authentication is enabled but neither api keys nor jwt secret are configured")
+	}
+	return res, nil
+}
+
+// authorizationValue returns the credentials of the Authorization header if it uses the given scheme.
+func authorizationValue(r *http.Request, scheme string) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) <= len(scheme)+1 || !strings.EqualFold(h[:len(scheme)], scheme) || h[len(scheme)] != ' ' {
+		return "", false
+	}
+	return strings.TrimSpace(h[len(scheme)+1:]), true
+}
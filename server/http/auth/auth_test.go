@@ -0,0 +1,118 @@
+//go:build unit
+// +build unit
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWT_Authenticate(t *testing.T) {
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	j := NewJWT([]byte("secret"), "libreria", "api")
+	now := time.Now()
+	sign := func(c *Claims) string {
+		token, err := j.Sign(c)
+		req.NoError(err)
+		return token
+	}
+	authenticate := func(token string) (*models.Identity, error) {
+		r := httptest.NewRequest(http.MethodPost, "/books", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return j.Authenticate(r)
+	}
+	t.Run("happy_path", func(t *testing.T) {
+		id, err := authenticate(sign(&Claims{
+			Subject:   "alice",
+			Issuer:    "libreria",
+			Audience:  audience{"api"},
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		}))
+		req.NoError(err)
+		a.Equal("alice", id.Subject)
+		a.Equal(MethodJWT, id.Method)
+	})
+	t.Run("expired", func(t *testing.T) {
+		_, err := authenticate(sign(&Claims{
+			Subject:   "alice",
+			Issuer:    "libreria",
+			Audience:  audience{"api"},
+			ExpiresAt: now.Add(-time.Hour).Unix(),
+		}))
+		a.IsType(models.ErrUnauthorized{}, err)
+	})
+	t.Run("no_expiration", func(t *testing.T) {
+		_, err := authenticate(sign(&Claims{Subject: "alice", Issuer: "libreria", Audience: audience{"api"}}))
+		a.Equal(models.ErrUnauthorized{Message: "token has no expiration"}, err)
+	})
+	t.Run("wrong_audience", func(t *testing.T) {
+		_, err := authenticate(sign(&Claims{
+			Subject:   "alice",
+			Issuer:    "libreria",
+			Audience:  audience{"web"},
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		}))
+		a.Equal(models.ErrUnauthorized{Message: "invalid token audience"}, err)
+	})
+	t.Run("wrong_secret", func(t *testing.T) {
+		token, err := NewJWT([]byte("other"), "libreria", "api").Sign(&Claims{
+			Subject:   "alice",
+			Issuer:    "libreria",
+			Audience:  audience{"api"},
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		})
+		req.NoError(err)
+		_, err = authenticate(token)
+		a.IsType(models.ErrUnauthorized{}, err)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := authenticate("abc")
+		a.IsType(models.ErrUnauthorized{}, err)
+	})
+	t.Run("no_credentials", func(t *testing.T) {
+		_, err := j.Authenticate(httptest.NewRequest(http.MethodPost, "/books", nil))
+		a.True(errors.Is(err, ErrNoCredentials))
+	})
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	keys, err := NewAPIKeys([]string{"k1=alice", "k2=bob"})
+	req.NoError(err)
+	t.Run("header", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/books", nil)
+		r.Header.Set("X-API-Key", "k2")
+		id, err := keys.Authenticate(r)
+		req.NoError(err)
+		a.Equal("bob", id.Subject)
+	})
+	t.Run("authorization", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/books", nil)
+		r.Header.Set("Authorization", "ApiKey k1")
+		id, err := keys.Authenticate(r)
+		req.NoError(err)
+		a.Equal("alice", id.Subject)
+	})
+	t.Run("invalid_key", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/books", nil)
+		r.Header.Set("X-API-Key", "k3")
+		_, err := keys.Authenticate(r)
+		a.IsType(models.ErrUnauthorized{}, err)
+	})
+	t.Run("invalid_config", func(t *testing.T) {
+		_, err := NewAPIKeys([]string{"k1"})
+		a.Error(err)
+	})
+}
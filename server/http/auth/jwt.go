@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+const (
+	bearerScheme = "Bearer"
+	// leeway tolerates clock skew between the token issuer and this service
+	leeway = time.Minute
+
+	MethodJWT = "jwt"
+)
+
+var jwtAlgorithms = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// JWT authenticates callers by an HMAC-signed JSON Web Token sent as
+// "Authorization: Bearer <token>". Tokens are validated locally with the shared secret.
+type JWT struct {
+	secret   []byte
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+func NewJWT(secret []byte, issuer, audience string) *JWT {
+	return &JWT{
+		secret:   secret,
+		issuer:   issuer,
+		audience: audience,
+		now:      time.Now,
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// Claims are the registered JWT claims the service understands.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// audience is either a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return err
+	}
+	*a = ss
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *JWT) Authenticate(r *http.Request) (*models.Identity, error) {
+	token, ok := authorizationValue(r, bearerScheme)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+	claims, err := j.Parse(token)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Identity{Subject: claims.Subject, Method: MethodJWT}, nil
+}
+
+// Parse verifies the token signature and registered claims and returns the
+// claims. Tokens have to expire, a leaked token without exp would be valid
+// forever.
+func (j *JWT) Parse(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalidToken("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalidToken("malformed token header")
+	}
+	newHash, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return nil, invalidToken("unsupported signing algorithm")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalidToken("malformed token signature")
+	}
+	mac := hmac.New(newHash, j.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, invalidToken("invalid token signature")
+	}
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalidToken("malformed token claims")
+	}
+	now := j.now()
+	switch {
+	case claims.Subject == "":
+		return nil, invalidToken("token has no subject")
+	case claims.ExpiresAt == 0:
+		return nil, invalidToken("token has no expiration")
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)):
+		return nil, invalidToken("token is expired")
+	case claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, invalidToken("token is not valid yet")
+	case j.issuer != "" && claims.Issuer != j.issuer:
+		return nil, invalidToken("invalid token issuer")
+	case j.audience != "" && !claims.Audience.contains(j.audience):
+		return nil, invalidToken("invalid token audience")
+	}
+	return &claims, nil
+}
+
+// Sign creates a token for the claims. It is meant for tests and tooling,
+// the service itself only validates tokens.
+func (j *JWT) Sign(claims *Claims) (string, error) {
+	header, err := json.Marshal(&jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func invalidToken(msg string) error {
+	return models.ErrUnauthorized{Message: msg}
+}
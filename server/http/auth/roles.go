@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+type Permission string
+
+const (
+	PermReadBooks   Permission = "books:read"
+	PermEditBooks   Permission = "books:edit"
+	PermDeleteBooks Permission = "books:delete"
+	PermPurgeBooks  Permission = "books:purge"
+	PermRateBooks   Permission = "books:rate"
+	PermCirculate   Permission = "books:circulate"
+	PermReadLoans   Permission = "loans:read"
+	PermPlaceHolds  Permission = "holds:place"
+	PermReadFines   Permission = "fines:read"
+	PermReadHistory Permission = "books:history"
+	PermReadPatrons Permission = "patrons:read"
+	PermEditPatrons Permission = "patrons:edit"
+)
+
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleAnonymous: {PermReadBooks},
+	models.RolePatron:    {PermReadBooks, PermRateBooks, PermPlaceHolds, PermReadFines},
+	models.RoleLibrarian: {PermReadBooks, PermRateBooks, PermPlaceHolds, PermReadFines, PermEditBooks, PermDeleteBooks, PermCirculate, PermReadLoans, PermReadHistory, PermReadPatrons, PermEditPatrons},
+	models.RoleAdmin:     {PermReadBooks, PermRateBooks, PermPlaceHolds, PermReadFines, PermEditBooks, PermDeleteBooks, PermCirculate, PermReadLoans, PermReadHistory, PermReadPatrons, PermEditPatrons, PermPurgeBooks},
+}
+
+// Allowed reports whether the role grants the permission.
+func Allowed(role models.Role, p Permission) bool {
+	for _, rp := range rolePermissions[role] {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
+
+// roleAssignment maps subjects to the roles given in the configuration.
+type roleAssignment struct {
+	roles       map[string]models.Role
+	defaultRole models.Role
+}
+
+func newRoleAssignment(pairs []string, defaultRole string) (*roleAssignment, error) {
+	ra := &roleAssignment{roles: make(map[string]models.Role, len(pairs))}
+	var err error
+	if ra.defaultRole, err = parseRole(defaultRole); err != nil {
+		return nil, err
+	}
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid role assignment %q, use 'subject=role'", p)
+		}
+		if ra.roles[kv[0]], err = parseRole(kv[1]); err != nil {
+			return nil, err
+		}
+	}
+	return ra, nil
+}
+
+func (ra *roleAssignment) role(subject string) models.Role {
+	if role, ok := ra.roles[subject]; ok {
+		return role
+	}
+	return ra.defaultRole
+}
+
+// withRoles assigns roles to the identities returned by the wrapped authenticator.
+type withRoles struct {
+	Authenticator
+	ra *roleAssignment
+}
+
+func (wr withRoles) Authenticate(r *http.Request) (*models.Identity, error) {
+	id, err := wr.Authenticator.Authenticate(r)
+	if err != nil {
+		return nil, err
+	}
+	id.Role = wr.ra.role(id.Subject)
+	return id, nil
+}
+
+func parseRole(s string) (models.Role, error) {
+	role := models.Role(strings.ToLower(s))
+	if _, ok := rolePermissions[role]; !ok || role == models.RoleAnonymous {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+func (h *Book) GetBookHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	entries, err := h.bk.GetBookHistory(r.Context(), id, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.AuditEntryResponse, len(entries))
+	for i := range entries {
+		resp[i] = toAuditEntryResponse(&entries[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func toAuditEntryResponse(e *models.AuditEntry) hm.AuditEntryResponse {
+	return hm.AuditEntryResponse{
+		ID:        e.ID,
+		BookID:    e.BookID,
+		Actor:     e.Actor,
+		Action:    string(e.Action),
+		Before:    e.Before,
+		After:     e.After,
+		CreatedAt: e.CreatedAt,
+	}
+}
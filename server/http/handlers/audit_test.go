@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_GetBookHistory(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                           // router
+	router.HandleFunc("/books/{id}/history", oh.GetBookHistory).Methods(http.MethodGet) // book history route
+	srv := httptest.NewServer(router)                                                   // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().GetBookHistory(gomock.Any(), 1, 10, 20).Return([]models.AuditEntry{
+			{ID: 1, BookID: 1, Actor: "alice", Action: models.AuditCreate, After: map[string]interface{}{"title": "Dune"}},
+			{ID: 2, BookID: 1, Actor: "bob", Action: models.AuditUpdate,
+				Before: map[string]interface{}{"title": "Dune"}, After: map[string]interface{}{"title": "Dune Messiah"}},
+		}, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/books/1/history?limit=10&offset=20", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+
+		var entries []hm.AuditEntryResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&entries))
+		req.Len(entries, 2)
+		a.Equal("create", entries[0].Action)
+		a.Nil(entries[0].Before)
+		a.Equal("bob", entries[1].Actor)
+		a.Equal("Dune Messiah", entries[1].After["title"])
+	})
+	t.Run("invalid_limit", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books/1/history?limit=abc", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/auth"
+)
+
+// Authenticate identifies the caller with the first authenticator that finds
+// credentials in the request and puts the identity on the request context.
+// Requests with invalid credentials are rejected. Requests without credentials
+// are rejected too, unless anonymousRead is set and the method is safe.
+func Authenticate(anonymousRead bool, authenticators ...auth.Authenticator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				id, err := a.Authenticate(r)
+				if errors.Is(err, auth.ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					sendHTTPError(w, err)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(models.WithIdentity(r.Context(), id)))
+				return
+			}
+			if anonymousRead && isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			sendHTTPError(w, models.ErrUnauthorized{Message: "authentication required"})
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Authorize lets the request through only if the role of the caller grants the
+// permission. Anonymous callers are rejected as unauthenticated, identified
+// callers lacking the permission as forbidden.
+func Authorize(p auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := models.RoleAnonymous
+		id, ok := models.IdentityFromContext(r.Context())
+		if ok {
+			role = id.Role
+		}
+		if auth.Allowed(role, p) {
+			next(w, r)
+			return
+		}
+		if !ok {
+			sendHTTPError(w, models.ErrUnauthorized{Message: "authentication required"})
+			return
+		}
+		sendHTTPError(w, models.ErrForbidden{Message: "insufficient permissions"})
+	}
+}
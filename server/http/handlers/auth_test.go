@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/server/http/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	authenticators, err := auth.New(auth.Config{
+		Enabled:       true,
+		AnonymousRead: true,
+		APIKeys:       []string{"patron-key=alice", "librarian-key=bob"},
+		Roles:         []string{"bob=librarian"},
+		DefaultRole:   "patron",
+	})
+	req.NoError(err)
+	ok := func(w http.ResponseWriter, r *http.Request) { sendEmptyResponse(w, http.StatusNoContent) }
+
+	router := mux.NewRouter() // router
+	router.Use(Authenticate(true, authenticators...))
+	router.HandleFunc("/books", Authorize(auth.PermReadBooks, ok)).Methods(http.MethodGet)
+	router.HandleFunc("/books/{id}", Authorize(auth.PermDeleteBooks, ok)).Methods(http.MethodDelete)
+	router.HandleFunc("/loans", Authorize(auth.PermReadLoans, ok)).Methods(http.MethodGet)
+	srv := httptest.NewServer(router) // test server
+	defer srv.Close()
+
+	do := func(method, path, key string) int {
+		request, err := http.NewRequest(method, fmt.Sprintf("%s%s", srv.URL, path), nil)
+		req.NoError(err)
+		if key != "" {
+			request.Header.Set("X-API-Key", key)
+		}
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		return res.StatusCode
+	}
+	t.Run("anonymous_read", func(t *testing.T) {
+		a.Equal(http.StatusNoContent, do(http.MethodGet, "/books", ""))
+	})
+	t.Run("anonymous_write", func(t *testing.T) {
+		a.Equal(http.StatusUnauthorized, do(http.MethodDelete, "/books/1", ""))
+	})
+	t.Run("anonymous_loans", func(t *testing.T) {
+		a.Equal(http.StatusUnauthorized, do(http.MethodGet, "/loans", ""))
+	})
+	t.Run("invalid_key", func(t *testing.T) {
+		a.Equal(http.StatusUnauthorized, do(http.MethodGet, "/books", "unknown-key"))
+	})
+	t.Run("patron_delete", func(t *testing.T) {
+		a.Equal(http.StatusForbidden, do(http.MethodDelete, "/books/1", "patron-key"))
+	})
+	t.Run("librarian_delete", func(t *testing.T) {
+		a.Equal(http.StatusNoContent, do(http.MethodDelete, "/books/1", "librarian-key"))
+	})
+}
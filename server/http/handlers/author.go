@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+func (h *Book) AddAuthor(w http.ResponseWriter, r *http.Request) {
+	var req hm.Author
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	author := &models.Author{Name: req.Name}
+	err = h.bk.AddAuthor(r.Context(), author)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toAuthorResponse(author)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
+}
+
+func (h *Book) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "author")
+		return
+	}
+	author, err := h.bk.GetAuthor(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toAuthorResponse(author)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	authors, err := h.bk.GetAuthors(r.Context(), r.URL.Query().Get("name"), limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.AuthorResponse, len(authors))
+	for i := range authors {
+		resp[i] = toAuthorResponse(&authors[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	var req hm.Author
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "author")
+		return
+	}
+	err = h.bk.UpdateAuthor(r.Context(), &models.Author{ID: id, Name: req.Name})
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func (h *Book) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "author")
+		return
+	}
+	err = h.bk.DeleteAuthor(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func (h *Book) ListAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "author")
+		return
+	}
+	sc, err := getSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sc.AuthorID = &id
+	h.listBooks(w, r, sc)
+}
+
+func toAuthorResponse(a *models.Author) hm.AuthorResponse {
+	return hm.AuthorResponse{
+		Author: hm.Author{Name: a.Name},
+		ID:     a.ID,
+	}
+}
@@ -0,0 +1,102 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_AddAuthor(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                            // router
+	router.HandleFunc("/authors", oh.AddAuthor).Methods(http.MethodPost) // create author route
+	srv := httptest.NewServer(router)                                    // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().AddAuthor(gomock.Any(), &models.Author{Name: "Ursula K. Le Guin"}).Return(nil)
+
+		reqBody, err := json.Marshal(&hm.Author{Name: "Ursula K. Le Guin"})
+		req.NoError(err)
+		res, err := http.Post(fmt.Sprintf("%s/authors", srv.URL), "application/json", bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+	})
+	t.Run("validation", func(t *testing.T) {
+		res, err := http.Post(fmt.Sprintf("%s/authors", srv.URL), "application/json", bytes.NewBufferString("{}"))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("conflict", func(t *testing.T) {
+		srvMock.EXPECT().AddAuthor(gomock.Any(), gomock.Any()).Return(models.ErrConflict{Message: "author already exists"})
+
+		res, err := http.Post(fmt.Sprintf("%s/authors", srv.URL), "application/json", bytes.NewBufferString(`{"name":"x"}`))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
+
+func TestBook_ListAuthorBooks(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                            // router
+	router.HandleFunc("/authors/{id}/books", oh.ListAuthorBooks).Methods(http.MethodGet) // list author books route
+	srv := httptest.NewServer(router)                                                    // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		authorID := 7
+		srvMock.EXPECT().GetBooks(gomock.Any(), &models.BookSearch{AuthorID: &authorID}, gomock.Any()).
+			Return(&models.BookList{Books: []models.Book{{ID: 1, Authors: []models.Author{{ID: 7}}}}}, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/authors/7/books", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+
+		var list hm.BookListResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&list))
+		req.Len(list.Books, 1)
+		a.Equal([]int{7}, list.Books[0].AuthorIDs)
+	})
+	t.Run("not_found", func(t *testing.T) {
+		srvMock.EXPECT().GetBooks(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, models.ErrNotFound{Message: "author does not exist"})
+
+		res, err := http.Get(fmt.Sprintf("%s/authors/8/books", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusNotFound, res.StatusCode) // Check if the Code is 404
+	})
+}
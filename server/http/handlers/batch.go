@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/auth"
+	hm "github.com/libreria/server/http/models"
+)
+
+// maxBatchOperations is the most operations a batch may have.
+const maxBatchOperations = 100
+
+// batchPermissions are the permissions the operations of a batch need.
+var batchPermissions = map[hm.BatchOp]auth.Permission{
+	hm.BatchCreate: auth.PermEditBooks,
+	hm.BatchUpdate: auth.PermEditBooks,
+	hm.BatchDelete: auth.PermDeleteBooks,
+	hm.BatchStatus: auth.PermCirculate,
+}
+
+// BatchBooks applies a list of operations on books in one transaction. If an
+// operation is invalid or fails nothing is applied and the batch is answered
+// with the status of the operation, the other operations fail as dependencies.
+func (h *Book) BatchBooks(w http.ResponseWriter, r *http.Request) {
+	var req hm.BatchRequest
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if len(req.Operations) == 0 {
+		sendHTTPError(w, models.ErrBadRequest{Message: "operations must not be empty"})
+		return
+	}
+	if len(req.Operations) > maxBatchOperations {
+		sendHTTPError(w, models.ErrBadRequest{Message: fmt.Sprintf("a batch must not exceed %d operations", maxBatchOperations)})
+		return
+	}
+	var (
+		ops     = make([]models.BookOperation, len(req.Operations))
+		results = make([]hm.BatchResult, len(req.Operations))
+		invalid bool
+	)
+	for i, o := range req.Operations {
+		err := o.Validate()
+		if err == nil {
+			err = authorizeOperation(r, batchPermissions[o.Op])
+		}
+		if err != nil {
+			results[i] = failedBatchResult(err)
+			invalid = true
+			continue
+		}
+		ops[i] = toBookOperation(&o)
+	}
+	if invalid {
+		sendBatchFailure(w, results)
+		return
+	}
+	res, err := h.bk.ApplyBookBatch(r.Context(), ops)
+	if be, ok := err.(models.BatchError); ok {
+		results[be.Index] = failedBatchResult(be.Err)
+		sendBatchFailure(w, results)
+		return
+	}
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	for i, or := range res {
+		results[i] = hm.BatchResult{Status: batchStatuses[ops[i].Type], ID: or.BookID, Version: or.Version}
+		if or.Loan != nil {
+			loan := toLoanResponse(or.Loan)
+			results[i].Loan = &loan
+		}
+	}
+	sendResponseWithBody(w, http.StatusOK, hm.BatchResponse{Committed: true, Results: results})
+}
+
+// batchStatuses are the status codes of applied operations, the ones of the
+// single operations.
+var batchStatuses = map[models.BookOperationType]int{
+	models.OpCreate:   http.StatusCreated,
+	models.OpUpdate:   http.StatusNoContent,
+	models.OpDelete:   http.StatusNoContent,
+	models.OpCheckout: http.StatusCreated,
+	models.OpCheckin:  http.StatusOK,
+}
+
+// authorizeOperation makes sure the caller, if identified, has the permission.
+// Anonymous callers are let through by the route only without authentication.
+func authorizeOperation(r *http.Request, p auth.Permission) error {
+	id, ok := models.IdentityFromContext(r.Context())
+	if ok && !auth.Allowed(id.Role, p) {
+		return models.ErrForbidden{Message: "insufficient permissions"}
+	}
+	return nil
+}
+
+func failedBatchResult(err error) hm.BatchResult {
+	code, message, _ := httpError(err)
+	return hm.BatchResult{Status: code, Error: message}
+}
+
+// sendBatchFailure answers a batch that was not applied with the status of its
+// first failed operation.
+func sendBatchFailure(w http.ResponseWriter, results []hm.BatchResult) {
+	code := 0
+	for i := range results {
+		if results[i].Status == 0 {
+			results[i].Status = http.StatusFailedDependency
+		} else if code == 0 {
+			code = results[i].Status
+		}
+	}
+	sendResponseWithBody(w, code, hm.BatchResponse{Results: results})
+}
+
+func toBookOperation(o *hm.BatchOperation) models.BookOperation {
+	switch o.Op {
+	case hm.BatchCreate:
+		return models.BookOperation{Type: models.OpCreate, Book: toBook(o.Book)}
+	case hm.BatchUpdate:
+		b := toBook(o.Book)
+		b.ID = o.ID
+		b.Version = o.Version
+		return models.BookOperation{Type: models.OpUpdate, Book: b}
+	case hm.BatchDelete:
+		return models.BookOperation{Type: models.OpDelete, Book: &models.Book{ID: o.ID, Version: o.Version}}
+	}
+	l := &models.Loan{BookID: o.ID, CopyID: o.CopyID, BorrowerID: o.BorrowerID}
+	if o.Status == hm.StatusCheckedIn {
+		return models.BookOperation{Type: models.OpCheckin, Loan: l}
+	}
+	if o.DueDate != nil {
+		l.DueAt = o.DueDate.UTC()
+	}
+	return models.BookOperation{Type: models.OpCheckout, Loan: l}
+}
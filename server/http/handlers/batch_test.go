@@ -0,0 +1,134 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_BatchBooks(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                 // router
+	router.HandleFunc("/books:batch", oh.BatchBooks).Methods(http.MethodPost) // batch books route
+	srv := httptest.NewServer(router)                                         // test server
+	defer srv.Close()
+
+	batch := func(body string) (*http.Response, hm.BatchResponse) {
+		res, err := srv.Client().Post(fmt.Sprintf("%s/books:batch", srv.URL), "application/json", bytes.NewBufferString(body))
+		req.NoError(err)
+		defer res.Body.Close()
+		var resp hm.BatchResponse
+		_ = json.NewDecoder(res.Body).Decode(&resp) // errors of the whole batch have no results
+		return res, resp
+	}
+	book := `{"name":"my_title","author":"my_author","publisher":"my_publisher","publish_date":"2001-02-03T00:00:00Z"}`
+	dueDate := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	t.Run("success", func(t *testing.T) {
+		srvMock.EXPECT().ApplyBookBatch(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, ops []models.BookOperation) ([]models.BookOperationResult, error) {
+				req.Len(ops, 4)
+				a.Equal(models.OpCreate, ops[0].Type)
+				a.Equal("my_title", ops[0].Book.Title)
+				a.Equal(models.OpUpdate, ops[1].Type)
+				a.Equal(2, ops[1].Book.ID)
+				a.Equal(3, ops[1].Book.Version)
+				a.Equal(models.BookOperation{Type: models.OpDelete, Book: &models.Book{ID: 4}}, ops[2])
+				a.Equal(models.BookOperation{Type: models.OpCheckout, Loan: &models.Loan{
+					BookID: 5, CopyID: 6, BorrowerID: 7, DueAt: dueDate,
+				}}, ops[3])
+				return []models.BookOperationResult{
+					{BookID: 11, Version: 1},
+					{BookID: 2, Version: 4},
+					{BookID: 4},
+					{BookID: 5, Version: 2, Loan: &models.Loan{ID: 8, BookID: 5, CopyID: 6, BorrowerID: 7, DueAt: dueDate}},
+				}, nil
+			})
+
+		res, resp := batch(fmt.Sprintf(`{"operations":[
+			{"op":"create","book":%s},
+			{"op":"update","id":2,"version":3,"book":%s},
+			{"op":"delete","id":4},
+			{"op":"status","id":5,"status":"CheckedOut","copy_id":6,"borrower_id":7,"due_date":"%s"}
+		]}`, book, book, dueDate.Format(time.RFC3339)))
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.True(resp.Committed)
+		req.Len(resp.Results, 4)
+		a.Equal(hm.BatchResult{Status: http.StatusCreated, ID: 11, Version: 1}, resp.Results[0])
+		a.Equal(hm.BatchResult{Status: http.StatusNoContent, ID: 2, Version: 4}, resp.Results[1])
+		a.Equal(hm.BatchResult{Status: http.StatusNoContent, ID: 4}, resp.Results[2])
+		a.Equal(http.StatusCreated, resp.Results[3].Status)
+		req.NotNil(resp.Results[3].Loan)
+		a.Equal(8, resp.Results[3].Loan.ID)
+	})
+	t.Run("checkin", func(t *testing.T) {
+		srvMock.EXPECT().ApplyBookBatch(gomock.Any(), []models.BookOperation{
+			{Type: models.OpCheckin, Loan: &models.Loan{BookID: 5, CopyID: 6}},
+		}).Return([]models.BookOperationResult{{BookID: 5, Version: 3, Loan: &models.Loan{ID: 8}}}, nil)
+
+		res, resp := batch(`{"operations":[{"op":"status","id":5,"status":"CheckedIn","copy_id":6}]}`)
+		a.Equal(http.StatusOK, res.StatusCode)
+		req.Len(resp.Results, 1)
+		a.Equal(http.StatusOK, resp.Results[0].Status)
+	})
+	t.Run("failed_operation", func(t *testing.T) {
+		srvMock.EXPECT().ApplyBookBatch(gomock.Any(), gomock.Len(2)).
+			Return(nil, models.BatchError{Index: 1, Err: models.ErrPreconditionFailed{Message: "version mismatch"}})
+
+		res, resp := batch(fmt.Sprintf(`{"operations":[{"op":"create","book":%s},{"op":"delete","id":4,"version":2}]}`, book))
+		a.Equal(http.StatusPreconditionFailed, res.StatusCode) // Check if the Code is 412
+		a.False(resp.Committed)
+		req.Len(resp.Results, 2)
+		a.Equal(hm.BatchResult{Status: http.StatusFailedDependency}, resp.Results[0])
+		a.Equal(hm.BatchResult{Status: http.StatusPreconditionFailed, Error: "version mismatch"}, resp.Results[1])
+	})
+	t.Run("invalid_operation", func(t *testing.T) {
+		res, resp := batch(fmt.Sprintf(`{"operations":[{"op":"create","book":%s},{"op":"status","id":5,"status":"Lost"}]}`, book))
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+		a.False(resp.Committed)
+		req.Len(resp.Results, 2)
+		a.Equal(http.StatusFailedDependency, resp.Results[0].Status)
+		a.Equal(http.StatusBadRequest, resp.Results[1].Status)
+		a.Contains(resp.Results[1].Error, "status")
+	})
+	t.Run("service_error", func(t *testing.T) {
+		srvMock.EXPECT().ApplyBookBatch(gomock.Any(), gomock.Any()).Return(nil, models.ErrInternal{Message: "db error"})
+
+		res, _ := batch(`{"operations":[{"op":"delete","id":4}]}`)
+		a.Equal(http.StatusInternalServerError, res.StatusCode) // Check if the Code is 500
+	})
+	t.Run("empty", func(t *testing.T) {
+		res, _ := batch(`{"operations":[]}`)
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("too_many_operations", func(t *testing.T) {
+		ops := strings.Repeat(`{"op":"delete","id":4},`, maxBatchOperations+1)
+		res, _ := batch(`{"operations":[` + strings.TrimSuffix(ops, ",") + `]}`)
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/libreria/models"
@@ -12,18 +13,62 @@ import (
 
 type BookKeeper interface {
 	AddBook(ctx context.Context, b *models.Book) error
+	ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error)
+	ApplyBookBatch(ctx context.Context, ops []models.BookOperation) ([]models.BookOperationResult, error)
 	GetBook(ctx context.Context, id int) (*models.Book, error)
-	GetBooks(ctx context.Context, bs *models.BookSearch, limit, offset int) ([]models.Book, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error)
+	ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error
 	UpdateBook(ctx context.Context, b *models.Book) error
-	UpdateBookStatus(ctx context.Context, id, status int) error
-	RateBook(ctx context.Context, id, rate int) error
-	DeleteBook(ctx context.Context, id int) error
+	RateBook(ctx context.Context, id int, userID string, rate int) error
+	DeleteBook(ctx context.Context, id, version int) error
+	RestoreBook(ctx context.Context, id int) (*models.Book, error)
+	PurgeBook(ctx context.Context, id, version int) error
+	GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error)
+	CheckoutBook(ctx context.Context, bookID, copyID, borrowerID int, dueAt time.Time) (*models.Loan, error)
+	ReturnBook(ctx context.Context, bookID, copyID int) (*models.Loan, error)
+	GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error)
+	AddPatron(ctx context.Context, p *models.Patron) error
+	GetPatron(ctx context.Context, id int) (*models.Patron, error)
+	GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error)
+	UpdatePatron(ctx context.Context, p *models.Patron) error
+	DeletePatron(ctx context.Context, id int) error
+	GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error)
+	PayFine(ctx context.Context, id int) (*models.Fine, error)
+	WaiveFine(ctx context.Context, id int) (*models.Fine, error)
+	AddHold(ctx context.Context, h *models.Hold) error
+	GetHold(ctx context.Context, id int) (*models.Hold, error)
+	GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error)
+	CancelHold(ctx context.Context, id int) (*models.Hold, error)
+	AddCopy(ctx context.Context, c *models.Copy) error
+	GetCopy(ctx context.Context, id int) (*models.Copy, error)
+	GetCopies(ctx context.Context, bookID int) ([]models.Copy, error)
+	RetireCopy(ctx context.Context, id int) (*models.Copy, error)
+	AddAuthor(ctx context.Context, a *models.Author) error
+	GetAuthor(ctx context.Context, id int) (*models.Author, error)
+	GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error)
+	UpdateAuthor(ctx context.Context, a *models.Author) error
+	DeleteAuthor(ctx context.Context, id int) error
+	AddPublisher(ctx context.Context, p *models.Publisher) error
+	GetPublisher(ctx context.Context, id int) (*models.Publisher, error)
+	GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error)
+	UpdatePublisher(ctx context.Context, p *models.Publisher) error
+	DeletePublisher(ctx context.Context, id int) error
 }
 
+const totalCountHeader = "X-Total-Count"
+
 type Book struct {
 	bk BookKeeper
 }
 
+// bookStatuses maps book statuses to their API representation.
+var bookStatuses = map[models.BookStatus]hm.Status{
+	models.StatusCheckedIn:  hm.StatusCheckedIn,
+	models.StatusCheckedOut: hm.StatusCheckedOut,
+	models.StatusOnHold:     hm.StatusOnHold,
+}
+
 func New(bk BookKeeper) *Book {
 	return &Book{bk: bk}
 }
@@ -40,17 +85,14 @@ func (h *Book) AddBook(w http.ResponseWriter, r *http.Request) {
 		sendHTTPError(w, err)
 		return
 	}
-	book := &models.Book{
-		Title:       req.Title,
-		Author:      req.Author,
-		Publisher:   req.Publisher,
-		PublishDate: req.PublishDate.UTC(),
-	}
+	book := toBook(&req)
+	book.PublishDate = req.PublishDate.UTC()
 	err = h.bk.AddBook(r.Context(), book)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
+	w.Header().Set("ETag", bookETag(book.Version))
 	resp := toBookResponse(book)
 	sendResponseWithBody(w, http.StatusCreated, &resp)
 }
@@ -58,7 +100,7 @@ func (h *Book) AddBook(w http.ResponseWriter, r *http.Request) {
 func (h *Book) GetBook(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendInvalidIDError(w)
+		sendInvalidIDError(w, "book")
 		return
 	}
 	book, err := h.bk.GetBook(r.Context(), id)
@@ -66,28 +108,64 @@ func (h *Book) GetBook(w http.ResponseWriter, r *http.Request) {
 		sendHTTPError(w, err)
 		return
 	}
+	sendBook(w, r, book)
+}
+
+func (h *Book) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	book, err := h.bk.GetBookByISBN(r.Context(), mux.Vars(r)["isbn"])
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendBook(w, r, book)
+}
+
+// sendBook sends the book with its entity tag, or 304 if the client has it already.
+func sendBook(w http.ResponseWriter, r *http.Request, book *models.Book) {
+	etag := bookETag(book.Version)
+	if notModified(w, r, etag) {
+		return
+	}
+	w.Header().Set("ETag", etag)
 	resp := toBookResponse(book)
 	sendResponseWithBody(w, http.StatusOK, &resp)
 }
 
 func (h *Book) ListBooks(w http.ResponseWriter, r *http.Request) {
-	limit, offset, err := getPagination(r)
+	sc, err := getSearch(r)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
-	sc, err := getSearch(r)
+	h.listBooks(w, r, sc)
+}
+
+// listBooks responds with a page of the books found by the search.
+func (h *Book) listBooks(w http.ResponseWriter, r *http.Request, sc *models.BookSearch) {
+	// full-text search results are ranked by relevance unless sorted otherwise
+	sortFields, defaultSort := models.BookSortFields, []models.SortField(nil)
+	if sc.Query != "" {
+		sortFields = append([]string{models.SortRelevance}, sortFields...)
+		defaultSort = []models.SortField{{Field: models.SortRelevance, Desc: true}}
+	}
+	page, err := getPage(r, sortFields, defaultSort)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
 
-	books, err := h.bk.GetBooks(r.Context(), sc, limit, offset)
+	books, err := h.bk.GetBooks(r.Context(), sc, page)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
-	resp := toBooksResponse(books)
+	if page.WithTotal {
+		w.Header().Set(totalCountHeader, strconv.Itoa(books.Total))
+	}
+	resp := hm.BookListResponse{
+		Books:      toBooksResponse(books.Books),
+		NextCursor: encodeCursor(books.Next),
+	}
 	sendResponseWithBody(w, http.StatusOK, &resp)
 }
 
@@ -105,46 +183,81 @@ func (h *Book) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	}
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendInvalidIDError(w)
+		sendInvalidIDError(w, "book")
 		return
 	}
-	book := &models.Book{
-		ID:          id,
-		Title:       req.Title,
-		Author:      req.Author,
-		Publisher:   req.Publisher,
-		PublishDate: req.PublishDate,
+	book := toBook(&req)
+	book.ID = id
+	book.Version, err = ifMatchVersion(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
 	}
 	err = h.bk.UpdateBook(r.Context(), book)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
+	w.Header().Set("ETag", bookETag(book.Version))
 	sendEmptyResponse(w, http.StatusNoContent)
 }
 
 func (h *Book) CheckinBook(w http.ResponseWriter, r *http.Request) {
-	h.updateBookStatus(w, r, 0)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	var copyID int
+	if c := r.URL.Query().Get("copy_id"); c != "" {
+		copyID, err = strconv.Atoi(c)
+		if err != nil || copyID < 1 {
+			sendHTTPError(w, models.ErrBadRequest{Message: "invalid copy_id format"})
+			return
+		}
+	}
+	loan, err := h.bk.ReturnBook(r.Context(), id, copyID)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toLoanResponse(loan)
+	sendResponseWithBody(w, http.StatusOK, &resp)
 }
 
 func (h *Book) CheckoutBook(w http.ResponseWriter, r *http.Request) {
-	h.updateBookStatus(w, r, 1)
-}
-
-func (h *Book) updateBookStatus(w http.ResponseWriter, r *http.Request, status int) {
+	var req hm.CheckoutRequest
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendInvalidIDError(w)
+		sendInvalidIDError(w, "book")
 		return
 	}
-	err = h.bk.UpdateBookStatus(r.Context(), id, status)
+	var dueAt time.Time
+	if req.DueDate != nil {
+		dueAt = req.DueDate.UTC()
+	}
+	loan, err := h.bk.CheckoutBook(r.Context(), id, req.CopyID, req.BorrowerID, dueAt)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
 	}
-	sendEmptyResponse(w, http.StatusNoContent)
+	resp := toLoanResponse(loan)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
 }
 
+// RateBook rates the book as the caller, so that every user has one rating per
+// book. Authenticated callers rate as their subject, with authentication
+// disabled the user is taken from the request.
 func (h *Book) RateBook(w http.ResponseWriter, r *http.Request) {
 	var req hm.RateRequest
 	err := unmarshalRequestBody(r, &req)
@@ -157,12 +270,27 @@ func (h *Book) RateBook(w http.ResponseWriter, r *http.Request) {
 		sendHTTPError(w, err)
 		return
 	}
+	userID := req.UserID
+	if identity, ok := models.IdentityFromContext(r.Context()); ok {
+		if identity.Subject == "" {
+			sendHTTPError(w, models.ErrUnauthorized{Message: "authentication required"})
+			return
+		}
+		if userID != "" && userID != identity.Subject {
+			sendHTTPError(w, models.ErrForbidden{Message: "users may only rate as themselves"})
+			return
+		}
+		userID = identity.Subject
+	} else if userID == "" {
+		sendHTTPError(w, models.ErrBadRequest{Message: "user_id is required when authentication is disabled"})
+		return
+	}
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendInvalidIDError(w)
+		sendInvalidIDError(w, "book")
 		return
 	}
-	err = h.bk.RateBook(r.Context(), id, req.Rating)
+	err = h.bk.RateBook(r.Context(), id, userID, req.Rating)
 	if err != nil {
 		sendHTTPError(w, err)
 		return
@@ -170,13 +298,31 @@ func (h *Book) RateBook(w http.ResponseWriter, r *http.Request) {
 	sendEmptyResponse(w, http.StatusNoContent)
 }
 
+// DeleteBook soft-deletes the book, or purges it if the hard parameter is set.
 func (h *Book) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		sendInvalidIDError(w)
+		sendInvalidIDError(w, "book")
 		return
 	}
-	err = h.bk.DeleteBook(r.Context(), id)
+	hard := false
+	if v := r.URL.Query().Get("hard"); v != "" {
+		hard, err = strconv.ParseBool(v)
+		if err != nil {
+			sendHTTPError(w, models.ErrBadRequest{Message: "invalid hard format, use 'true' or 'false'"})
+			return
+		}
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if hard {
+		err = h.bk.PurgeBook(r.Context(), id, version)
+	} else {
+		err = h.bk.DeleteBook(r.Context(), id, version)
+	}
 	if err != nil {
 		sendHTTPError(w, err)
 		return
@@ -184,22 +330,65 @@ func (h *Book) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	sendEmptyResponse(w, http.StatusNoContent)
 }
 
+func (h *Book) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	book, err := h.bk.RestoreBook(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	w.Header().Set("ETag", bookETag(book.Version))
+	resp := toBookResponse(book)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+// toBook maps the request to a book. Authors referenced by id replace the
+// author name, otherwise the name is the only author of the book.
+func toBook(req *hm.Book) *models.Book {
+	b := &models.Book{
+		Title:       req.Title,
+		ISBN:        req.ISBN,
+		Author:      req.Author,
+		Authors:     []models.Author{{Name: req.Author}},
+		Publisher:   req.Publisher,
+		PublisherID: req.PublisherID,
+		PublishDate: req.PublishDate,
+	}
+	if len(req.AuthorIDs) > 0 {
+		b.Authors = make([]models.Author, len(req.AuthorIDs))
+		for i, id := range req.AuthorIDs {
+			b.Authors[i].ID = id
+		}
+	}
+	return b
+}
+
 func toBookResponse(b *models.Book) hm.GetBookResponse {
 	resp := hm.GetBookResponse{
 		Book: hm.Book{
 			Title:       b.Title,
+			ISBN:        b.ISBN,
 			Author:      b.Author,
 			Publisher:   b.Publisher,
+			PublisherID: b.PublisherID,
 			PublishDate: b.PublishDate,
 		},
-		ID:     b.ID,
-		Rating: b.Rating,
+		ID:              b.ID,
+		Version:         b.Version,
+		DeletedAt:       b.DeletedAt,
+		Rating:          b.Rating,
+		RatingCount:     b.RatingCount,
+		TotalCopies:     b.TotalCopies,
+		AvailableCopies: b.AvailableCopies,
 	}
-	if b.Status == 0 {
-		resp.Status = hm.StatusCheckedIn
-	} else {
-		resp.Status = hm.StatusCheckedOut
+	for _, a := range b.Authors {
+		resp.AuthorIDs = append(resp.AuthorIDs, a.ID)
 	}
+	resp.Status = bookStatuses[b.Status]
 	return resp
 }
 
@@ -1,9 +1,11 @@
+//go:build unit
 // +build unit
 
 package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -47,6 +49,7 @@ func TestBook_UpdateBook(t *testing.T) {
 		ID:          1,
 		Title:       input.Title,
 		Author:      input.Author,
+		Authors:     []models.Author{{Name: input.Author}},
 		Publisher:   input.Publisher,
 		PublishDate: input.PublishDate,
 	}
@@ -63,6 +66,101 @@ func TestBook_UpdateBook(t *testing.T) {
 		defer res.Body.Close()
 		a.Equal(http.StatusNoContent, res.StatusCode)
 	})
+	t.Run("if_match", func(t *testing.T) {
+		versioned := *book
+		versioned.Version = 3
+		srvMock.EXPECT().UpdateBook(gomock.Any(), &versioned).DoAndReturn(func(_ interface{}, b *models.Book) error {
+			b.Version = 4
+			return nil
+		})
+
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/books/1", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		request.Header.Set("If-Match", `"3"`)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+		a.Equal(`"4"`, res.Header.Get("ETag"))
+	})
+	t.Run("modified", func(t *testing.T) {
+		srvMock.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(models.ErrPreconditionFailed{Message: "book has been modified"})
+
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/books/1", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		request.Header.Set("If-Match", `"2"`)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusPreconditionFailed, res.StatusCode) // Check if the Code is 412
+	})
+	t.Run("weak_if_match", func(t *testing.T) {
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/books/1", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		request.Header.Set("If-Match", `W/"3"`)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusPreconditionFailed, res.StatusCode) // Check if the Code is 412
+	})
+}
+
+func TestBook_GetBook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                            // router
+	router.HandleFunc("/books/{id}", oh.GetBook).Methods(http.MethodGet) // get book route
+	srv := httptest.NewServer(router)                                    // test server
+	defer srv.Close()
+	get := func(ifNoneMatch string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/books/1", srv.URL), nil)
+		req.NoError(err)
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		return res
+	}
+	t.Run("etag", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(&models.Book{ID: 1, Version: 7}, nil)
+
+		res := get("")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal(`"7"`, res.Header.Get("ETag"))
+		var book hm.GetBookResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&book))
+		a.Equal(7, book.Version)
+	})
+	t.Run("not_modified", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(&models.Book{ID: 1, Version: 7}, nil)
+
+		res := get(`"6", W/"7"`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNotModified, res.StatusCode) // Check if the Code is 304
+		a.Equal(`"7"`, res.Header.Get("ETag"))
+	})
+	t.Run("modified", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(&models.Book{ID: 1, Version: 8}, nil)
+
+		res := get(`"7"`)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
 }
 
 func TestBook_AddBook(t *testing.T) {
@@ -88,6 +186,7 @@ func TestBook_AddBook(t *testing.T) {
 	book := &models.Book{
 		Title:       input.Title,
 		Author:      input.Author,
+		Authors:     []models.Author{{Name: input.Author}},
 		Publisher:   input.Publisher,
 		PublishDate: input.PublishDate,
 	}
@@ -112,6 +211,37 @@ func TestBook_AddBook(t *testing.T) {
 		a.Equal(book.Title, bookFromHandler.Title)
 		a.Equal(hm.StatusCheckedIn, bookFromHandler.Status)
 	})
+	t.Run("author_ids", func(t *testing.T) {
+		input := &hm.Book{
+			Title:       "my_title",
+			AuthorIDs:   []int{2, 1},
+			PublisherID: 3,
+			PublishDate: time.Now().Add(-time.Hour).UTC(),
+		}
+		srvMock.EXPECT().AddBook(gomock.Any(), &models.Book{
+			Title:       input.Title,
+			Authors:     []models.Author{{ID: 2}, {ID: 1}},
+			PublisherID: 3,
+			PublishDate: input.PublishDate,
+		}).DoAndReturn(func(_ context.Context, b *models.Book) error {
+			b.Authors = []models.Author{{ID: 2, Name: "b"}, {ID: 1, Name: "a"}}
+			b.Author, b.Publisher = "b, a", "p"
+			return nil
+		})
+
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		res, err := http.Post(fmt.Sprintf("%s/books", srv.URL), "application/json", bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+
+		var bookFromHandler hm.GetBookResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&bookFromHandler))
+		a.Equal("b, a", bookFromHandler.Author)
+		a.Equal([]int{2, 1}, bookFromHandler.AuthorIDs)
+		a.Equal(3, bookFromHandler.PublisherID)
+	})
 	t.Run("validation", func(t *testing.T) {
 		input := &hm.Book{PublishDate: time.Now().Add(-time.Hour).UTC()}
 		reqBody, err := json.Marshal(input)
@@ -120,6 +250,15 @@ func TestBook_AddBook(t *testing.T) {
 		req.NoError(err)
 		a.Equal(http.StatusBadRequest, resp.StatusCode) // Check if the Code is 400
 	})
+	t.Run("invalid_isbn", func(t *testing.T) {
+		input := *input
+		input.ISBN = "978-0-306-40615-8" // wrong check digit
+		reqBody, err := json.Marshal(&input)
+		req.NoError(err)
+		resp, err := http.Post(fmt.Sprintf("%s/books", srv.URL), "application/json", bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		a.Equal(http.StatusBadRequest, resp.StatusCode) // Check if the Code is 400
+	})
 	t.Run("bad_request", func(t *testing.T) {
 		invalid := "adc" // invalid request body
 		resp, err := http.Post(fmt.Sprintf("%s/books", srv.URL), "application/json", bytes.NewBuffer([]byte(invalid)))
@@ -136,3 +275,404 @@ func TestBook_AddBook(t *testing.T) {
 		a.Equal(http.StatusServiceUnavailable, resp.StatusCode) // Check if the Code is 503
 	})
 }
+
+func TestBook_CheckoutBook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                       // router
+	router.HandleFunc("/books/{id}/out", oh.CheckoutBook).Methods(http.MethodPatch) // checkout book route
+	srv := httptest.NewServer(router)                                               // test server
+	defer srv.Close()
+	dueDate := time.Now().Add(time.Hour).UTC()
+	input := &hm.CheckoutRequest{
+		BorrowerID: 7,
+		DueDate:    &dueDate,
+	}
+	loan := &models.Loan{
+		ID:           1,
+		BookID:       1,
+		BorrowerID:   input.BorrowerID,
+		CheckedOutAt: time.Now().UTC(),
+		DueAt:        dueDate,
+	}
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().CheckoutBook(gomock.Any(), 1, 0, input.BorrowerID, dueDate).Return(loan, nil)
+
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/1/out", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+
+		var loanFromHandler hm.LoanResponse
+		err = json.NewDecoder(res.Body).Decode(&loanFromHandler)
+		req.NoError(err)
+		a.Equal(loan.BorrowerID, loanFromHandler.BorrowerID)
+		a.False(loanFromHandler.Overdue)
+	})
+	t.Run("copy", func(t *testing.T) {
+		srvMock.EXPECT().CheckoutBook(gomock.Any(), 1, 3, input.BorrowerID, dueDate).Return(&models.Loan{ID: 2, BookID: 1, CopyID: 3}, nil)
+
+		reqBody, err := json.Marshal(&hm.CheckoutRequest{BorrowerID: input.BorrowerID, CopyID: 3, DueDate: &dueDate})
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/1/out", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+
+		var loanFromHandler hm.LoanResponse
+		err = json.NewDecoder(res.Body).Decode(&loanFromHandler)
+		req.NoError(err)
+		a.Equal(3, loanFromHandler.CopyID)
+	})
+	t.Run("validation", func(t *testing.T) {
+		reqBody, err := json.Marshal(&hm.CheckoutRequest{})
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/1/out", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("not_found", func(t *testing.T) {
+		srvMock.EXPECT().CheckoutBook(gomock.Any(), 2, 0, input.BorrowerID, dueDate).Return(nil, models.ErrNotFound{})
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/2/out", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusNotFound, res.StatusCode) // Check if the Code is 404
+	})
+	t.Run("conflict", func(t *testing.T) {
+		srvMock.EXPECT().CheckoutBook(gomock.Any(), 1, 0, input.BorrowerID, dueDate).
+			Return(nil, models.ErrConflict{Message: "book is already checked out"})
+		reqBody, err := json.Marshal(input)
+		req.NoError(err)
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/1/out", srv.URL), bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
+
+func TestBook_ListBooks(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                         // router
+	router.HandleFunc("/books", oh.ListBooks).Methods(http.MethodGet) // list books route
+	srv := httptest.NewServer(router)                                 // test server
+	defer srv.Close()
+	t.Run("next_page", func(t *testing.T) {
+		first := &models.BookList{
+			Books: []models.Book{{ID: 1}, {ID: 2}},
+			Next:  &models.Cursor{ID: 2},
+			Total: 3,
+		}
+		srvMock.EXPECT().GetBooks(gomock.Any(), gomock.Any(), &models.Page{Limit: 2, WithTotal: true}).Return(first, nil)
+		res, err := http.Get(fmt.Sprintf("%s/books?limit=2&total=true", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal("3", res.Header.Get("X-Total-Count"))
+		var books hm.BookListResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&books))
+		a.Len(books.Books, 2)
+		req.NotEmpty(books.NextCursor)
+
+		last := &models.BookList{Books: []models.Book{{ID: 3}}}
+		srvMock.EXPECT().GetBooks(gomock.Any(), gomock.Any(), &models.Page{Limit: 2, After: first.Next}).Return(last, nil)
+		res, err = http.Get(fmt.Sprintf("%s/books?limit=2&cursor=%s", srv.URL, books.NextCursor))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Empty(res.Header.Get("X-Total-Count"))
+		books = hm.BookListResponse{}
+		req.NoError(json.NewDecoder(res.Body).Decode(&books))
+		a.Len(books.Books, 1)
+		a.Empty(books.NextCursor)
+	})
+	t.Run("sort", func(t *testing.T) {
+		page := &models.Page{
+			Limit: defaultLimit,
+			Sort:  []models.SortField{{Field: "title"}, {Field: "publish_date", Desc: true}},
+		}
+		srvMock.EXPECT().GetBooks(gomock.Any(), gomock.Any(), page).Return(&models.BookList{}, nil)
+		res, err := http.Get(fmt.Sprintf("%s/books?sort=title,-publish_date", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("full_text", func(t *testing.T) {
+		search := &models.BookSearch{Query: "sapolsky"}
+		page := &models.Page{
+			Limit: defaultLimit,
+			Sort:  []models.SortField{{Field: models.SortRelevance, Desc: true}},
+		}
+		srvMock.EXPECT().GetBooks(gomock.Any(), search, page).Return(&models.BookList{}, nil)
+		res, err := http.Get(fmt.Sprintf("%s/books?q=sapolsky", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("relevance_without_query", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books?sort=-relevance", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("invalid_sort", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books?sort=title%%3BDROP%%20TABLE%%20books", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("invalid_cursor", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books?cursor=abc", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("limit_too_big", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books?limit=%d", srv.URL, maxLimit+1))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("deleted", func(t *testing.T) {
+		deletedAt := time.Now().UTC().Truncate(time.Second)
+		srvMock.EXPECT().GetBooks(gomock.Any(), &models.BookSearch{Deleted: models.DeletedOnly}, gomock.Any()).
+			Return(&models.BookList{Books: []models.Book{{ID: 1, DeletedAt: &deletedAt}}}, nil)
+		res, err := http.Get(fmt.Sprintf("%s/books?deleted=only", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+		var books hm.BookListResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&books))
+		req.Len(books.Books, 1)
+		req.NotNil(books.Books[0].DeletedAt)
+		a.True(deletedAt.Equal(*books.Books[0].DeletedAt))
+	})
+	t.Run("invalid_deleted", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/books?deleted=maybe", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
+
+func TestBook_DeleteBook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                         // router
+	router.HandleFunc("/books/{id}", oh.DeleteBook).Methods(http.MethodDelete)        // delete book route
+	router.HandleFunc("/books/{id}/restore", oh.RestoreBook).Methods(http.MethodPost) // restore book route
+	srv := httptest.NewServer(router)                                                 // test server
+	defer srv.Close()
+	del := func(url string) *http.Response {
+		r, err := http.NewRequest(http.MethodDelete, srv.URL+url, nil)
+		req.NoError(err)
+		res, err := http.DefaultClient.Do(r)
+		req.NoError(err)
+		return res
+	}
+	t.Run("soft", func(t *testing.T) {
+		srvMock.EXPECT().DeleteBook(gomock.Any(), 1, 0).Return(nil)
+
+		res := del("/books/1")
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("hard", func(t *testing.T) {
+		srvMock.EXPECT().PurgeBook(gomock.Any(), 2, 0).Return(nil)
+
+		res := del("/books/2?hard=true")
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("hard_with_open_loans", func(t *testing.T) {
+		srvMock.EXPECT().PurgeBook(gomock.Any(), 3, 0).Return(models.ErrConflict{Message: "book has open loans"})
+
+		res := del("/books/3?hard=true")
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+	t.Run("if_match", func(t *testing.T) {
+		srvMock.EXPECT().DeleteBook(gomock.Any(), 4, 9).Return(models.ErrPreconditionFailed{Message: "book has been modified"})
+
+		r, err := http.NewRequest(http.MethodDelete, srv.URL+"/books/4", nil)
+		req.NoError(err)
+		r.Header.Set("If-Match", `"9"`)
+		res, err := http.DefaultClient.Do(r)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusPreconditionFailed, res.StatusCode) // Check if the Code is 412
+	})
+	t.Run("invalid_hard", func(t *testing.T) {
+		res := del("/books/3?hard=yes")
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("restore", func(t *testing.T) {
+		srvMock.EXPECT().RestoreBook(gomock.Any(), 1).Return(&models.Book{ID: 1, Title: "Behave"}, nil)
+
+		res, err := http.Post(fmt.Sprintf("%s/books/1/restore", srv.URL), "application/json", nil)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+		var book hm.GetBookResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&book))
+		a.Equal("Behave", book.Title)
+		a.Nil(book.DeletedAt)
+	})
+	t.Run("restore_not_deleted", func(t *testing.T) {
+		srvMock.EXPECT().RestoreBook(gomock.Any(), 2).Return(nil, models.ErrConflict{Message: "book is not deleted"})
+
+		res, err := http.Post(fmt.Sprintf("%s/books/2/restore", srv.URL), "application/json", nil)
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
+
+func TestBook_RateBook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	// the caller of /users/{subject} is authenticated as the subject, the
+	// caller of /books is not as with authentication disabled
+	router := mux.NewRouter()
+	router.HandleFunc("/books/{id}/rate", oh.RateBook).Methods(http.MethodPatch)
+	router.HandleFunc("/users/{subject}/books/{id}/rate", func(w http.ResponseWriter, r *http.Request) {
+		identity := &models.Identity{Subject: mux.Vars(r)["subject"], Role: models.RolePatron}
+		oh.RateBook(w, r.WithContext(models.WithIdentity(r.Context(), identity)))
+	}).Methods(http.MethodPatch)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	rate := func(url, body string) *http.Response {
+		request, err := http.NewRequest(http.MethodPatch, srv.URL+url, bytes.NewBufferString(body))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		return res
+	}
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().RateBook(gomock.Any(), 1, "alice", 3).Return(nil)
+
+		res := rate("/users/alice/books/1/rate", `{"rating":3}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("own_user_in_body", func(t *testing.T) {
+		srvMock.EXPECT().RateBook(gomock.Any(), 1, "alice", 2).Return(nil)
+
+		res := rate("/users/alice/books/1/rate", `{"user_id":"alice","rating":2}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("other_user_in_body", func(t *testing.T) {
+		res := rate("/users/alice/books/1/rate", `{"user_id":"bob","rating":2}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+	t.Run("auth_disabled", func(t *testing.T) {
+		srvMock.EXPECT().RateBook(gomock.Any(), 1, "bob", 1).Return(nil)
+
+		res := rate("/books/1/rate", `{"user_id":"bob","rating":1}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("auth_disabled_without_user", func(t *testing.T) {
+		res := rate("/books/1/rate", `{"rating":3}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("validation", func(t *testing.T) {
+		res := rate("/users/alice/books/1/rate", `{"rating":4}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
+
+func TestBook_InvalidID(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	oh := New(mock.NewMockBookKeeper(ctrl)) // book handler with mocked service
+
+	router := mux.NewRouter()
+	router.HandleFunc("/books/{id}", oh.GetBook).Methods(http.MethodGet)
+	router.HandleFunc("/authors/{id}", oh.GetAuthor).Methods(http.MethodGet)
+	router.HandleFunc("/publishers/{id}", oh.GetPublisher).Methods(http.MethodGet)
+	router.HandleFunc("/copies/{id}", oh.GetCopy).Methods(http.MethodGet)
+	router.HandleFunc("/holds/{id}", oh.CancelHold).Methods(http.MethodDelete)
+	router.HandleFunc("/patrons/{id}", oh.GetPatron).Methods(http.MethodGet)
+	router.HandleFunc("/patrons/{id}/fines", oh.ListPatronFines).Methods(http.MethodGet)
+	router.HandleFunc("/fines/{id}/pay", oh.PayFine).Methods(http.MethodPatch)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	for _, tt := range []struct {
+		method, url, message string
+	}{
+		{http.MethodGet, "/books/x", "invalid book id"},
+		{http.MethodGet, "/authors/x", "invalid author id"},
+		{http.MethodGet, "/publishers/x", "invalid publisher id"},
+		{http.MethodGet, "/copies/x", "invalid copy id"},
+		{http.MethodDelete, "/holds/x", "invalid hold id"},
+		{http.MethodGet, "/patrons/x", "invalid patron id"},
+		{http.MethodGet, "/patrons/x/fines", "invalid patron id"},
+		{http.MethodPatch, "/fines/x/pay", "invalid fine id"},
+	} {
+		request, err := http.NewRequest(tt.method, srv.URL+tt.url, nil)
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		var body struct {
+			Message string `json:"message"`
+		}
+		req.NoError(json.NewDecoder(res.Body).Decode(&body))
+		res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode, tt.url) // Check if the Code is 400
+		a.Equal(tt.message, body.Message, tt.url)
+	}
+}
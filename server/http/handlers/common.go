@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -10,7 +12,6 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/libreria/models"
-	hm "github.com/libreria/server/http/models"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,7 +28,10 @@ func unmarshalRequestBody(r *http.Request, body interface{}) error {
 	return nil
 }
 
-const defaultLimit = 50
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
 
 func getPagination(r *http.Request) (limit, offset int, err error) {
 	limit = defaultLimit
@@ -35,32 +39,134 @@ func getPagination(r *http.Request) (limit, offset int, err error) {
 	offsetStr := r.URL.Query().Get("offset")
 	if limitStr != "" {
 		l, lErr := strconv.Atoi(limitStr)
-		if lErr != nil {
-			err = models.ErrBadRequest{Message: "invalid limit format"}
+		if lErr != nil || l < 1 {
+			return 0, 0, models.ErrBadRequest{Message: "invalid limit format"}
+		}
+		if l > maxLimit {
+			return 0, 0, models.ErrBadRequest{Message: fmt.Sprintf("limit must not exceed %d", maxLimit)}
 		}
 		limit = l
 	}
 	if offsetStr != "" {
 		o, oErr := strconv.Atoi(offsetStr)
-		if oErr != nil {
-			err = models.ErrBadRequest{Message: "invalid offset format"}
+		if oErr != nil || o < 0 {
+			return 0, 0, models.ErrBadRequest{Message: "invalid offset format"}
 		}
 		offset = o
 	}
 	return
 }
 
+// getPage reads limit/offset pagination or, if the cursor parameter is set,
+// keyset pagination continuing after the cursor. The listing is sorted by the
+// sort parameter restricted to the allowed fields, or by defaultSort.
+func getPage(r *http.Request, allowedSort []string, defaultSort []models.SortField) (*models.Page, error) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &models.Page{Limit: limit, Offset: offset}
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		if r.URL.Query().Get("offset") != "" {
+			return nil, models.ErrBadRequest{Message: "cursor and offset can not be used together"}
+		}
+		if p.After, err = decodeCursor(c); err != nil {
+			return nil, err
+		}
+	}
+	if p.Sort, err = getSort(r, allowedSort); err != nil {
+		return nil, err
+	}
+	if p.Sort == nil {
+		p.Sort = defaultSort
+	}
+	if p.After != nil && len(p.After.Values) != len(p.Sort) {
+		return nil, models.ErrBadRequest{Message: "cursor does not match sort"}
+	}
+	if t := r.URL.Query().Get("total"); t != "" {
+		if p.WithTotal, err = strconv.ParseBool(t); err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid total format, use 'true' or 'false'"}
+		}
+	}
+	return p, nil
+}
+
+// getSort parses the sort parameter, a comma separated list of fields where
+// a leading '-' sorts descending, e.g. 'title,-publish_date'.
+func getSort(r *http.Request, allowed []string) ([]models.SortField, error) {
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		return nil, nil
+	}
+	var (
+		res  []models.SortField
+		seen = make(map[string]bool)
+	)
+	for _, f := range strings.Split(sort, ",") {
+		sf := models.SortField{Field: strings.TrimSpace(f)}
+		if strings.HasPrefix(sf.Field, "-") {
+			sf.Field, sf.Desc = sf.Field[1:], true
+		} else {
+			sf.Field = strings.TrimPrefix(sf.Field, "+")
+		}
+		if !contains(allowed, sf.Field) {
+			return nil, models.ErrBadRequest{
+				Message: fmt.Sprintf("invalid sort field '%s', use one of %s", sf.Field, strings.Join(allowed, ", ")),
+			}
+		}
+		if seen[sf.Field] {
+			return nil, models.ErrBadRequest{Message: fmt.Sprintf("sort field '%s' is repeated", sf.Field)}
+		}
+		seen[sf.Field] = true
+		res = append(res, sf)
+	}
+	return res, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeCursor makes an opaque page cursor for the client.
+func encodeCursor(c *models.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*models.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, models.ErrBadRequest{Message: "invalid cursor"}
+	}
+	var c models.Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, models.ErrBadRequest{Message: "invalid cursor"}
+	}
+	return &c, nil
+}
+
 const timeFormat = "2006-01-02"
 
 func getSearch(r *http.Request) (*models.BookSearch, error) {
 	status := r.URL.Query().Get("status")
-	var intStatus *int
+	var bookStatus *models.BookStatus
 	if status != "" {
-		if status == strings.ToLower(string(hm.StatusCheckedIn)) {
-			intStatus = toIntPtr(0)
-		} else if status == strings.ToLower(string(hm.StatusCheckedOut)) {
-			intStatus = toIntPtr(1)
-		} else {
+		for st, name := range bookStatuses {
+			if status == strings.ToLower(string(name)) {
+				st := st
+				bookStatus = &st
+				break
+			}
+		}
+		if bookStatus == nil {
 			return nil, models.ErrBadRequest{Message: "invalid status format, use 'checkedIn' or 'checkedOut'"}
 		}
 	}
@@ -84,19 +190,83 @@ func getSearch(r *http.Request) (*models.BookSearch, error) {
 			Condition:   ft,
 		}
 	}
+	deleted := models.DeletedExclude
+	switch r.URL.Query().Get("deleted") {
+	case "", "exclude":
+	case "include":
+		deleted = models.DeletedInclude
+	case "only":
+		deleted = models.DeletedOnly
+	default:
+		return nil, models.ErrBadRequest{Message: "invalid deleted format, use 'exclude', 'include' or 'only'"}
+	}
 	return &models.BookSearch{
+		Query:             strings.TrimSpace(r.URL.Query().Get("q")),
 		Title:             r.URL.Query().Get("title"),
 		Author:            r.URL.Query().Get("author"),
 		Publisher:         r.URL.Query().Get("publisher"),
-		Status:            intStatus,
+		Status:            bookStatus,
 		PublishDateSearch: pds,
+		Deleted:           deleted,
 	}, nil
 
 }
 
-func toIntPtr(b int) (ptr *int) {
-	ptr = &b
-	return
+func getLoanSearch(r *http.Request) (*models.LoanSearch, error) {
+	ls := new(models.LoanSearch)
+	if v := r.URL.Query().Get("borrower_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid borrower_id format"}
+		}
+		ls.BorrowerID = &id
+	}
+	if v := r.URL.Query().Get("open"); v != "" {
+		open, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid open format, use 'true' or 'false'"}
+		}
+		ls.Open = &open
+	}
+	if v := r.URL.Query().Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid overdue format, use 'true' or 'false'"}
+		}
+		ls.Overdue = overdue
+	}
+	return ls, nil
+}
+
+func getHoldSearch(r *http.Request) (*models.HoldSearch, error) {
+	hs := new(models.HoldSearch)
+	if v := r.URL.Query().Get("patron_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid patron_id format"}
+		}
+		hs.PatronID = &id
+	}
+	if v := r.URL.Query().Get("status"); v != "" {
+		for st, name := range holdStatuses {
+			if v == string(name) {
+				st := st
+				hs.Status = &st
+				break
+			}
+		}
+		if hs.Status == nil {
+			return nil, models.ErrBadRequest{Message: "invalid status format, use 'waiting', 'ready', 'fulfilled', 'cancelled' or 'expired'"}
+		}
+	}
+	if v := r.URL.Query().Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, models.ErrBadRequest{Message: "invalid active format, use 'true' or 'false'"}
+		}
+		hs.Active = &active
+	}
+	return hs, nil
 }
 
 // sendEmptyResponse sends only response code
@@ -116,17 +286,27 @@ func sendResponseWithBody(w http.ResponseWriter, statusCode int, respBody interf
 	_, _ = w.Write(b)
 }
 
-func sendInvalidIDError(w http.ResponseWriter) {
-	sendHTTPError(w, &models.ErrBadRequest{Message: "invalid book id"})
+// sendInvalidIDError rejects a request whose path has a malformed id of the resource.
+func sendInvalidIDError(w http.ResponseWriter, resource string) {
+	sendHTTPError(w, models.ErrBadRequest{Message: "invalid " + resource + " id"})
 }
 
 // sendHTTPError sends error response with appropriate status code.
 func sendHTTPError(w http.ResponseWriter, err error) {
-	var (
-		code    int
-		message string
-		errs    []models.FieldError
-	)
+	code, message, errs := httpError(err)
+	sendResponseWithBody(w, code, struct {
+		Code    int                 `json:"code"`
+		Message string              `json:"message,omitempty"`
+		Errors  []models.FieldError `json:"errors,omitempty"`
+	}{
+		Code:    code,
+		Message: message,
+		Errors:  errs,
+	})
+}
+
+// httpError returns the status code and the message the error is sent with.
+func httpError(err error) (code int, message string, errs []models.FieldError) {
 	switch v := err.(type) {
 	case validation.Error:
 		code = http.StatusBadRequest
@@ -145,18 +325,25 @@ func sendHTTPError(w http.ResponseWriter, err error) {
 		code = http.StatusBadRequest
 		message = v.Message
 		errs = v.Errors
+	case models.ErrConflict:
+		code = http.StatusConflict
+		message = v.Message
+	case models.ErrUnauthorized:
+		code = http.StatusUnauthorized
+		message = v.Message
+	case models.ErrForbidden:
+		code = http.StatusForbidden
+		message = v.Message
+	case models.ErrPreconditionFailed:
+		code = http.StatusPreconditionFailed
+		message = v.Message
+	case models.ErrUnsupportedMediaType:
+		code = http.StatusUnsupportedMediaType
+		message = v.Message
 	default:
 		log.WithError(err).Error("unknown error")
 		code = http.StatusServiceUnavailable
 		message = "service unavailable"
 	}
-	sendResponseWithBody(w, code, struct {
-		Code    int                 `json:"code"`
-		Message string              `json:"message,omitempty"`
-		Errors  []models.FieldError `json:"errors,omitempty"`
-	}{
-		Code:    code,
-		Message: message,
-		Errors:  errs,
-	})
+	return code, message, errs
 }
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+func (h *Book) AddCopy(w http.ResponseWriter, r *http.Request) {
+	var req hm.CopyRequest
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	c := &models.Copy{
+		BookID:    id,
+		Barcode:   req.Barcode,
+		Condition: req.Condition,
+		Location:  req.Location,
+	}
+	err = h.bk.AddCopy(r.Context(), c)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toCopyResponse(c)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
+}
+
+func (h *Book) ListCopies(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	copies, err := h.bk.GetCopies(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.CopyResponse, len(copies))
+	for i := range copies {
+		resp[i] = toCopyResponse(&copies[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) GetCopy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "copy")
+		return
+	}
+	c, err := h.bk.GetCopy(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toCopyResponse(c)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) RetireCopy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "copy")
+		return
+	}
+	c, err := h.bk.RetireCopy(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toCopyResponse(c)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func toCopyResponse(c *models.Copy) hm.CopyResponse {
+	return hm.CopyResponse{
+		ID:        c.ID,
+		BookID:    c.BookID,
+		Barcode:   c.Barcode,
+		Condition: c.Condition,
+		Location:  c.Location,
+		Status:    bookStatuses[c.Status],
+		RetiredAt: c.RetiredAt,
+	}
+}
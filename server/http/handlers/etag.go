@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+// bookETag is the entity tag of a version of a book.
+func bookETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion returns the book version required by the If-Match header of
+// the request, zero if the header is missing or matches any version. Weak and
+// malformed entity tags never match.
+func ifMatchVersion(r *http.Request) (int, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	if strings.Contains(v, ",") {
+		return 0, models.ErrBadRequest{Message: "If-Match must be a single entity tag"}
+	}
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, models.ErrPreconditionFailed{Message: "book has been modified"}
+	}
+	version, err := strconv.Atoi(v[1 : len(v)-1])
+	if err != nil || version < 1 {
+		return 0, models.ErrPreconditionFailed{Message: "book has been modified"}
+	}
+	return version, nil
+}
+
+// notModified answers 304 if the If-None-Match header of the request matches
+// the entity tag, comparing weakly, and reports whether it did.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	v := r.Header.Get("If-None-Match")
+	if v == "" {
+		return false
+	}
+	for _, tag := range strings.Split(v, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
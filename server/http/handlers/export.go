@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/libreria/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// exportFormats are the formats of exported books by the value of the format
+// parameter.
+var exportFormats = map[string]struct {
+	contentType string
+	extension   string
+	newEncoder  func(w io.Writer) bookEncoder
+}{
+	"csv":    {"text/csv;charset=utf-8", "csv", newCSVBookEncoder},
+	"ndjson": {jsonLinesType, "ndjson", newJSONLinesBookEncoder},
+	"marc":   {"text/plain;charset=utf-8", "mrk", newMARCBookEncoder},
+}
+
+// bookEncoder writes exported books, flush writes what it buffered.
+type bookEncoder interface {
+	encode(b *models.Book) error
+	flush() error
+}
+
+// ExportBooks streams every book found by the search filters of ListBooks as
+// CSV, JSON Lines or MARC records. Errors before the first bytes are sent are
+// answered as usual, later ones abort the response so the client can not
+// take a partial export for a complete one.
+func (h *Book) ExportBooks(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		sendHTTPError(w, models.ErrBadRequest{Message: "invalid format, use 'csv', 'ndjson' or 'marc'"})
+		return
+	}
+	sc, err := getSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := &exportResponse{w: w}
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="books.`+format.extension+`"`)
+	bw := bufio.NewWriter(resp)
+	enc := format.newEncoder(bw)
+	err = h.bk.ExportBooks(r.Context(), sc, enc.encode)
+	if err == nil {
+		err = enc.flush()
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil && !resp.started {
+		w.Header().Del("Content-Disposition")
+		sendHTTPError(w, err)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("books export failed")
+		panic(http.ErrAbortHandler)
+	}
+	if !resp.started {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// exportResponse tells whether the response has been started.
+type exportResponse struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	e.started = true
+	return e.w.Write(p)
+}
+
+// exportColumns are the columns of exported CSV files. Author ids are
+// separated by semicolons as in imported files.
+var exportColumns = []string{
+	"id", "name", "isbn", "author", "author_ids", "publisher", "publisher_id", "publish_date",
+	"status", "rating", "rating_count", "total_copies", "available_copies",
+}
+
+type csvBookEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVBookEncoder(w io.Writer) bookEncoder {
+	return &csvBookEncoder{w: csv.NewWriter(w)}
+}
+
+func (c *csvBookEncoder) encode(b *models.Book) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	ids := make([]string, len(b.Authors))
+	for i, a := range b.Authors {
+		ids[i] = strconv.Itoa(a.ID)
+	}
+	return c.w.Write([]string{
+		strconv.Itoa(b.ID),
+		b.Title,
+		b.ISBN,
+		b.Author,
+		strings.Join(ids, ";"),
+		b.Publisher,
+		strconv.Itoa(b.PublisherID),
+		b.PublishDate.Format(timeFormat),
+		string(bookStatuses[b.Status]),
+		strconv.FormatFloat(b.Rating, 'f', -1, 64),
+		strconv.Itoa(b.RatingCount),
+		strconv.Itoa(b.TotalCopies),
+		strconv.Itoa(b.AvailableCopies),
+	})
+}
+
+func (c *csvBookEncoder) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(exportColumns)
+}
+
+func (c *csvBookEncoder) flush() error {
+	// an empty export still has the header
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonLinesBookEncoder writes a book per line as returned by GetBook.
+type jsonLinesBookEncoder struct {
+	enc *json.Encoder
+}
+
+func newJSONLinesBookEncoder(w io.Writer) bookEncoder {
+	return jsonLinesBookEncoder{enc: json.NewEncoder(w)}
+}
+
+func (j jsonLinesBookEncoder) encode(b *models.Book) error {
+	return j.enc.Encode(toBookResponse(b))
+}
+
+func (j jsonLinesBookEncoder) flush() error {
+	return nil
+}
+
+// marcBookEncoder writes books as MARC 21 bibliographic records in the
+// mnemonic text format of MARCMaker, records are separated by blank lines.
+type marcBookEncoder struct {
+	w io.Writer
+}
+
+func newMARCBookEncoder(w io.Writer) bookEncoder {
+	return marcBookEncoder{w: w}
+}
+
+// marcEscaper escapes the characters of values that delimit mnemonic records.
+var marcEscaper = strings.NewReplacer("$", "{dollar}", "{", "{lcub}", "}", "{rcub}", "\n", " ", "\r", " ")
+
+func (m marcBookEncoder) encode(b *models.Book) error {
+	var sb strings.Builder
+	sb.WriteString("=LDR  00000nam a2200000 i 4500\n")
+	fmt.Fprintf(&sb, "=001  %d\n", b.ID)
+	if b.ISBN != "" {
+		fmt.Fprintf(&sb, "=020  \\\\$a%s\n", marcEscaper.Replace(b.ISBN))
+	}
+	authors := make([]string, len(b.Authors))
+	for i, a := range b.Authors {
+		authors[i] = a.Name
+	}
+	if len(authors) == 0 && b.Author != "" {
+		authors = []string{b.Author}
+	}
+	// the title is added to indexes under the main entry, the first author
+	titleIndicator := "0"
+	if len(authors) > 0 {
+		fmt.Fprintf(&sb, "=100  1\\$a%s\n", marcEscaper.Replace(authors[0]))
+		titleIndicator = "1"
+	}
+	fmt.Fprintf(&sb, "=245  %s0$a%s\n", titleIndicator, marcEscaper.Replace(b.Title))
+	fmt.Fprintf(&sb, "=264  \\1$b%s,$c%d\n", marcEscaper.Replace(b.Publisher), b.PublishDate.Year())
+	for i := 1; i < len(authors); i++ {
+		fmt.Fprintf(&sb, "=700  1\\$a%s\n", marcEscaper.Replace(authors[i]))
+	}
+	sb.WriteString("\n")
+	_, err := io.WriteString(m.w, sb.String())
+	return err
+}
+
+func (m marcBookEncoder) flush() error {
+	return nil
+}
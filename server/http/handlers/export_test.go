@@ -0,0 +1,153 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_ExportBooks(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                  // router
+	router.HandleFunc("/books:export", oh.ExportBooks).Methods(http.MethodGet) // export books route
+	srv := httptest.NewServer(router)                                          // test server
+	defer srv.Close()
+
+	books := []*models.Book{
+		{
+			ID:          1,
+			Title:       "first, the title",
+			ISBN:        "9780306406157",
+			Author:      "Ann, Bob",
+			Authors:     []models.Author{{ID: 4, Name: "Ann"}, {ID: 5, Name: "Bob"}},
+			Publisher:   "my_publisher",
+			PublisherID: 2,
+			PublishDate: time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC),
+			Status:      models.StatusCheckedOut,
+			TotalCopies: 1,
+		},
+		{
+			ID:          2,
+			Title:       "second",
+			Author:      "Ann",
+			Authors:     []models.Author{{ID: 4, Name: "Ann"}},
+			Publisher:   "my_publisher",
+			PublisherID: 2,
+			PublishDate: time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	exportBooks := func(_ interface{}, _ *models.BookSearch, fn func(*models.Book) error) error {
+		for _, b := range books {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	export := func(query string) (*http.Response, string) {
+		res, err := srv.Client().Get(fmt.Sprintf("%s/books:export%s", srv.URL, query))
+		req.NoError(err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		req.NoError(err)
+		return res, string(body)
+	}
+	t.Run("csv", func(t *testing.T) {
+		srvMock.EXPECT().ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(exportBooks)
+
+		res, body := export("")
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal("text/csv;charset=utf-8", res.Header.Get("Content-Type"))
+		a.Equal(`attachment; filename="books.csv"`, res.Header.Get("Content-Disposition"))
+		a.Equal(`id,name,isbn,author,author_ids,publisher,publisher_id,publish_date,status,rating,rating_count,total_copies,available_copies
+1,"first, the title",9780306406157,"Ann, Bob",4;5,my_publisher,2,2001-02-03,CheckedOut,0,0,1,0
+2,second,,Ann,4,my_publisher,2,1999-01-01,CheckedIn,0,0,0,0
+`, body)
+	})
+	t.Run("ndjson_search", func(t *testing.T) {
+		srvMock.EXPECT().ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx interface{}, bs *models.BookSearch, fn func(*models.Book) error) error {
+				a.Equal("my_author", bs.Author)
+				a.Equal(models.DeletedInclude, bs.Deleted)
+				return exportBooks(ctx, bs, fn)
+			})
+
+		res, body := export("?format=ndjson&author=my_author&deleted=include")
+		a.Equal(http.StatusOK, res.StatusCode)
+		lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+		req.Len(lines, 2)
+		var book hm.GetBookResponse
+		req.NoError(json.Unmarshal([]byte(lines[1]), &book))
+		a.Equal(2, book.ID)
+		a.Equal("second", book.Title)
+		a.Equal([]int{4}, book.AuthorIDs)
+	})
+	t.Run("marc", func(t *testing.T) {
+		srvMock.EXPECT().ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(exportBooks)
+
+		res, body := export("?format=marc")
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal(`=LDR  00000nam a2200000 i 4500
+=001  1
+=020  \\$a9780306406157
+=100  1\$aAnn
+=245  10$afirst, the title
+=264  \1$bmy_publisher,$c2001
+=700  1\$aBob
+
+=LDR  00000nam a2200000 i 4500
+=001  2
+=100  1\$aAnn
+=245  10$asecond
+=264  \1$bmy_publisher,$c1999
+
+`, body)
+	})
+	t.Run("empty_csv", func(t *testing.T) {
+		srvMock.EXPECT().ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		res, body := export("?format=csv")
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal(strings.Join(exportColumns, ",")+"\n", body)
+	})
+	t.Run("service_error", func(t *testing.T) {
+		srvMock.EXPECT().ExportBooks(gomock.Any(), gomock.Any(), gomock.Any()).Return(models.ErrInternal{Message: "db error"})
+
+		res, _ := export("?format=ndjson")
+		a.Equal(http.StatusInternalServerError, res.StatusCode) // Check if the Code is 500
+		a.Empty(res.Header.Get("Content-Disposition"))
+	})
+	t.Run("invalid_format", func(t *testing.T) {
+		res, _ := export("?format=xml")
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("invalid_search", func(t *testing.T) {
+		res, _ := export("?status=lost")
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
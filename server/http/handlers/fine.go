@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+// fineStatuses maps fine statuses to their API representation.
+var fineStatuses = map[models.FineStatus]hm.FineStatus{
+	models.FineOpen:   hm.FineOpen,
+	models.FinePaid:   hm.FinePaid,
+	models.FineWaived: hm.FineWaived,
+}
+
+// ListPatronFines lists the fines of the patron. Callers with the patron role
+// list their own fines only.
+func (h *Book) ListPatronFines(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "patron")
+		return
+	}
+	patron, err := h.callerPatron(r.Context())
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if patron != nil && patron.ID != id {
+		sendHTTPError(w, models.ErrForbidden{Message: "patrons may only list their own fines"})
+		return
+	}
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	fs := &models.FineSearch{PatronID: &id}
+	if v := r.URL.Query().Get("status"); v != "" {
+		for st, name := range fineStatuses {
+			if v == string(name) {
+				st := st
+				fs.Status = &st
+				break
+			}
+		}
+		if fs.Status == nil {
+			sendHTTPError(w, models.ErrBadRequest{Message: "invalid status format, use 'open', 'paid' or 'waived'"})
+			return
+		}
+	}
+	fines, err := h.bk.GetFines(r.Context(), fs, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.FineResponse, len(fines))
+	for i := range fines {
+		resp[i] = toFineResponse(&fines[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) PayFine(w http.ResponseWriter, r *http.Request) {
+	h.settleFine(w, r, h.bk.PayFine)
+}
+
+func (h *Book) WaiveFine(w http.ResponseWriter, r *http.Request) {
+	h.settleFine(w, r, h.bk.WaiveFine)
+}
+
+func (h *Book) settleFine(w http.ResponseWriter, r *http.Request, settle func(ctx context.Context, id int) (*models.Fine, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "fine")
+		return
+	}
+	fine, err := settle(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toFineResponse(fine)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func toFineResponse(f *models.Fine) hm.FineResponse {
+	return hm.FineResponse{
+		ID:           f.ID,
+		LoanID:       f.LoanID,
+		PatronID:     f.PatronID,
+		Amount:       f.Amount,
+		Status:       fineStatuses[f.Status],
+		AccruedUntil: f.AccruedUntil,
+		SettledAt:    f.SettledAt,
+		CreatedAt:    f.CreatedAt,
+	}
+}
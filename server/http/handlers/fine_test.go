@@ -0,0 +1,141 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_ListPatronFines(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                            // router
+	router.HandleFunc("/patrons/{id}/fines", oh.ListPatronFines).Methods(http.MethodGet) // list fines route
+	// the caller of /patron/{subject} is authenticated with the patron role
+	router.HandleFunc("/patron/{subject}/patrons/{id}/fines", func(w http.ResponseWriter, r *http.Request) {
+		identity := &models.Identity{Subject: mux.Vars(r)["subject"], Role: models.RolePatron}
+		oh.ListPatronFines(w, r.WithContext(models.WithIdentity(r.Context(), identity)))
+	}).Methods(http.MethodGet)
+	srv := httptest.NewServer(router) // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		patronID, status := 5, models.FineOpen
+		srvMock.EXPECT().GetFines(gomock.Any(), &models.FineSearch{PatronID: &patronID, Status: &status}, gomock.Any(), 0).
+			Return([]models.Fine{{ID: 1, LoanID: 2, PatronID: 5, Amount: 75, Status: models.FineOpen}}, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/patrons/5/fines?status=open", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+
+		var fines []hm.FineResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&fines))
+		req.Len(fines, 1)
+		a.Equal(75, fines[0].Amount)
+		a.Equal(hm.FineOpen, fines[0].Status)
+	})
+	t.Run("invalid_status", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/patrons/5/fines?status=late", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("patron_own", func(t *testing.T) {
+		patronID := 5
+		srvMock.EXPECT().GetPatrons(gomock.Any(), &models.PatronSearch{MembershipNumber: "M1"}, 1, 0).
+			Return([]models.Patron{{ID: 5, MembershipNumber: "M1"}}, nil)
+		srvMock.EXPECT().GetFines(gomock.Any(), &models.FineSearch{PatronID: &patronID}, gomock.Any(), 0).Return(nil, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/patron/M1/patrons/5/fines", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("patron_other", func(t *testing.T) {
+		srvMock.EXPECT().GetPatrons(gomock.Any(), &models.PatronSearch{MembershipNumber: "M1"}, 1, 0).
+			Return([]models.Patron{{ID: 5, MembershipNumber: "M1"}}, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/patron/M1/patrons/6/fines", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+}
+
+func TestBook_PayFine(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                      // router
+	router.HandleFunc("/fines/{id}/pay", oh.PayFine).Methods(http.MethodPatch)     // pay fine route
+	router.HandleFunc("/fines/{id}/waive", oh.WaiveFine).Methods(http.MethodPatch) // waive fine route
+	srv := httptest.NewServer(router)                                              // test server
+	defer srv.Close()
+	patch := func(url string) *http.Response {
+		r, err := http.NewRequest(http.MethodPatch, srv.URL+url, nil)
+		req.NoError(err)
+		res, err := http.DefaultClient.Do(r)
+		req.NoError(err)
+		return res
+	}
+	t.Run("pay", func(t *testing.T) {
+		srvMock.EXPECT().PayFine(gomock.Any(), 1).Return(&models.Fine{ID: 1, Amount: 75, Status: models.FinePaid}, nil)
+
+		res := patch("/fines/1/pay")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+
+		var fine hm.FineResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&fine))
+		a.Equal(hm.FinePaid, fine.Status)
+	})
+	t.Run("still_accruing", func(t *testing.T) {
+		srvMock.EXPECT().PayFine(gomock.Any(), 2).Return(nil, models.ErrConflict{Message: "fine is still accruing, return the book first"})
+
+		res := patch("/fines/2/pay")
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+	t.Run("waive", func(t *testing.T) {
+		srvMock.EXPECT().WaiveFine(gomock.Any(), 3).Return(&models.Fine{ID: 3, Status: models.FineWaived}, nil)
+
+		res := patch("/fines/3/waive")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("not_found", func(t *testing.T) {
+		srvMock.EXPECT().WaiveFine(gomock.Any(), 4).Return(nil, models.ErrNotFound{Message: "fine not found"})
+
+		res := patch("/fines/4/waive")
+		defer res.Body.Close()
+		a.Equal(http.StatusNotFound, res.StatusCode) // Check if the Code is 404
+	})
+}
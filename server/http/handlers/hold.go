@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+// holdStatuses maps hold statuses to their API representation.
+var holdStatuses = map[models.HoldStatus]hm.HoldStatus{
+	models.HoldWaiting:   hm.HoldWaiting,
+	models.HoldReady:     hm.HoldReady,
+	models.HoldFulfilled: hm.HoldFulfilled,
+	models.HoldCancelled: hm.HoldCancelled,
+	models.HoldExpired:   hm.HoldExpired,
+}
+
+// AddHold queues a patron for the book. Callers with the patron role place
+// holds for themselves only, the patron may be left out of the request.
+func (h *Book) AddHold(w http.ResponseWriter, r *http.Request) {
+	var req hm.HoldRequest
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	patron, err := h.callerPatron(r.Context())
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if patron != nil {
+		if req.PatronID != 0 && req.PatronID != patron.ID {
+			sendHTTPError(w, models.ErrForbidden{Message: "patrons may only place holds for themselves"})
+			return
+		}
+		req.PatronID = patron.ID
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	hold := &models.Hold{BookID: id, PatronID: req.PatronID}
+	err = h.bk.AddHold(r.Context(), hold)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toHoldResponse(hold)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
+}
+
+// ListHolds lists the holds matching the filters. Callers with the patron role
+// list their own holds only.
+func (h *Book) ListHolds(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	hs, err := getHoldSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	patron, err := h.callerPatron(r.Context())
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if patron != nil {
+		if hs.PatronID != nil && *hs.PatronID != patron.ID {
+			sendHTTPError(w, models.ErrForbidden{Message: "patrons may only list their own holds"})
+			return
+		}
+		hs.PatronID = &patron.ID
+	}
+	holds, err := h.bk.GetHolds(r.Context(), hs, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toHoldsResponse(holds)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+// ListBookHolds lists the hold queue of the book in the order the holds were placed.
+func (h *Book) ListBookHolds(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	hs, err := getHoldSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	hs.BookID = &id
+	holds, err := h.bk.GetHolds(r.Context(), hs, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toHoldsResponse(holds)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+// CancelHold cancels the hold. Callers with the patron role cancel their own
+// holds only.
+func (h *Book) CancelHold(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "hold")
+		return
+	}
+	patron, err := h.callerPatron(r.Context())
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	if patron != nil {
+		hold, err := h.bk.GetHold(r.Context(), id)
+		if err != nil {
+			sendHTTPError(w, err)
+			return
+		}
+		if hold.PatronID != patron.ID {
+			sendHTTPError(w, models.ErrForbidden{Message: "patrons may only cancel their own holds"})
+			return
+		}
+	}
+	hold, err := h.bk.CancelHold(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toHoldResponse(hold)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+// callerPatron returns the patron record of a caller with the patron role,
+// authenticated with the membership number as subject. Other callers act for
+// any patron and get nil.
+func (h *Book) callerPatron(ctx context.Context) (*models.Patron, error) {
+	id, ok := models.IdentityFromContext(ctx)
+	if !ok || id.Role != models.RolePatron {
+		return nil, nil
+	}
+	if id.Subject != "" {
+		patrons, err := h.bk.GetPatrons(ctx, &models.PatronSearch{MembershipNumber: id.Subject}, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(patrons) > 0 {
+			return &patrons[0], nil
+		}
+	}
+	return nil, models.ErrForbidden{Message: "caller is not a patron of the library"}
+}
+
+func toHoldResponse(h *models.Hold) hm.HoldResponse {
+	return hm.HoldResponse{
+		ID:        h.ID,
+		BookID:    h.BookID,
+		PatronID:  h.PatronID,
+		CopyID:    h.CopyID,
+		Status:    holdStatuses[h.Status],
+		CreatedAt: h.CreatedAt,
+		ReadyAt:   h.ReadyAt,
+		ExpiresAt: h.ExpiresAt,
+	}
+}
+
+func toHoldsResponse(hs []models.Hold) []hm.HoldResponse {
+	resp := make([]hm.HoldResponse, len(hs))
+	for i := range hs {
+		resp[i] = toHoldResponse(&hs[i])
+	}
+	return resp
+}
@@ -0,0 +1,173 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_AddHold(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                   // router
+	router.HandleFunc("/books/{id}/holds", oh.AddHold).Methods(http.MethodPost) // add hold route
+	srv := httptest.NewServer(router)                                           // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().AddHold(gomock.Any(), &models.Hold{BookID: 1, PatronID: 5}).Return(nil)
+
+		reqBody, err := json.Marshal(&hm.HoldRequest{PatronID: 5})
+		req.NoError(err)
+		res, err := http.Post(fmt.Sprintf("%s/books/1/holds", srv.URL), "application/json", bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+
+		var hold hm.HoldResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&hold))
+		a.Equal(hm.HoldWaiting, hold.Status)
+	})
+	t.Run("validation", func(t *testing.T) {
+		res, err := http.Post(fmt.Sprintf("%s/books/1/holds", srv.URL), "application/json", bytes.NewBufferString("{}"))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("available", func(t *testing.T) {
+		srvMock.EXPECT().AddHold(gomock.Any(), gomock.Any()).Return(models.ErrConflict{Message: "book has an available copy"})
+
+		res, err := http.Post(fmt.Sprintf("%s/books/2/holds", srv.URL), "application/json", bytes.NewBufferString(`{"patron_id":5}`))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
+
+func TestBook_PatronHolds(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	// the caller of /{role}/{subject} is authenticated as the subject with the role
+	withIdentity := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			identity := &models.Identity{Subject: vars["subject"], Role: models.Role(vars["role"])}
+			next(w, r.WithContext(models.WithIdentity(r.Context(), identity)))
+		}
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/{role}/{subject}/books/{id}/holds", withIdentity(oh.AddHold)).Methods(http.MethodPost)
+	router.HandleFunc("/{role}/{subject}/holds/{id}", withIdentity(oh.CancelHold)).Methods(http.MethodDelete)
+	router.HandleFunc("/{role}/{subject}/holds", withIdentity(oh.ListHolds)).Methods(http.MethodGet)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	do := func(method, url, body string) *http.Response {
+		request, err := http.NewRequest(method, srv.URL+url, bytes.NewBufferString(body))
+		req.NoError(err)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		return res
+	}
+	expectPatron := func(number string, patrons ...models.Patron) {
+		srvMock.EXPECT().GetPatrons(gomock.Any(), &models.PatronSearch{MembershipNumber: number}, 1, 0).Return(patrons, nil)
+	}
+	t.Run("place_own", func(t *testing.T) {
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+		srvMock.EXPECT().AddHold(gomock.Any(), &models.Hold{BookID: 1, PatronID: 5}).Return(nil)
+
+		res := do(http.MethodPost, "/patron/M1/books/1/holds", "{}")
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+	})
+	t.Run("place_for_other", func(t *testing.T) {
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+
+		res := do(http.MethodPost, "/patron/M1/books/1/holds", `{"patron_id":6}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+	t.Run("not_a_patron", func(t *testing.T) {
+		expectPatron("M9")
+
+		res := do(http.MethodPost, "/patron/M9/books/1/holds", "{}")
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+	t.Run("librarian_places_for_patron", func(t *testing.T) {
+		srvMock.EXPECT().AddHold(gomock.Any(), &models.Hold{BookID: 1, PatronID: 6}).Return(nil)
+
+		res := do(http.MethodPost, "/librarian/lib/books/1/holds", `{"patron_id":6}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+	})
+	t.Run("cancel_own", func(t *testing.T) {
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+		srvMock.EXPECT().GetHold(gomock.Any(), 3).Return(&models.Hold{ID: 3, BookID: 1, PatronID: 5}, nil)
+		srvMock.EXPECT().CancelHold(gomock.Any(), 3).Return(&models.Hold{ID: 3, BookID: 1, PatronID: 5, Status: models.HoldCancelled}, nil)
+
+		res := do(http.MethodDelete, "/patron/M1/holds/3", "")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("cancel_other", func(t *testing.T) {
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+		srvMock.EXPECT().GetHold(gomock.Any(), 4).Return(&models.Hold{ID: 4, BookID: 1, PatronID: 6}, nil)
+
+		res := do(http.MethodDelete, "/patron/M1/holds/4", "")
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+	t.Run("list_own", func(t *testing.T) {
+		patronID := 5
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+		srvMock.EXPECT().GetHolds(gomock.Any(), &models.HoldSearch{PatronID: &patronID}, defaultLimit, 0).
+			Return([]models.Hold{{ID: 3, BookID: 1, PatronID: 5}}, nil)
+
+		res := do(http.MethodGet, "/patron/M1/holds", "")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+	t.Run("list_other", func(t *testing.T) {
+		expectPatron("M1", models.Patron{ID: 5, MembershipNumber: "M1"})
+
+		res := do(http.MethodGet, "/patron/M1/holds?patron_id=6", "")
+		defer res.Body.Close()
+		a.Equal(http.StatusForbidden, res.StatusCode) // Check if the Code is 403
+	})
+	t.Run("librarian_lists_all", func(t *testing.T) {
+		srvMock.EXPECT().GetHolds(gomock.Any(), &models.HoldSearch{}, defaultLimit, 0).Return(nil, nil)
+
+		res := do(http.MethodGet, "/librarian/lib/holds", "")
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+	})
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+const (
+	csvType       = "text/csv"
+	jsonLinesType = "application/x-ndjson"
+	// importBatchSize is how many books are imported in one transaction.
+	importBatchSize = 200
+)
+
+// importStatuses maps import outcomes to their API representation.
+var importStatuses = map[models.ImportStatus]hm.ImportStatus{
+	models.ImportCreated:   hm.ImportCreated,
+	models.ImportDuplicate: hm.ImportDuplicate,
+	models.ImportRejected:  hm.ImportRejected,
+}
+
+// bookReader reads the rows of an imported file as books. Rows that are not
+// a book are returned with an ErrBadRequest, io.EOF ends the file.
+type bookReader interface {
+	next() (row int, b *hm.Book, err error)
+}
+
+// ImportBooks adds the books of a CSV or JSON Lines body. The rows are read
+// as a stream and imported in batches of importBatchSize, each batch in its
+// own transaction, so the batches before a failed one stay imported. Every
+// row is validated like a book added alone, the report tells for each row
+// whether it was created, a duplicate or rejected. An import stopped by an
+// error is answered with the status of the error and the report of the rows
+// read so far, those not imported are marked so.
+func (h *Book) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	var dryRun bool
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			sendHTTPError(w, models.ErrBadRequest{Message: "invalid dry_run format, use 'true' or 'false'"})
+			return
+		}
+	}
+	defer r.Body.Close()
+	rows, err := newBookReader(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	report := hm.ImportReport{DryRun: dryRun, Rows: []hm.ImportRowResult{}}
+	var (
+		batch []*models.Book
+		// indexes of the rows of the batch in the report
+		batchRows []int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		res, err := h.bk.ImportBooks(r.Context(), batch, dryRun)
+		if err != nil {
+			return err
+		}
+		for i, ir := range res {
+			row := &report.Rows[batchRows[i]]
+			row.Status = importStatuses[ir.Status]
+			row.BookID = ir.BookID
+			row.Error = ir.Message
+		}
+		batch, batchRows = nil, nil
+		return nil
+	}
+	fail := func(err error) {
+		code, message, _ := httpError(err)
+		for i := range report.Rows {
+			if report.Rows[i].Status == "" {
+				report.Rows[i].Status = hm.ImportNotImported
+			}
+		}
+		report.Error = message
+		countImportRows(&report)
+		sendResponseWithBody(w, code, report)
+	}
+	for {
+		row, req, err := rows.next()
+		if err == io.EOF {
+			break
+		}
+		if e, ok := err.(models.ErrBadRequest); ok {
+			report.Rows = append(report.Rows, hm.ImportRowResult{Row: row, Status: hm.ImportRejected, Error: e.Message})
+			continue
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := req.Validate(); err != nil {
+			report.Rows = append(report.Rows, hm.ImportRowResult{Row: row, Status: hm.ImportRejected, Error: err.Error()})
+			continue
+		}
+		report.Rows = append(report.Rows, hm.ImportRowResult{Row: row})
+		batch = append(batch, toBook(req))
+		batchRows = append(batchRows, len(report.Rows)-1)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				fail(err)
+				return
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		fail(err)
+		return
+	}
+	countImportRows(&report)
+	sendResponseWithBody(w, http.StatusOK, report)
+}
+
+// countImportRows counts the rows of the report by their outcome.
+func countImportRows(report *hm.ImportReport) {
+	for _, row := range report.Rows {
+		switch row.Status {
+		case hm.ImportCreated:
+			report.Created++
+		case hm.ImportDuplicate:
+			report.Duplicates++
+		case hm.ImportRejected:
+			report.Rejected++
+		case hm.ImportNotImported:
+			report.NotImported++
+		}
+	}
+}
+
+// newBookReader reads the request body by its content type.
+func newBookReader(r *http.Request) (bookReader, error) {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mt = ""
+	}
+	switch mt {
+	case csvType:
+		return newCSVBookReader(r.Body)
+	case jsonLinesType, "application/jsonl":
+		return &jsonLinesBookReader{r: bufio.NewReader(r.Body)}, nil
+	default:
+		return nil, models.ErrUnsupportedMediaType{Message: "use content type " + csvType + " or " + jsonLinesType}
+	}
+}
+
+// csvColumns are the columns of imported CSV files, named like the fields of
+// a book. Author ids are separated by semicolons.
+var csvColumns = []string{"name", "isbn", "author", "author_ids", "publisher", "publisher_id", "publish_date"}
+
+// csvBookReader reads books from a CSV file with a header row naming the
+// columns. Publish dates are formatted as yyyy-mm-dd or RFC 3339.
+type csvBookReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func newCSVBookReader(body io.Reader) (*csvBookReader, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, models.ErrBadRequest{Message: "missing CSV header"}
+	}
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return nil, models.ErrBadRequest{Message: "invalid CSV header: " + pe.Err.Error()}
+	}
+	if err != nil {
+		return nil, models.ErrInternal{Message: err.Error()}
+	}
+	seen := make(map[string]bool, len(header))
+	for i, c := range header {
+		c = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(c, "\ufeff")))
+		if !contains(csvColumns, c) {
+			return nil, models.ErrBadRequest{
+				Message: fmt.Sprintf("invalid column '%s', use %s", c, strings.Join(csvColumns, ", ")),
+			}
+		}
+		if seen[c] {
+			return nil, models.ErrBadRequest{Message: fmt.Sprintf("column '%s' is repeated", c)}
+		}
+		seen[c] = true
+		header[i] = c
+	}
+	return &csvBookReader{r: r, columns: header}, nil
+}
+
+func (c *csvBookReader) next() (int, *hm.Book, error) {
+	rec, err := c.r.Read()
+	if err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return pe.StartLine, nil, models.ErrBadRequest{Message: pe.Err.Error()}
+	}
+	if err != nil {
+		return 0, nil, models.ErrInternal{Message: err.Error()}
+	}
+	row, _ := c.r.FieldPos(0)
+	if len(rec) != len(c.columns) {
+		return row, nil, models.ErrBadRequest{
+			Message: fmt.Sprintf("row has %d fields, the header %d", len(rec), len(c.columns)),
+		}
+	}
+	b := new(hm.Book)
+	for i, v := range rec {
+		v = strings.TrimSpace(v)
+		switch c.columns[i] {
+		case "name":
+			b.Title = v
+		case "isbn":
+			b.ISBN = v
+		case "author":
+			b.Author = v
+		case "author_ids":
+			for _, f := range strings.FieldsFunc(v, func(r rune) bool { return r == ';' || unicode.IsSpace(r) }) {
+				id, err := strconv.Atoi(f)
+				if err != nil {
+					return row, nil, models.ErrBadRequest{Message: "invalid author_ids format"}
+				}
+				b.AuthorIDs = append(b.AuthorIDs, id)
+			}
+		case "publisher":
+			b.Publisher = v
+		case "publisher_id":
+			if v == "" {
+				continue
+			}
+			if b.PublisherID, err = strconv.Atoi(v); err != nil {
+				return row, nil, models.ErrBadRequest{Message: "invalid publisher_id format"}
+			}
+		case "publish_date":
+			if v == "" {
+				continue
+			}
+			if b.PublishDate, err = time.Parse(timeFormat, v); err != nil {
+				if b.PublishDate, err = time.Parse(time.RFC3339, v); err != nil {
+					return row, nil, models.ErrBadRequest{Message: "invalid publish_date format, use 'yyyy-mm-dd'"}
+				}
+			}
+		}
+	}
+	return row, b, nil
+}
+
+// jsonLinesBookReader reads books from JSON Lines, one book per line in the
+// representation of a book added alone. Blank lines are skipped.
+type jsonLinesBookReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (j *jsonLinesBookReader) next() (int, *hm.Book, error) {
+	for {
+		line, err := j.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, nil, models.ErrInternal{Message: err.Error()}
+		}
+		if len(line) == 0 && err == io.EOF {
+			return 0, nil, io.EOF
+		}
+		j.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var b hm.Book
+		if err := json.Unmarshal(line, &b); err != nil {
+			return j.line, nil, models.ErrBadRequest{Message: err.Error()}
+		}
+		return j.line, &b, nil
+	}
+}
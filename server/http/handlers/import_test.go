@@ -0,0 +1,175 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_ImportBooks(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                   // router
+	router.HandleFunc("/books:import", oh.ImportBooks).Methods(http.MethodPost) // import books route
+	srv := httptest.NewServer(router)                                           // test server
+	defer srv.Close()
+
+	importBooks := func(query, contentType, body string) (*http.Response, hm.ImportReport) {
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/books:import%s", srv.URL, query), bytes.NewBufferString(body))
+		req.NoError(err)
+		request.Header.Set("Content-Type", contentType)
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		defer res.Body.Close()
+		var report hm.ImportReport
+		req.NoError(json.NewDecoder(res.Body).Decode(&report))
+		return res, report
+	}
+	publishDate := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	t.Run("csv", func(t *testing.T) {
+		srvMock.EXPECT().ImportBooks(gomock.Any(), []*models.Book{
+			{
+				Title:       "first",
+				ISBN:        "0306406152",
+				Author:      "my_author",
+				Authors:     []models.Author{{Name: "my_author"}},
+				Publisher:   "my_publisher",
+				PublishDate: publishDate,
+			},
+			{
+				Title:       "third",
+				Authors:     []models.Author{{ID: 1}, {ID: 2}},
+				PublisherID: 3,
+				PublishDate: publishDate,
+			},
+		}, false).Return([]models.ImportResult{
+			{Status: models.ImportCreated, BookID: 11},
+			{Status: models.ImportDuplicate, Message: "book with this isbn already exists"},
+		}, nil)
+
+		res, report := importBooks("", "text/csv; charset=utf-8", `name,isbn,author,author_ids,publisher,publisher_id,publish_date
+first,0306406152,my_author,,my_publisher,,2001-02-03
+second,,my_author,,my_publisher,,
+third,,,1;2,,3,2001-02-03T00:00:00Z
+"fourth,my_author
+`)
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal(1, report.Created)
+		a.Equal(1, report.Duplicates)
+		a.Equal(2, report.Rejected)
+		req.Len(report.Rows, 4)
+		a.Equal(hm.ImportRowResult{Row: 2, Status: hm.ImportCreated, BookID: 11}, report.Rows[0])
+		a.Equal(3, report.Rows[1].Row)
+		a.Equal(hm.ImportRejected, report.Rows[1].Status)
+		a.Contains(report.Rows[1].Error, "publish_date")
+		a.Equal(hm.ImportRowResult{Row: 4, Status: hm.ImportDuplicate, Error: "book with this isbn already exists"}, report.Rows[2])
+		a.Equal(5, report.Rows[3].Row)
+		a.Equal(hm.ImportRejected, report.Rows[3].Status)
+	})
+	t.Run("json_lines_dry_run", func(t *testing.T) {
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Len(1), true).Return([]models.ImportResult{
+			{Status: models.ImportCreated},
+		}, nil)
+
+		res, report := importBooks("?dry_run=true", "application/x-ndjson", `{"name":"first","author":"my_author","publisher":"my_publisher","publish_date":"2001-02-03T00:00:00Z"}
+
+{"name":
+`)
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.True(report.DryRun)
+		a.Equal(1, report.Created)
+		a.Equal(1, report.Rejected)
+		req.Len(report.Rows, 2)
+		a.Equal(hm.ImportRowResult{Row: 1, Status: hm.ImportCreated}, report.Rows[0])
+		a.Equal(3, report.Rows[1].Row)
+	})
+	t.Run("batches", func(t *testing.T) {
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Len(importBatchSize), false).
+			DoAndReturn(func(_ interface{}, books []*models.Book, _ bool) ([]models.ImportResult, error) {
+				return make([]models.ImportResult, len(books)), nil
+			})
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Len(1), false).Return(make([]models.ImportResult, 1), nil)
+
+		body := "name,author,publisher,publish_date\n" +
+			strings.Repeat("title,my_author,my_publisher,2001-02-03\n", importBatchSize+1)
+		res, report := importBooks("", "text/csv", body)
+		a.Equal(http.StatusOK, res.StatusCode)
+		a.Equal(importBatchSize+1, report.Created)
+	})
+	t.Run("service_error", func(t *testing.T) {
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Any(), false).Return(nil, models.ErrInternal{Message: "db error"})
+
+		res, report := importBooks("", "text/csv", "name,author,publisher,publish_date\ntitle,my_author,my_publisher,2001-02-03\n")
+		a.Equal(http.StatusInternalServerError, res.StatusCode) // Check if the Code is 500
+		a.Equal(1, report.NotImported)
+		a.NotEmpty(report.Error)
+	})
+	t.Run("second_batch_fails", func(t *testing.T) {
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Len(importBatchSize), false).
+			DoAndReturn(func(_ interface{}, books []*models.Book, _ bool) ([]models.ImportResult, error) {
+				res := make([]models.ImportResult, len(books))
+				for i := range res {
+					res[i] = models.ImportResult{Status: models.ImportCreated, BookID: i + 1}
+				}
+				return res, nil
+			})
+		srvMock.EXPECT().ImportBooks(gomock.Any(), gomock.Len(2), false).Return(nil, models.ErrInternal{Message: "db error"})
+
+		body := "name,author,publisher,publish_date\n" +
+			strings.Repeat("title,my_author,my_publisher,2001-02-03\n", importBatchSize) +
+			"title,my_author,my_publisher,\n" +
+			strings.Repeat("title,my_author,my_publisher,2001-02-03\n", 2)
+		res, report := importBooks("", "text/csv", body)
+		a.Equal(http.StatusInternalServerError, res.StatusCode) // Check if the Code is 500
+		// the rows of the first batch stay imported and are reported
+		a.Equal(importBatchSize, report.Created)
+		a.Equal(1, report.Rejected)
+		a.Equal(2, report.NotImported)
+		a.Equal("oops, something went wrong", report.Error)
+		req.Len(report.Rows, importBatchSize+3)
+		a.Equal(hm.ImportRowResult{Row: 2, Status: hm.ImportCreated, BookID: 1}, report.Rows[0])
+		a.Equal(hm.ImportRejected, report.Rows[importBatchSize].Status)
+		a.Equal(hm.ImportRowResult{Row: importBatchSize + 3, Status: hm.ImportNotImported}, report.Rows[importBatchSize+1])
+	})
+	t.Run("invalid_column", func(t *testing.T) {
+		res, _ := importBooks("", "text/csv", "name,title\n")
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("missing_header", func(t *testing.T) {
+		res, _ := importBooks("", "text/csv", "")
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("invalid_dry_run", func(t *testing.T) {
+		res, _ := importBooks("?dry_run=maybe", "text/csv", "name\n")
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("unsupported_media_type", func(t *testing.T) {
+		res, _ := importBooks("", "application/json", "[]")
+		a.Equal(http.StatusUnsupportedMediaType, res.StatusCode) // Check if the Code is 415
+	})
+}
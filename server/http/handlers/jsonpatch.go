@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+// mergePatch applies an RFC 7396 JSON merge patch to the document: members of
+// a patch object replace the members of the document, null removes them and
+// anything but an object replaces the document.
+func mergePatch(doc, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		d = make(map[string]interface{}, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+			continue
+		}
+		d[k] = mergePatch(d[k], v)
+	}
+	return d
+}
+
+// patchOperation is an operation of an RFC 6902 JSON patch.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch applies the operations of an RFC 6902 JSON patch to the document
+// in order. Malformed operations are bad requests, operations that do not fit
+// the document and failed tests are conflicts.
+func jsonPatch(doc interface{}, ops []patchOperation) (interface{}, error) {
+	var err error
+	for i, op := range ops {
+		doc, err = op.apply(doc)
+		if err != nil {
+			switch e := err.(type) {
+			case models.ErrBadRequest:
+				e.Message = fmt.Sprintf("operation %d: %s", i, e.Message)
+				return nil, e
+			case models.ErrConflict:
+				e.Message = fmt.Sprintf("operation %d: %s", i, e.Message)
+				return nil, e
+			}
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+func (op patchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				return value, nil
+			}
+			doc, err = removeValue(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		}
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, models.ErrConflict{Message: fmt.Sprintf("test of '%s' failed", op.Path)}
+		}
+		return doc, nil
+	case "remove":
+		return removeValue(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return addValue(doc, path, copyValue(value))
+		}
+		if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return nil, models.ErrBadRequest{Message: "can not move a value into itself"}
+		}
+		doc, err = removeValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	default:
+		return nil, models.ErrBadRequest{Message: fmt.Sprintf("invalid op '%s'", op.Op)}
+	}
+}
+
+func (op patchOperation) value() (interface{}, error) {
+	if op.Value == nil {
+		return nil, models.ErrBadRequest{Message: fmt.Sprintf("%s needs a value", op.Op)}
+	}
+	var v interface{}
+	if err := json.Unmarshal(op.Value, &v); err != nil {
+		return nil, models.ErrBadRequest{Message: err.Error()}
+	}
+	return v, nil
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, models.ErrBadRequest{Message: fmt.Sprintf("invalid path '%s'", p)}
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, t := range path {
+		switch n := doc.(type) {
+		case map[string]interface{}:
+			v, ok := n[t]
+			if !ok {
+				return nil, pathNotFound(path)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(t, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = n[i]
+		default:
+			return nil, pathNotFound(path)
+		}
+	}
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	return updateValue(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[key] = value
+			return n, nil
+		case []interface{}:
+			if key == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(key, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		default:
+			return nil, pathNotFound(path)
+		}
+	}, value)
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, models.ErrBadRequest{Message: "can not remove the whole document"}
+	}
+	return updateValue(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[key]; !ok {
+				return nil, pathNotFound(path)
+			}
+			delete(n, key)
+			return n, nil
+		case []interface{}:
+			i, err := arrayIndex(key, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:i], n[i+1:]...), nil
+		default:
+			return nil, pathNotFound(path)
+		}
+	}, nil)
+}
+
+// updateValue calls update with the parent of the path and the last token of
+// the path and stores the result of update in place of the parent. An empty
+// path replaces the document by root.
+func updateValue(doc interface{}, path []string, update func(parent interface{}, key string) (interface{}, error), root interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return root, nil
+	}
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, pathNotFound(path)
+		}
+		child, err := updateValue(child, path[1:], update, root)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = child
+		return n, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updateValue(n[i], path[1:], update, root)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	default:
+		return nil, pathNotFound(path)
+	}
+}
+
+// arrayIndex parses an array index of a JSON pointer not greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, models.ErrBadRequest{Message: fmt.Sprintf("invalid array index '%s'", token)}
+	}
+	if i > max {
+		return 0, models.ErrConflict{Message: fmt.Sprintf("array index %d is out of range", i)}
+	}
+	return i, nil
+}
+
+func pathNotFound(path []string) error {
+	return models.ErrConflict{Message: fmt.Sprintf("path '/%s' does not exist", strings.Join(path, "/"))}
+}
+
+// copyValue deep copies a decoded JSON value.
+func copyValue(v interface{}) interface{} {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(n))
+		for k, e := range n {
+			m[k] = copyValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(n))
+		for i, e := range n {
+			s[i] = copyValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
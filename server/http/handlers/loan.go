@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+func (h *Book) ListLoans(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	ls, err := getLoanSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	loans, err := h.bk.GetLoans(r.Context(), ls, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toLoansResponse(loans)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) ListBookLoans(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	ls, err := getLoanSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	ls.BookID = &id
+	loans, err := h.bk.GetLoans(r.Context(), ls, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toLoansResponse(loans)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func toLoanResponse(l *models.Loan) hm.LoanResponse {
+	return hm.LoanResponse{
+		ID:           l.ID,
+		BookID:       l.BookID,
+		CopyID:       l.CopyID,
+		BorrowerID:   l.BorrowerID,
+		CheckedOutAt: l.CheckedOutAt,
+		DueDate:      l.DueAt,
+		ReturnedAt:   l.ReturnedAt,
+		OverdueAt:    l.OverdueAt,
+		Overdue:      l.IsOverdue(time.Now().UTC()),
+	}
+}
+
+func toLoansResponse(ls []models.Loan) []hm.LoanResponse {
+	resp := make([]hm.LoanResponse, len(ls))
+	for i := range ls {
+		resp[i] = toLoanResponse(&ls[i])
+	}
+	return resp
+}
@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/libreria/models"
 	reflect "reflect"
+	time "time"
 )
 
 // MockBookKeeper is a mock of BookKeeper interface
@@ -48,6 +49,36 @@ func (mr *MockBookKeeperMockRecorder) AddBook(ctx, b interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBook", reflect.TypeOf((*MockBookKeeper)(nil).AddBook), ctx, b)
 }
 
+// ImportBooks mocks base method
+func (m *MockBookKeeper) ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportBooks", ctx, books, dryRun)
+	ret0, _ := ret[0].([]models.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportBooks indicates an expected call of ImportBooks
+func (mr *MockBookKeeperMockRecorder) ImportBooks(ctx, books, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBooks", reflect.TypeOf((*MockBookKeeper)(nil).ImportBooks), ctx, books, dryRun)
+}
+
+// ApplyBookBatch mocks base method
+func (m *MockBookKeeper) ApplyBookBatch(ctx context.Context, ops []models.BookOperation) ([]models.BookOperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBookBatch", ctx, ops)
+	ret0, _ := ret[0].([]models.BookOperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyBookBatch indicates an expected call of ApplyBookBatch
+func (mr *MockBookKeeperMockRecorder) ApplyBookBatch(ctx, ops interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBookBatch", reflect.TypeOf((*MockBookKeeper)(nil).ApplyBookBatch), ctx, ops)
+}
+
 // GetBook mocks base method
 func (m *MockBookKeeper) GetBook(ctx context.Context, id int) (*models.Book, error) {
 	m.ctrl.T.Helper()
@@ -63,19 +94,48 @@ func (mr *MockBookKeeperMockRecorder) GetBook(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockBookKeeper)(nil).GetBook), ctx, id)
 }
 
+// GetBookByISBN mocks base method
+func (m *MockBookKeeper) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookByISBN", ctx, isbn)
+	ret0, _ := ret[0].(*models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookByISBN indicates an expected call of GetBookByISBN
+func (mr *MockBookKeeperMockRecorder) GetBookByISBN(ctx, isbn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByISBN", reflect.TypeOf((*MockBookKeeper)(nil).GetBookByISBN), ctx, isbn)
+}
+
 // GetBooks mocks base method
-func (m *MockBookKeeper) GetBooks(ctx context.Context, bs *models.BookSearch, limit, offset int) ([]models.Book, error) {
+func (m *MockBookKeeper) GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBooks", ctx, bs, limit, offset)
-	ret0, _ := ret[0].([]models.Book)
+	ret := m.ctrl.Call(m, "GetBooks", ctx, bs, p)
+	ret0, _ := ret[0].(*models.BookList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBooks indicates an expected call of GetBooks
-func (mr *MockBookKeeperMockRecorder) GetBooks(ctx, bs, limit, offset interface{}) *gomock.Call {
+func (mr *MockBookKeeperMockRecorder) GetBooks(ctx, bs, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockBookKeeper)(nil).GetBooks), ctx, bs, p)
+}
+
+// ExportBooks mocks base method
+func (m *MockBookKeeper) ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(*models.Book) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBooks", ctx, bs, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportBooks indicates an expected call of ExportBooks
+func (mr *MockBookKeeperMockRecorder) ExportBooks(ctx, bs, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBooks", reflect.TypeOf((*MockBookKeeper)(nil).GetBooks), ctx, bs, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBooks", reflect.TypeOf((*MockBookKeeper)(nil).ExportBooks), ctx, bs, fn)
 }
 
 // UpdateBook mocks base method
@@ -92,44 +152,498 @@ func (mr *MockBookKeeperMockRecorder) UpdateBook(ctx, b interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockBookKeeper)(nil).UpdateBook), ctx, b)
 }
 
-// UpdateBookStatus mocks base method
-func (m *MockBookKeeper) UpdateBookStatus(ctx context.Context, id, status int) error {
+// RateBook mocks base method
+func (m *MockBookKeeper) RateBook(ctx context.Context, id int, userID string, rate int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateBookStatus", ctx, id, status)
+	ret := m.ctrl.Call(m, "RateBook", ctx, id, userID, rate)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateBookStatus indicates an expected call of UpdateBookStatus
-func (mr *MockBookKeeperMockRecorder) UpdateBookStatus(ctx, id, status interface{}) *gomock.Call {
+// RateBook indicates an expected call of RateBook
+func (mr *MockBookKeeperMockRecorder) RateBook(ctx, id, userID, rate interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBookStatus", reflect.TypeOf((*MockBookKeeper)(nil).UpdateBookStatus), ctx, id, status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateBook", reflect.TypeOf((*MockBookKeeper)(nil).RateBook), ctx, id, userID, rate)
 }
 
-// RateBook mocks base method
-func (m *MockBookKeeper) RateBook(ctx context.Context, id, rate int) error {
+// DeleteBook mocks base method
+func (m *MockBookKeeper) DeleteBook(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RateBook", ctx, id, rate)
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RateBook indicates an expected call of RateBook
-func (mr *MockBookKeeperMockRecorder) RateBook(ctx, id, rate interface{}) *gomock.Call {
+// DeleteBook indicates an expected call of DeleteBook
+func (mr *MockBookKeeperMockRecorder) DeleteBook(ctx, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateBook", reflect.TypeOf((*MockBookKeeper)(nil).RateBook), ctx, id, rate)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockBookKeeper)(nil).DeleteBook), ctx, id, version)
 }
 
-// DeleteBook mocks base method
-func (m *MockBookKeeper) DeleteBook(ctx context.Context, id int) error {
+// RestoreBook mocks base method
+func (m *MockBookKeeper) RestoreBook(ctx context.Context, id int) (*models.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBook", ctx, id)
+	ret0, _ := ret[0].(*models.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBook indicates an expected call of RestoreBook
+func (mr *MockBookKeeperMockRecorder) RestoreBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBook", reflect.TypeOf((*MockBookKeeper)(nil).RestoreBook), ctx, id)
+}
+
+// PurgeBook mocks base method
+func (m *MockBookKeeper) PurgeBook(ctx context.Context, id, version int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret := m.ctrl.Call(m, "PurgeBook", ctx, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteBook indicates an expected call of DeleteBook
-func (mr *MockBookKeeperMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+// PurgeBook indicates an expected call of PurgeBook
+func (mr *MockBookKeeperMockRecorder) PurgeBook(ctx, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeBook", reflect.TypeOf((*MockBookKeeper)(nil).PurgeBook), ctx, id, version)
+}
+
+// GetBookHistory mocks base method
+func (m *MockBookKeeper) GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookHistory", ctx, bookID, limit, offset)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookHistory indicates an expected call of GetBookHistory
+func (mr *MockBookKeeperMockRecorder) GetBookHistory(ctx, bookID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookHistory", reflect.TypeOf((*MockBookKeeper)(nil).GetBookHistory), ctx, bookID, limit, offset)
+}
+
+// CheckoutBook mocks base method
+func (m *MockBookKeeper) CheckoutBook(ctx context.Context, bookID, copyID, borrowerID int, dueAt time.Time) (*models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckoutBook", ctx, bookID, copyID, borrowerID, dueAt)
+	ret0, _ := ret[0].(*models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckoutBook indicates an expected call of CheckoutBook
+func (mr *MockBookKeeperMockRecorder) CheckoutBook(ctx, bookID, copyID, borrowerID, dueAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckoutBook", reflect.TypeOf((*MockBookKeeper)(nil).CheckoutBook), ctx, bookID, copyID, borrowerID, dueAt)
+}
+
+// ReturnBook mocks base method
+func (m *MockBookKeeper) ReturnBook(ctx context.Context, bookID, copyID int) (*models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReturnBook", ctx, bookID, copyID)
+	ret0, _ := ret[0].(*models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReturnBook indicates an expected call of ReturnBook
+func (mr *MockBookKeeperMockRecorder) ReturnBook(ctx, bookID, copyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReturnBook", reflect.TypeOf((*MockBookKeeper)(nil).ReturnBook), ctx, bookID, copyID)
+}
+
+// GetLoans mocks base method
+func (m *MockBookKeeper) GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoans", ctx, ls, limit, offset)
+	ret0, _ := ret[0].([]models.Loan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoans indicates an expected call of GetLoans
+func (mr *MockBookKeeperMockRecorder) GetLoans(ctx, ls, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoans", reflect.TypeOf((*MockBookKeeper)(nil).GetLoans), ctx, ls, limit, offset)
+}
+
+// AddPatron mocks base method
+func (m *MockBookKeeper) AddPatron(ctx context.Context, p *models.Patron) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPatron", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPatron indicates an expected call of AddPatron
+func (mr *MockBookKeeperMockRecorder) AddPatron(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPatron", reflect.TypeOf((*MockBookKeeper)(nil).AddPatron), ctx, p)
+}
+
+// GetPatron mocks base method
+func (m *MockBookKeeper) GetPatron(ctx context.Context, id int) (*models.Patron, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatron", ctx, id)
+	ret0, _ := ret[0].(*models.Patron)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatron indicates an expected call of GetPatron
+func (mr *MockBookKeeperMockRecorder) GetPatron(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatron", reflect.TypeOf((*MockBookKeeper)(nil).GetPatron), ctx, id)
+}
+
+// GetPatrons mocks base method
+func (m *MockBookKeeper) GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatrons", ctx, ps, limit, offset)
+	ret0, _ := ret[0].([]models.Patron)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatrons indicates an expected call of GetPatrons
+func (mr *MockBookKeeperMockRecorder) GetPatrons(ctx, ps, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatrons", reflect.TypeOf((*MockBookKeeper)(nil).GetPatrons), ctx, ps, limit, offset)
+}
+
+// UpdatePatron mocks base method
+func (m *MockBookKeeper) UpdatePatron(ctx context.Context, p *models.Patron) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePatron", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePatron indicates an expected call of UpdatePatron
+func (mr *MockBookKeeperMockRecorder) UpdatePatron(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatron", reflect.TypeOf((*MockBookKeeper)(nil).UpdatePatron), ctx, p)
+}
+
+// DeletePatron mocks base method
+func (m *MockBookKeeper) DeletePatron(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePatron", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePatron indicates an expected call of DeletePatron
+func (mr *MockBookKeeperMockRecorder) DeletePatron(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatron", reflect.TypeOf((*MockBookKeeper)(nil).DeletePatron), ctx, id)
+}
+
+// GetFines mocks base method
+func (m *MockBookKeeper) GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFines", ctx, fs, limit, offset)
+	ret0, _ := ret[0].([]models.Fine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFines indicates an expected call of GetFines
+func (mr *MockBookKeeperMockRecorder) GetFines(ctx, fs, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFines", reflect.TypeOf((*MockBookKeeper)(nil).GetFines), ctx, fs, limit, offset)
+}
+
+// PayFine mocks base method
+func (m *MockBookKeeper) PayFine(ctx context.Context, id int) (*models.Fine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PayFine", ctx, id)
+	ret0, _ := ret[0].(*models.Fine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PayFine indicates an expected call of PayFine
+func (mr *MockBookKeeperMockRecorder) PayFine(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PayFine", reflect.TypeOf((*MockBookKeeper)(nil).PayFine), ctx, id)
+}
+
+// WaiveFine mocks base method
+func (m *MockBookKeeper) WaiveFine(ctx context.Context, id int) (*models.Fine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaiveFine", ctx, id)
+	ret0, _ := ret[0].(*models.Fine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaiveFine indicates an expected call of WaiveFine
+func (mr *MockBookKeeperMockRecorder) WaiveFine(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaiveFine", reflect.TypeOf((*MockBookKeeper)(nil).WaiveFine), ctx, id)
+}
+
+// AddHold mocks base method
+func (m *MockBookKeeper) AddHold(ctx context.Context, h *models.Hold) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddHold", ctx, h)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddHold indicates an expected call of AddHold
+func (mr *MockBookKeeperMockRecorder) AddHold(ctx, h interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddHold", reflect.TypeOf((*MockBookKeeper)(nil).AddHold), ctx, h)
+}
+
+// GetHolds mocks base method
+func (m *MockBookKeeper) GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHolds", ctx, hs, limit, offset)
+	ret0, _ := ret[0].([]models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHolds indicates an expected call of GetHolds
+func (mr *MockBookKeeperMockRecorder) GetHolds(ctx, hs, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHolds", reflect.TypeOf((*MockBookKeeper)(nil).GetHolds), ctx, hs, limit, offset)
+}
+
+// GetHold mocks base method
+func (m *MockBookKeeper) GetHold(ctx context.Context, id int) (*models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(*models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold
+func (mr *MockBookKeeperMockRecorder) GetHold(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockBookKeeper)(nil).GetHold), ctx, id)
+}
+
+// CancelHold mocks base method
+func (m *MockBookKeeper) CancelHold(ctx context.Context, id int) (*models.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelHold", ctx, id)
+	ret0, _ := ret[0].(*models.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelHold indicates an expected call of CancelHold
+func (mr *MockBookKeeperMockRecorder) CancelHold(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelHold", reflect.TypeOf((*MockBookKeeper)(nil).CancelHold), ctx, id)
+}
+
+// AddCopy mocks base method
+func (m *MockBookKeeper) AddCopy(ctx context.Context, c *models.Copy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCopy", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCopy indicates an expected call of AddCopy
+func (mr *MockBookKeeperMockRecorder) AddCopy(ctx, c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCopy", reflect.TypeOf((*MockBookKeeper)(nil).AddCopy), ctx, c)
+}
+
+// GetCopy mocks base method
+func (m *MockBookKeeper) GetCopy(ctx context.Context, id int) (*models.Copy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCopy", ctx, id)
+	ret0, _ := ret[0].(*models.Copy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCopy indicates an expected call of GetCopy
+func (mr *MockBookKeeperMockRecorder) GetCopy(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCopy", reflect.TypeOf((*MockBookKeeper)(nil).GetCopy), ctx, id)
+}
+
+// GetCopies mocks base method
+func (m *MockBookKeeper) GetCopies(ctx context.Context, bookID int) ([]models.Copy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCopies", ctx, bookID)
+	ret0, _ := ret[0].([]models.Copy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCopies indicates an expected call of GetCopies
+func (mr *MockBookKeeperMockRecorder) GetCopies(ctx, bookID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCopies", reflect.TypeOf((*MockBookKeeper)(nil).GetCopies), ctx, bookID)
+}
+
+// RetireCopy mocks base method
+func (m *MockBookKeeper) RetireCopy(ctx context.Context, id int) (*models.Copy, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetireCopy", ctx, id)
+	ret0, _ := ret[0].(*models.Copy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetireCopy indicates an expected call of RetireCopy
+func (mr *MockBookKeeperMockRecorder) RetireCopy(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetireCopy", reflect.TypeOf((*MockBookKeeper)(nil).RetireCopy), ctx, id)
+}
+
+// AddAuthor mocks base method
+func (m *MockBookKeeper) AddAuthor(ctx context.Context, a *models.Author) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthor", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuthor indicates an expected call of AddAuthor
+func (mr *MockBookKeeperMockRecorder) AddAuthor(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthor", reflect.TypeOf((*MockBookKeeper)(nil).AddAuthor), ctx, a)
+}
+
+// GetAuthor mocks base method
+func (m *MockBookKeeper) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthor", ctx, id)
+	ret0, _ := ret[0].(*models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthor indicates an expected call of GetAuthor
+func (mr *MockBookKeeperMockRecorder) GetAuthor(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthor", reflect.TypeOf((*MockBookKeeper)(nil).GetAuthor), ctx, id)
+}
+
+// GetAuthors mocks base method
+func (m *MockBookKeeper) GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", ctx, name, limit, offset)
+	ret0, _ := ret[0].([]models.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthors indicates an expected call of GetAuthors
+func (mr *MockBookKeeperMockRecorder) GetAuthors(ctx, name, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockBookKeeper)(nil).GetAuthors), ctx, name, limit, offset)
+}
+
+// UpdateAuthor mocks base method
+func (m *MockBookKeeper) UpdateAuthor(ctx context.Context, a *models.Author) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthor", ctx, a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAuthor indicates an expected call of UpdateAuthor
+func (mr *MockBookKeeperMockRecorder) UpdateAuthor(ctx, a interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthor", reflect.TypeOf((*MockBookKeeper)(nil).UpdateAuthor), ctx, a)
+}
+
+// DeleteAuthor mocks base method
+func (m *MockBookKeeper) DeleteAuthor(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAuthor", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuthor indicates an expected call of DeleteAuthor
+func (mr *MockBookKeeperMockRecorder) DeleteAuthor(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuthor", reflect.TypeOf((*MockBookKeeper)(nil).DeleteAuthor), ctx, id)
+}
+
+// AddPublisher mocks base method
+func (m *MockBookKeeper) AddPublisher(ctx context.Context, p *models.Publisher) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPublisher", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPublisher indicates an expected call of AddPublisher
+func (mr *MockBookKeeperMockRecorder) AddPublisher(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPublisher", reflect.TypeOf((*MockBookKeeper)(nil).AddPublisher), ctx, p)
+}
+
+// GetPublisher mocks base method
+func (m *MockBookKeeper) GetPublisher(ctx context.Context, id int) (*models.Publisher, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublisher", ctx, id)
+	ret0, _ := ret[0].(*models.Publisher)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublisher indicates an expected call of GetPublisher
+func (mr *MockBookKeeperMockRecorder) GetPublisher(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublisher", reflect.TypeOf((*MockBookKeeper)(nil).GetPublisher), ctx, id)
+}
+
+// GetPublishers mocks base method
+func (m *MockBookKeeper) GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublishers", ctx, name, limit, offset)
+	ret0, _ := ret[0].([]models.Publisher)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublishers indicates an expected call of GetPublishers
+func (mr *MockBookKeeperMockRecorder) GetPublishers(ctx, name, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublishers", reflect.TypeOf((*MockBookKeeper)(nil).GetPublishers), ctx, name, limit, offset)
+}
+
+// UpdatePublisher mocks base method
+func (m *MockBookKeeper) UpdatePublisher(ctx context.Context, p *models.Publisher) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePublisher", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePublisher indicates an expected call of UpdatePublisher
+func (mr *MockBookKeeperMockRecorder) UpdatePublisher(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePublisher", reflect.TypeOf((*MockBookKeeper)(nil).UpdatePublisher), ctx, p)
+}
+
+// DeletePublisher mocks base method
+func (m *MockBookKeeper) DeletePublisher(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublisher", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePublisher indicates an expected call of DeletePublisher
+func (mr *MockBookKeeperMockRecorder) DeletePublisher(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockBookKeeper)(nil).DeleteBook), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublisher", reflect.TypeOf((*MockBookKeeper)(nil).DeletePublisher), ctx, id)
 }
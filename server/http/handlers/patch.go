@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+	// patchAttempts is how often a patch without If-Match is reapplied when
+	// the book changes while it is patched.
+	patchAttempts = 3
+)
+
+// PatchBook applies a JSON merge patch or a JSON patch to the book in its
+// request representation and updates the book to the result. Only the result
+// is validated, so fields the patch leaves out keep their values.
+func (h *Book) PatchBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "book")
+		return
+	}
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	patch, err := readPatch(r)
+	if err != nil {
+		if _, ok := err.(models.ErrUnsupportedMediaType); ok {
+			w.Header().Set("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		}
+		sendHTTPError(w, err)
+		return
+	}
+	for attempt := 1; ; attempt++ {
+		current, err := h.bk.GetBook(r.Context(), id)
+		if err != nil {
+			sendHTTPError(w, err)
+			return
+		}
+		if version != 0 && current.Version != version {
+			sendHTTPError(w, models.ErrPreconditionFailed{Message: "book has been modified"})
+			return
+		}
+		book, err := patchBook(current, patch)
+		if err != nil {
+			sendHTTPError(w, err)
+			return
+		}
+		err = h.bk.UpdateBook(r.Context(), book)
+		if _, ok := err.(models.ErrPreconditionFailed); ok && version == 0 && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			sendHTTPError(w, err)
+			return
+		}
+		w.Header().Set("ETag", bookETag(book.Version))
+		sendEmptyResponse(w, http.StatusNoContent)
+		return
+	}
+}
+
+// readPatch reads the patch of the request body by its content type. Plain
+// JSON is taken as a merge patch.
+func readPatch(r *http.Request) (func(doc interface{}) (interface{}, error), error) {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mt = ""
+	}
+	switch mt {
+	case mergePatchType, "application/json":
+		var patch interface{}
+		if err := unmarshalRequestBody(r, &patch); err != nil {
+			return nil, err
+		}
+		return func(doc interface{}) (interface{}, error) {
+			return mergePatch(doc, copyValue(patch)), nil
+		}, nil
+	case jsonPatchType:
+		var ops []patchOperation
+		if err := unmarshalRequestBody(r, &ops); err != nil {
+			return nil, err
+		}
+		return func(doc interface{}) (interface{}, error) {
+			return jsonPatch(doc, ops)
+		}, nil
+	default:
+		return nil, models.ErrUnsupportedMediaType{
+			Message: "use content type " + mergePatchType + " or " + jsonPatchType,
+		}
+	}
+}
+
+// patchBook applies the patch to the request representation of the book and
+// returns the validated result as the next version of the book. A patch
+// renaming the author or the publisher without changing their ids drops the
+// ids, so the book is linked by the new name.
+func patchBook(current *models.Book, patch func(doc interface{}) (interface{}, error)) (*models.Book, error) {
+	orig := toBookResponse(current).Book
+	b, err := json.Marshal(orig)
+	if err != nil {
+		return nil, models.ErrInternal{Message: err.Error()}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, models.ErrInternal{Message: err.Error()}
+	}
+	doc, err = patch(doc)
+	if err != nil {
+		return nil, err
+	}
+	b, err = json.Marshal(doc)
+	if err != nil {
+		return nil, models.ErrInternal{Message: err.Error()}
+	}
+	var req hm.Book
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, models.ErrBadRequest{Message: err.Error()}
+	}
+	if req.Author != orig.Author && sameIDs(req.AuthorIDs, orig.AuthorIDs) {
+		req.AuthorIDs = nil
+	}
+	if req.Publisher != orig.Publisher && req.PublisherID == orig.PublisherID {
+		req.PublisherID = 0
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	book := toBook(&req)
+	book.ID = current.ID
+	book.Version = current.Version
+	return book, nil
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
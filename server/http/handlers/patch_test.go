@@ -0,0 +1,233 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_PatchBook(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                // router
+	router.HandleFunc("/books/{id}", oh.PatchBook).Methods(http.MethodPatch) // patch book route
+	srv := httptest.NewServer(router)                                        // test server
+	defer srv.Close()
+
+	publishDate := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	stored := func() *models.Book {
+		return &models.Book{
+			ID:          1,
+			Title:       "my_title",
+			Author:      "my_author",
+			Authors:     []models.Author{{ID: 7, Name: "my_author"}},
+			Publisher:   "my_publisher",
+			PublisherID: 9,
+			PublishDate: publishDate,
+			Version:     3,
+		}
+	}
+	patch := func(contentType, body string, header ...string) *http.Response {
+		request, err := http.NewRequest(http.MethodPatch, fmt.Sprintf("%s/books/1", srv.URL), bytes.NewBufferString(body))
+		req.NoError(err)
+		request.Header.Set("Content-Type", contentType)
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		res, err := srv.Client().Do(request)
+		req.NoError(err)
+		return res
+	}
+	t.Run("merge_patch", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+		srvMock.EXPECT().UpdateBook(gomock.Any(), &models.Book{
+			ID:          1,
+			Title:       "new_title",
+			Author:      "my_author",
+			Authors:     []models.Author{{ID: 7}},
+			Publisher:   "my_publisher",
+			PublisherID: 9,
+			PublishDate: publishDate,
+			Version:     3,
+		}).DoAndReturn(func(_ interface{}, b *models.Book) error {
+			b.Version = 4
+			return nil
+		})
+
+		res := patch(mergePatchType, `{"name":"new_title"}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+		a.Equal(`"4"`, res.Header.Get("ETag"))
+	})
+	t.Run("rename_author", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+		srvMock.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, b *models.Book) error {
+			a.Equal([]models.Author{{Name: "new_author"}}, b.Authors)
+			a.Equal(9, b.PublisherID)
+			return nil
+		})
+
+		res := patch("application/json", `{"author":"new_author"}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("json_patch", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+		srvMock.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, b *models.Book) error {
+			a.Equal("new_title", b.Title)
+			a.Equal([]models.Author{{Name: "my_publisher"}}, b.Authors)
+			return nil
+		})
+
+		res := patch(jsonPatchType, `[
+{"op":"test","path":"/name","value":"my_title"},
+{"op":"replace","path":"/name","value":"new_title"},
+{"op":"copy","from":"/publisher","path":"/author"}
+]`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("json_patch_test_failed", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+
+		res := patch(jsonPatchType, `[{"op":"test","path":"/name","value":"other_title"}]`)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+	t.Run("invalid_result", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+
+		res := patch(mergePatchType, `{"name":null}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+	t.Run("unsupported_media_type", func(t *testing.T) {
+		res := patch("text/plain", `{"name":"new_title"}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusUnsupportedMediaType, res.StatusCode) // Check if the Code is 415
+		a.Equal(mergePatchType+", "+jsonPatchType, res.Header.Get("Accept-Patch"))
+	})
+	t.Run("if_match_modified", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil)
+
+		res := patch(mergePatchType, `{"name":"new_title"}`, "If-Match", `"2"`)
+		defer res.Body.Close()
+		a.Equal(http.StatusPreconditionFailed, res.StatusCode) // Check if the Code is 412
+	})
+	t.Run("retry_concurrent_update", func(t *testing.T) {
+		gomock.InOrder(
+			srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil),
+			srvMock.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(models.ErrPreconditionFailed{Message: "book has been modified"}),
+			srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(stored(), nil),
+			srvMock.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(nil),
+		)
+
+		res := patch(mergePatchType, `{"name":"new_title"}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("not_found", func(t *testing.T) {
+		srvMock.EXPECT().GetBook(gomock.Any(), 1).Return(nil, models.ErrNotFound{Message: "book does not exist"})
+
+		res := patch(mergePatchType, `{"name":"new_title"}`)
+		defer res.Body.Close()
+		a.Equal(http.StatusNotFound, res.StatusCode) // Check if the Code is 404
+	})
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples of RFC 7396, appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		var doc, patch interface{}
+		require.NoError(t, json.Unmarshal([]byte(c.doc), &doc))
+		require.NoError(t, json.Unmarshal([]byte(c.patch), &patch))
+		got, err := json.Marshal(mergePatch(doc, patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, c.want, string(got), "%s patched by %s", c.doc, c.patch)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// examples of RFC 6902, appendix A
+	cases := []struct {
+		doc, patch, want string
+		err              error
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, nil},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, models.ErrConflict{}},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, models.ErrConflict{}},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, nil},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{`{"foo":"bar"}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":"bar","baz":"bar"}`, nil},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ``, models.ErrBadRequest{}},
+		{`{"foo":"bar"}`, `[{"op":"invalid","path":"/foo"}]`, ``, models.ErrBadRequest{}},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/01","value":"baz"}]`, ``, models.ErrBadRequest{}},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, ``, models.ErrBadRequest{}},
+	}
+	for _, c := range cases {
+		var (
+			doc interface{}
+			ops []patchOperation
+		)
+		require.NoError(t, json.Unmarshal([]byte(c.doc), &doc))
+		require.NoError(t, json.Unmarshal([]byte(c.patch), &ops))
+		res, err := jsonPatch(doc, ops)
+		if c.err != nil {
+			assert.IsType(t, c.err, err, "%s patched by %s", c.doc, c.patch)
+			continue
+		}
+		require.NoError(t, err, "%s patched by %s", c.doc, c.patch)
+		got, err := json.Marshal(res)
+		require.NoError(t, err)
+		assert.JSONEq(t, c.want, string(got), "%s patched by %s", c.doc, c.patch)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+// patronStatuses maps patron statuses to their API representation.
+var patronStatuses = map[models.PatronStatus]hm.PatronStatus{
+	models.PatronActive:    hm.PatronActive,
+	models.PatronSuspended: hm.PatronSuspended,
+}
+
+func (h *Book) AddPatron(w http.ResponseWriter, r *http.Request) {
+	var req hm.Patron
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	patron := toPatron(&req)
+	err = h.bk.AddPatron(r.Context(), patron)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toPatronResponse(patron)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
+}
+
+func (h *Book) GetPatron(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "patron")
+		return
+	}
+	patron, err := h.bk.GetPatron(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toPatronResponse(patron)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) ListPatrons(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	ps := &models.PatronSearch{Query: r.URL.Query().Get("q")}
+	if v := r.URL.Query().Get("status"); v != "" {
+		for st, name := range patronStatuses {
+			if v == string(name) {
+				st := st
+				ps.Status = &st
+				break
+			}
+		}
+		if ps.Status == nil {
+			sendHTTPError(w, models.ErrBadRequest{Message: "invalid status format, use 'active' or 'suspended'"})
+			return
+		}
+	}
+	patrons, err := h.bk.GetPatrons(r.Context(), ps, limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.PatronResponse, len(patrons))
+	for i := range patrons {
+		resp[i] = toPatronResponse(&patrons[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) UpdatePatron(w http.ResponseWriter, r *http.Request) {
+	var req hm.Patron
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "patron")
+		return
+	}
+	patron := toPatron(&req)
+	patron.ID = id
+	err = h.bk.UpdatePatron(r.Context(), patron)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func (h *Book) DeletePatron(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "patron")
+		return
+	}
+	err = h.bk.DeletePatron(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func toPatron(req *hm.Patron) *models.Patron {
+	p := &models.Patron{
+		MembershipNumber: req.MembershipNumber,
+		Name:             req.Name,
+		Email:            req.Email,
+		Phone:            req.Phone,
+		Address:          req.Address,
+		LoanLimit:        req.LoanLimit,
+	}
+	if req.Status == hm.PatronSuspended {
+		p.Status = models.PatronSuspended
+	}
+	return p
+}
+
+func toPatronResponse(p *models.Patron) hm.PatronResponse {
+	return hm.PatronResponse{
+		Patron: hm.Patron{
+			MembershipNumber: p.MembershipNumber,
+			Name:             p.Name,
+			Email:            p.Email,
+			Phone:            p.Phone,
+			Address:          p.Address,
+			Status:           patronStatuses[p.Status],
+			LoanLimit:        p.LoanLimit,
+		},
+		ID:        p.ID,
+		CreatedAt: p.CreatedAt,
+	}
+}
@@ -0,0 +1,162 @@
+//go:build unit
+// +build unit
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	"github.com/libreria/server/http/handlers/mock"
+	hm "github.com/libreria/server/http/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBook_AddPatron(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                            // router
+	router.HandleFunc("/patrons", oh.AddPatron).Methods(http.MethodPost) // create patron route
+	srv := httptest.NewServer(router)                                    // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().AddPatron(gomock.Any(), &models.Patron{Name: "Ada Lovelace", Email: "ada@example.com"}).
+			DoAndReturn(func(_ interface{}, p *models.Patron) error {
+				p.ID, p.MembershipNumber, p.LoanLimit = 1, "M000000001", 5
+				return nil
+			})
+
+		reqBody, err := json.Marshal(&hm.Patron{Name: "Ada Lovelace", Email: "ada@example.com"})
+		req.NoError(err)
+		res, err := http.Post(fmt.Sprintf("%s/patrons", srv.URL), "application/json", bytes.NewBuffer(reqBody))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+
+		var patron hm.PatronResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&patron))
+		a.Equal("M000000001", patron.MembershipNumber)
+		a.Equal(hm.PatronActive, patron.Status)
+		a.Equal(5, patron.LoanLimit)
+	})
+	t.Run("suspended", func(t *testing.T) {
+		srvMock.EXPECT().AddPatron(gomock.Any(), &models.Patron{Name: "Bob", Status: models.PatronSuspended}).Return(nil)
+
+		res, err := http.Post(fmt.Sprintf("%s/patrons", srv.URL), "application/json", bytes.NewBufferString(`{"name":"Bob","status":"suspended"}`))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusCreated, res.StatusCode)
+	})
+	t.Run("validation", func(t *testing.T) {
+		for _, body := range []string{
+			`{}`,
+			`{"name":"Bob","email":"bob"}`,
+			`{"name":"Bob","status":"banned"}`,
+			`{"name":"Bob","loan_limit":-1}`,
+		} {
+			res, err := http.Post(fmt.Sprintf("%s/patrons", srv.URL), "application/json", bytes.NewBufferString(body))
+			req.NoError(err)
+			res.Body.Close()
+			a.Equal(http.StatusBadRequest, res.StatusCode, body) // Check if the Code is 400
+		}
+	})
+	t.Run("conflict", func(t *testing.T) {
+		srvMock.EXPECT().AddPatron(gomock.Any(), gomock.Any()).Return(models.ErrConflict{Message: "patron with this membership number already exists"})
+
+		res, err := http.Post(fmt.Sprintf("%s/patrons", srv.URL), "application/json", bytes.NewBufferString(`{"name":"x","membership_number":"M1"}`))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
+
+func TestBook_ListPatrons(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                             // router
+	router.HandleFunc("/patrons", oh.ListPatrons).Methods(http.MethodGet) // list patrons route
+	srv := httptest.NewServer(router)                                     // test server
+	defer srv.Close()
+	t.Run("happy_path", func(t *testing.T) {
+		status := models.PatronSuspended
+		srvMock.EXPECT().GetPatrons(gomock.Any(), &models.PatronSearch{Query: "ada", Status: &status}, gomock.Any(), 0).
+			Return([]models.Patron{{ID: 1, Name: "Ada Lovelace", Status: models.PatronSuspended}}, nil)
+
+		res, err := http.Get(fmt.Sprintf("%s/patrons?q=ada&status=suspended", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusOK, res.StatusCode)
+
+		var patrons []hm.PatronResponse
+		req.NoError(json.NewDecoder(res.Body).Decode(&patrons))
+		req.Len(patrons, 1)
+		a.Equal(hm.PatronSuspended, patrons[0].Status)
+	})
+	t.Run("invalid_status", func(t *testing.T) {
+		res, err := http.Get(fmt.Sprintf("%s/patrons?status=banned", srv.URL))
+		req.NoError(err)
+		defer res.Body.Close()
+		a.Equal(http.StatusBadRequest, res.StatusCode) // Check if the Code is 400
+	})
+}
+
+func TestBook_DeletePatron(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t)    // assertion object for comparing values
+	req := require.New(t) // same as assertion, but stops test execution if condition is false
+
+	ctrl := gomock.NewController(t) // gomock controller
+	defer ctrl.Finish()
+	srvMock := mock.NewMockBookKeeper(ctrl) // mocked service
+	oh := New(srvMock)                      // book handler with mocked service
+
+	router := mux.NewRouter()                                                      // router
+	router.HandleFunc("/patrons/{id}", oh.DeletePatron).Methods(http.MethodDelete) // delete patron route
+	srv := httptest.NewServer(router)                                              // test server
+	defer srv.Close()
+	del := func(url string) *http.Response {
+		r, err := http.NewRequest(http.MethodDelete, srv.URL+url, nil)
+		req.NoError(err)
+		res, err := http.DefaultClient.Do(r)
+		req.NoError(err)
+		return res
+	}
+	t.Run("happy_path", func(t *testing.T) {
+		srvMock.EXPECT().DeletePatron(gomock.Any(), 1).Return(nil)
+
+		res := del("/patrons/1")
+		defer res.Body.Close()
+		a.Equal(http.StatusNoContent, res.StatusCode)
+	})
+	t.Run("has_loans", func(t *testing.T) {
+		srvMock.EXPECT().DeletePatron(gomock.Any(), 2).Return(models.ErrConflict{Message: "patron has loans"})
+
+		res := del("/patrons/2")
+		defer res.Body.Close()
+		a.Equal(http.StatusConflict, res.StatusCode) // Check if the Code is 409
+	})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/libreria/models"
+	hm "github.com/libreria/server/http/models"
+)
+
+func (h *Book) AddPublisher(w http.ResponseWriter, r *http.Request) {
+	var req hm.Publisher
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	publisher := &models.Publisher{Name: req.Name}
+	err = h.bk.AddPublisher(r.Context(), publisher)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toPublisherResponse(publisher)
+	sendResponseWithBody(w, http.StatusCreated, &resp)
+}
+
+func (h *Book) GetPublisher(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "publisher")
+		return
+	}
+	publisher, err := h.bk.GetPublisher(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := toPublisherResponse(publisher)
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) ListPublishers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := getPagination(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	publishers, err := h.bk.GetPublishers(r.Context(), r.URL.Query().Get("name"), limit, offset)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	resp := make([]hm.PublisherResponse, len(publishers))
+	for i := range publishers {
+		resp[i] = toPublisherResponse(&publishers[i])
+	}
+	sendResponseWithBody(w, http.StatusOK, &resp)
+}
+
+func (h *Book) UpdatePublisher(w http.ResponseWriter, r *http.Request) {
+	var req hm.Publisher
+	err := unmarshalRequestBody(r, &req)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	err = req.Validate()
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "publisher")
+		return
+	}
+	err = h.bk.UpdatePublisher(r.Context(), &models.Publisher{ID: id, Name: req.Name})
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func (h *Book) DeletePublisher(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "publisher")
+		return
+	}
+	err = h.bk.DeletePublisher(r.Context(), id)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sendEmptyResponse(w, http.StatusNoContent)
+}
+
+func (h *Book) ListPublisherBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendInvalidIDError(w, "publisher")
+		return
+	}
+	sc, err := getSearch(r)
+	if err != nil {
+		sendHTTPError(w, err)
+		return
+	}
+	sc.PublisherID = &id
+	h.listBooks(w, r, sc)
+}
+
+func toPublisherResponse(p *models.Publisher) hm.PublisherResponse {
+	return hm.PublisherResponse{
+		Publisher: hm.Publisher{Name: p.Name},
+		ID:        p.ID,
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AuditEntryResponse is a mutation of a book, Before and After hold the fields
+// of the book it changed.
+type AuditEntryResponse struct {
+	ID        int                    `json:"id"`
+	BookID    int                    `json:"book_id"`
+	Actor     string                 `json:"actor"`
+	Action    string                 `json:"action"`
+	Before    map[string]interface{} `json:"before,omitempty"`
+	After     map[string]interface{} `json:"after,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
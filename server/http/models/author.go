@@ -0,0 +1,18 @@
+package models
+
+import validation "github.com/go-ozzo/ozzo-validation/v4"
+
+type Author struct {
+	Name string `json:"name"`
+}
+
+func (a Author) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.Name, validation.Required, validation.Length(1, 200)),
+	)
+}
+
+type AuthorResponse struct {
+	Author
+	ID int `json:"id"`
+}
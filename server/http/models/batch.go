@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+	// BatchStatus checks a copy of the book out to the borrower or back in.
+	BatchStatus BatchOp = "status"
+)
+
+// BatchRequest lists operations on books applied in order, all or nothing.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation creates a book, or updates, deletes or changes the status of
+// the book with ID. A non-zero Version must be the version of the book.
+type BatchOperation struct {
+	Op         BatchOp    `json:"op"`
+	ID         int        `json:"id,omitempty"`
+	Version    int        `json:"version,omitempty"`
+	Book       *Book      `json:"book,omitempty"`
+	Status     Status     `json:"status,omitempty"`
+	BorrowerID int        `json:"borrower_id,omitempty"`
+	CopyID     int        `json:"copy_id,omitempty"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+}
+
+func (o BatchOperation) Validate() error {
+	return validation.ValidateStruct(&o,
+		validation.Field(&o.Op, validation.Required, validation.In(BatchCreate, BatchUpdate, BatchDelete, BatchStatus)),
+		validation.Field(&o.ID, validation.When(o.Op != BatchCreate, validation.Required, validation.Min(1)).Else(validation.Empty)),
+		validation.Field(&o.Version, validation.When(o.Op == BatchUpdate || o.Op == BatchDelete, validation.Min(0)).Else(validation.Empty)),
+		validation.Field(&o.Book, validation.When(o.Op == BatchCreate || o.Op == BatchUpdate, validation.Required).Else(validation.Nil)),
+		validation.Field(&o.Status, validation.When(o.Op == BatchStatus, validation.Required, validation.In(StatusCheckedIn, StatusCheckedOut)).Else(validation.Empty)),
+		validation.Field(&o.BorrowerID, validation.When(o.Status == StatusCheckedOut, validation.Required, validation.Min(1)).Else(validation.Empty)),
+		validation.Field(&o.CopyID, validation.When(o.Op == BatchStatus, validation.Min(1)).Else(validation.Empty)),
+		validation.Field(&o.DueDate, validation.When(o.Status == StatusCheckedOut, validation.Min(time.Now())).Else(validation.Nil)),
+	)
+}
+
+// BatchResponse has the result of every operation of a batch in order.
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// BatchResult is the outcome of an operation, Status is the status code the
+// operation has alone. Operations of a batch that was not committed because
+// of another operation have the status 424 Failed Dependency.
+type BatchResult struct {
+	Status  int           `json:"status"`
+	ID      int           `json:"id,omitempty"`
+	Version int           `json:"version,omitempty"`
+	Loan    *LoanResponse `json:"loan,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
@@ -1,43 +1,81 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/libreria/models"
 )
 
+// Book names its author and publisher, or references them by id. The ids take
+// precedence over the names, books with several authors need AuthorIDs.
 type Book struct {
 	Title       string    `json:"name"`
+	ISBN        string    `json:"isbn,omitempty"`
 	Author      string    `json:"author"`
+	AuthorIDs   []int     `json:"author_ids,omitempty"`
 	Publisher   string    `json:"publisher"`
+	PublisherID int       `json:"publisher_id,omitempty"`
 	PublishDate time.Time `json:"publish_date"`
 }
 
 func (b Book) Validate() error {
 	return validation.ValidateStruct(&b,
 		validation.Field(&b.Title, validation.Required, validation.Length(1, 200)),
-		validation.Field(&b.Author, validation.Required, validation.Length(1, 200)),
-		validation.Field(&b.Publisher, validation.Required, validation.Length(1, 200)),
+		validation.Field(&b.ISBN, validation.By(validISBN)),
+		validation.Field(&b.Author, validation.When(len(b.AuthorIDs) == 0, validation.Required), validation.Length(1, 200)),
+		validation.Field(&b.AuthorIDs, validation.Each(validation.Min(1))),
+		validation.Field(&b.Publisher, validation.When(b.PublisherID == 0, validation.Required), validation.Length(1, 200)),
+		validation.Field(&b.PublisherID, validation.Min(1)),
 		validation.Field(&b.PublishDate, validation.Required, validation.Max(time.Now())),
 	)
 }
 
+func validISBN(value interface{}) error {
+	isbn, _ := value.(string)
+	if isbn == "" {
+		return nil
+	}
+	_, err := models.NormalizeISBN(isbn)
+	if err != nil {
+		return errors.New("must be a valid ISBN-10 or ISBN-13")
+	}
+	return nil
+}
+
 type Status string
 
 const (
 	StatusCheckedIn  Status = "CheckedIn"
 	StatusCheckedOut Status = "CheckedOut"
+	StatusOnHold     Status = "OnHold"
 )
 
+// GetBookResponse is a book, DeletedAt is set for books listed with deleted
+// ones. Version is the entity tag of the book without quotes.
 type GetBookResponse struct {
 	Book
-	ID     int     `json:"id"`
-	Status Status  `json:"status"`
-	Rating float64 `json:"rating,omitempty"`
+	ID              int        `json:"id"`
+	Version         int        `json:"version"`
+	Status          Status     `json:"status"`
+	Rating          float64    `json:"rating,omitempty"`
+	RatingCount     int        `json:"rating_count"`
+	TotalCopies     int        `json:"total_copies"`
+	AvailableCopies int        `json:"available_copies"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+type BookListResponse struct {
+	Books      []GetBookResponse `json:"books"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
+// RateRequest rates a book as the authenticated caller. With authentication
+// disabled the rating user is given by UserID instead.
 type RateRequest struct {
-	Rating int `json:"rating"`
+	UserID string `json:"user_id,omitempty"`
+	Rating int    `json:"rating"`
 }
 
 func (r RateRequest) Validate() error {
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/libreria/models"
+)
+
+// CopyRequest adds a copy of a book, a barcode is generated if not given.
+type CopyRequest struct {
+	Barcode   string `json:"barcode,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	Location  string `json:"location,omitempty"`
+}
+
+func (r CopyRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Barcode, validation.Length(1, 64)),
+		validation.Field(&r.Condition, validation.In(models.CopyConditions...)),
+		validation.Field(&r.Location, validation.Length(1, 200)),
+	)
+}
+
+type CopyResponse struct {
+	ID        int        `json:"id"`
+	BookID    int        `json:"book_id"`
+	Barcode   string     `json:"barcode"`
+	Condition string     `json:"condition"`
+	Location  string     `json:"location"`
+	Status    Status     `json:"status"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
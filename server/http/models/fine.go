@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type FineStatus string
+
+const (
+	FineOpen   FineStatus = "open"
+	FinePaid   FineStatus = "paid"
+	FineWaived FineStatus = "waived"
+)
+
+type FineResponse struct {
+	ID           int        `json:"id"`
+	LoanID       int        `json:"loan_id"`
+	PatronID     int        `json:"patron_id"`
+	Amount       int        `json:"amount"`
+	Status       FineStatus `json:"status"`
+	AccruedUntil time.Time  `json:"accrued_until"`
+	SettledAt    *time.Time `json:"settled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
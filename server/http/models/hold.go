@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// HoldRequest places a hold for the patron, which callers with the patron role
+// may leave out to place it for themselves.
+type HoldRequest struct {
+	PatronID int `json:"patron_id"`
+}
+
+func (r HoldRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.PatronID, validation.Required, validation.Min(1)),
+	)
+}
+
+type HoldStatus string
+
+const (
+	HoldWaiting   HoldStatus = "waiting"
+	HoldReady     HoldStatus = "ready"
+	HoldFulfilled HoldStatus = "fulfilled"
+	HoldCancelled HoldStatus = "cancelled"
+	HoldExpired   HoldStatus = "expired"
+)
+
+type HoldResponse struct {
+	ID        int        `json:"id"`
+	BookID    int        `json:"book_id"`
+	PatronID  int        `json:"patron_id"`
+	CopyID    *int       `json:"copy_id,omitempty"`
+	Status    HoldStatus `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadyAt   *time.Time `json:"ready_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportDuplicate ImportStatus = "duplicate"
+	ImportRejected  ImportStatus = "rejected"
+	// ImportNotImported is the status of the rows read but not imported when
+	// an error stopped the import.
+	ImportNotImported ImportStatus = "not_imported"
+)
+
+// ImportReport counts the rows of an import by their outcome, Rows has the
+// outcome of every row. Created rows of a dry run have no book id. Error is
+// the error that stopped the import, the rows after the last one reported
+// were not read.
+type ImportReport struct {
+	DryRun      bool              `json:"dry_run"`
+	Created     int               `json:"created"`
+	Duplicates  int               `json:"duplicates"`
+	Rejected    int               `json:"rejected"`
+	NotImported int               `json:"not_imported"`
+	Rows        []ImportRowResult `json:"rows"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// ImportRowResult is the outcome of a row, Row is its line in the imported file.
+type ImportRowResult struct {
+	Row    int          `json:"row"`
+	Status ImportStatus `json:"status"`
+	BookID int          `json:"book_id,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// CheckoutRequest lends the copy, or any available copy of the book if CopyID is not set.
+type CheckoutRequest struct {
+	BorrowerID int        `json:"borrower_id"`
+	CopyID     int        `json:"copy_id,omitempty"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+}
+
+func (r CheckoutRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.BorrowerID, validation.Required, validation.Min(1)),
+		validation.Field(&r.CopyID, validation.Min(1)),
+		validation.Field(&r.DueDate, validation.Min(time.Now())),
+	)
+}
+
+type LoanResponse struct {
+	ID           int        `json:"id"`
+	BookID       int        `json:"book_id"`
+	CopyID       int        `json:"copy_id"`
+	BorrowerID   int        `json:"borrower_id"`
+	CheckedOutAt time.Time  `json:"checked_out_at"`
+	DueDate      time.Time  `json:"due_date"`
+	ReturnedAt   *time.Time `json:"returned_at,omitempty"`
+	OverdueAt    *time.Time `json:"overdue_at,omitempty"`
+	Overdue      bool       `json:"overdue"`
+}
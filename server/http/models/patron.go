@@ -0,0 +1,48 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type PatronStatus string
+
+const (
+	PatronActive    PatronStatus = "active"
+	PatronSuspended PatronStatus = "suspended"
+)
+
+var emailRegexp = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// Patron adds or updates a member of the library. A membership number is
+// generated if not given, patrons are active and get the default loan limit
+// unless told otherwise.
+type Patron struct {
+	MembershipNumber string       `json:"membership_number,omitempty"`
+	Name             string       `json:"name"`
+	Email            string       `json:"email,omitempty"`
+	Phone            string       `json:"phone,omitempty"`
+	Address          string       `json:"address,omitempty"`
+	Status           PatronStatus `json:"status,omitempty"`
+	LoanLimit        int          `json:"loan_limit,omitempty"`
+}
+
+func (p Patron) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.MembershipNumber, validation.Length(1, 64)),
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 200)),
+		validation.Field(&p.Email, validation.Length(3, 254), validation.Match(emailRegexp).Error("must be a valid email address")),
+		validation.Field(&p.Phone, validation.Length(1, 32)),
+		validation.Field(&p.Address, validation.Length(1, 500)),
+		validation.Field(&p.Status, validation.In(PatronActive, PatronSuspended)),
+		validation.Field(&p.LoanLimit, validation.Min(1), validation.Max(100)),
+	)
+}
+
+type PatronResponse struct {
+	Patron
+	ID        int       `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import validation "github.com/go-ozzo/ozzo-validation/v4"
+
+type Publisher struct {
+	Name string `json:"name"`
+}
+
+func (p Publisher) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 200)),
+	)
+}
+
+type PublisherResponse struct {
+	Publisher
+	ID int `json:"id"`
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/libreria/server/http/auth"
 	"github.com/libreria/server/http/handlers"
 	log "github.com/sirupsen/logrus"
 )
@@ -15,20 +16,23 @@ import (
 const version1 = "/v1"
 
 type Config struct {
-	Port      int    `mapstructure:"PORT" default:"8080"`
-	URLPrefix string `mapstructure:"URL_PREFIX" default:"/api"`
+	Port      int         `mapstructure:"PORT" default:"8080"`
+	URLPrefix string      `mapstructure:"URL_PREFIX" default:"/api"`
+	Auth      auth.Config `mapstructure:"AUTH"`
 }
 
 type Server struct {
-	config Config
-	server *http.Server
-	oh     *handlers.Book
+	config         Config
+	server         *http.Server
+	oh             *handlers.Book
+	authenticators []auth.Authenticator
 }
 
-func New(cfg Config, oh *handlers.Book) *Server {
+func New(cfg Config, oh *handlers.Book, authenticators ...auth.Authenticator) *Server {
 	s := &Server{
-		config: cfg,
-		oh:     oh,
+		config:         cfg,
+		oh:             oh,
+		authenticators: authenticators,
 	}
 	// build http server
 	httpSrv := &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port)}
@@ -47,7 +51,8 @@ func (s *Server) Run(globalCtx context.Context, wg *sync.WaitGroup) {
 	}()
 	go func() {
 		<-globalCtx.Done()
-		sdCtx, _ := context.WithTimeout(context.Background(), 5*time.Second) // nolint
+		sdCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
 		err := s.server.Shutdown(sdCtx)
 		if err != nil {
 			log.Infof("http server shutdown error %s", err)
@@ -61,14 +66,66 @@ func (s *Server) BuildHandler() http.Handler {
 		serviceRouter = router.PathPrefix(s.config.URLPrefix).Subrouter()
 		v1Router      = serviceRouter.PathPrefix(version1).Subrouter()
 	)
+	if s.config.Auth.Enabled {
+		v1Router.Use(handlers.Authenticate(s.config.Auth.AnonymousRead, s.authenticators...))
+	}
 	// routes
-	v1Router.HandleFunc("/books", s.oh.AddBook).Methods(http.MethodPost)
-	v1Router.HandleFunc("/books", s.oh.ListBooks).Methods(http.MethodGet)
-	v1Router.HandleFunc("/books/{id}", s.oh.GetBook).Methods(http.MethodGet)
-	v1Router.HandleFunc("/books/{id}", s.oh.UpdateBook).Methods(http.MethodPut)
-	v1Router.HandleFunc("/books/{id}/in", s.oh.CheckinBook).Methods(http.MethodPatch)
-	v1Router.HandleFunc("/books/{id}/out", s.oh.CheckoutBook).Methods(http.MethodPatch)
-	v1Router.HandleFunc("/books/{id}/rate", s.oh.RateBook).Methods(http.MethodPatch)
-	v1Router.HandleFunc("/books/{id}", s.oh.DeleteBook).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/books", s.authorize(auth.PermEditBooks, s.oh.AddBook)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/books:import", s.authorize(auth.PermEditBooks, s.oh.ImportBooks)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/books:batch", s.authorize(auth.PermEditBooks, s.oh.BatchBooks)).Methods(http.MethodPost)
+	// deleted books are listed to those who may delete them
+	v1Router.HandleFunc("/books", s.authorize(auth.PermDeleteBooks, s.oh.ListBooks)).Methods(http.MethodGet).Queries("deleted", "{deleted}")
+	v1Router.HandleFunc("/books", s.authorize(auth.PermReadBooks, s.oh.ListBooks)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books:export", s.authorize(auth.PermDeleteBooks, s.oh.ExportBooks)).Methods(http.MethodGet).Queries("deleted", "{deleted}")
+	v1Router.HandleFunc("/books:export", s.authorize(auth.PermReadBooks, s.oh.ExportBooks)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books/{id}", s.authorize(auth.PermReadBooks, s.oh.GetBook)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books/isbn/{isbn}", s.authorize(auth.PermReadBooks, s.oh.GetBookByISBN)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books/{id}", s.authorize(auth.PermEditBooks, s.oh.UpdateBook)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/books/{id}", s.authorize(auth.PermEditBooks, s.oh.PatchBook)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/books/{id}/history", s.authorize(auth.PermReadHistory, s.oh.GetBookHistory)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books/{id}/in", s.authorize(auth.PermCirculate, s.oh.CheckinBook)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/books/{id}/out", s.authorize(auth.PermCirculate, s.oh.CheckoutBook)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/books/{id}/rate", s.authorize(auth.PermRateBooks, s.oh.RateBook)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/books/{id}/loans", s.authorize(auth.PermReadLoans, s.oh.ListBookLoans)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/books/{id}", s.authorize(auth.PermPurgeBooks, s.oh.DeleteBook)).Methods(http.MethodDelete).Queries("hard", "{hard}")
+	v1Router.HandleFunc("/books/{id}", s.authorize(auth.PermDeleteBooks, s.oh.DeleteBook)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/books/{id}/restore", s.authorize(auth.PermDeleteBooks, s.oh.RestoreBook)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/books/{id}/holds", s.authorize(auth.PermPlaceHolds, s.oh.AddHold)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/books/{id}/holds", s.authorize(auth.PermReadLoans, s.oh.ListBookHolds)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/holds", s.authorize(auth.PermPlaceHolds, s.oh.ListHolds)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/holds/{id}", s.authorize(auth.PermPlaceHolds, s.oh.CancelHold)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/patrons", s.authorize(auth.PermEditPatrons, s.oh.AddPatron)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/patrons", s.authorize(auth.PermReadPatrons, s.oh.ListPatrons)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/patrons/{id}", s.authorize(auth.PermReadPatrons, s.oh.GetPatron)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/patrons/{id}", s.authorize(auth.PermEditPatrons, s.oh.UpdatePatron)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/patrons/{id}", s.authorize(auth.PermEditPatrons, s.oh.DeletePatron)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/patrons/{id}/fines", s.authorize(auth.PermReadFines, s.oh.ListPatronFines)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/fines/{id}/pay", s.authorize(auth.PermCirculate, s.oh.PayFine)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/fines/{id}/waive", s.authorize(auth.PermCirculate, s.oh.WaiveFine)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/books/{id}/copies", s.authorize(auth.PermEditBooks, s.oh.AddCopy)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/books/{id}/copies", s.authorize(auth.PermReadBooks, s.oh.ListCopies)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/copies/{id}", s.authorize(auth.PermReadBooks, s.oh.GetCopy)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/copies/{id}", s.authorize(auth.PermDeleteBooks, s.oh.RetireCopy)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/loans", s.authorize(auth.PermReadLoans, s.oh.ListLoans)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/authors", s.authorize(auth.PermEditBooks, s.oh.AddAuthor)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/authors", s.authorize(auth.PermReadBooks, s.oh.ListAuthors)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/authors/{id}", s.authorize(auth.PermReadBooks, s.oh.GetAuthor)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/authors/{id}", s.authorize(auth.PermEditBooks, s.oh.UpdateAuthor)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/authors/{id}", s.authorize(auth.PermDeleteBooks, s.oh.DeleteAuthor)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/authors/{id}/books", s.authorize(auth.PermReadBooks, s.oh.ListAuthorBooks)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/publishers", s.authorize(auth.PermEditBooks, s.oh.AddPublisher)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/publishers", s.authorize(auth.PermReadBooks, s.oh.ListPublishers)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/publishers/{id}", s.authorize(auth.PermReadBooks, s.oh.GetPublisher)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/publishers/{id}", s.authorize(auth.PermEditBooks, s.oh.UpdatePublisher)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/publishers/{id}", s.authorize(auth.PermDeleteBooks, s.oh.DeletePublisher)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/publishers/{id}/books", s.authorize(auth.PermReadBooks, s.oh.ListPublisherBooks)).Methods(http.MethodGet)
 	return router
 }
+
+// authorize wraps the handler with the permission check when authentication is enabled.
+func (s *Server) authorize(p auth.Permission, h http.HandlerFunc) http.HandlerFunc {
+	if !s.config.Auth.Enabled {
+		return h
+	}
+	return handlers.Authorize(p, h)
+}
@@ -0,0 +1,12 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// GetBookHistory returns the audit log of the book, deleted books keep theirs.
+func (s *Service) GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error) {
+	return s.storage.GetBookHistory(ctx, bookID, limit, offset)
+}
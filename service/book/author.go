@@ -0,0 +1,27 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+func (s *Service) AddAuthor(ctx context.Context, a *models.Author) error {
+	return s.storage.CreateAuthor(ctx, a)
+}
+
+func (s *Service) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	return s.storage.GetAuthor(ctx, id)
+}
+
+func (s *Service) GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error) {
+	return s.storage.GetAuthors(ctx, name, limit, offset)
+}
+
+func (s *Service) UpdateAuthor(ctx context.Context, a *models.Author) error {
+	return s.storage.UpdateAuthor(ctx, a)
+}
+
+func (s *Service) DeleteAuthor(ctx context.Context, id int) error {
+	return s.storage.DeleteAuthor(ctx, id)
+}
@@ -0,0 +1,85 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// ApplyBookBatch applies the operations in order in one transaction, all or
+// nothing. Every operation is checked like the single operation, the error of
+// a failing operation is a BatchError.
+func (s *Service) ApplyBookBatch(ctx context.Context, ops []models.BookOperation) ([]models.BookOperationResult, error) {
+	var res []models.BookOperationResult
+	err := s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		ts := s.withStorage(tx)
+		res = make([]models.BookOperationResult, len(ops))
+		for i, op := range ops {
+			r, err := ts.applyBookOperation(ctx, op)
+			if err != nil {
+				return models.BatchError{Index: i, Err: err}
+			}
+			res[i] = *r
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// applyBookOperation applies the operation to a copy of its book, so a retried
+// transaction starts over with the books of the batch.
+func (s *Service) applyBookOperation(ctx context.Context, op models.BookOperation) (*models.BookOperationResult, error) {
+	switch op.Type {
+	case models.OpCreate, models.OpUpdate:
+		b := *op.Book
+		b.Authors = append([]models.Author(nil), op.Book.Authors...)
+		var err error
+		if op.Type == models.OpCreate {
+			err = s.AddBook(ctx, &b)
+		} else {
+			err = s.UpdateBook(ctx, &b)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &models.BookOperationResult{BookID: b.ID, Version: b.Version}, nil
+	case models.OpDelete:
+		err := s.DeleteBook(ctx, op.Book.ID, op.Book.Version)
+		if err != nil {
+			return nil, err
+		}
+		return &models.BookOperationResult{BookID: op.Book.ID}, nil
+	case models.OpCheckout:
+		l, err := s.CheckoutBook(ctx, op.Loan.BookID, op.Loan.CopyID, op.Loan.BorrowerID, op.Loan.DueAt)
+		if err != nil {
+			return nil, err
+		}
+		return s.loanResult(ctx, l)
+	case models.OpCheckin:
+		l, err := s.ReturnBook(ctx, op.Loan.BookID, op.Loan.CopyID)
+		if err != nil {
+			return nil, err
+		}
+		return s.loanResult(ctx, l)
+	default:
+		return nil, models.ErrBadRequest{Message: "invalid operation"}
+	}
+}
+
+// loanResult is the result of a status change, with the version of the book
+// after the change. Copies of deleted books are returned without a version.
+func (s *Service) loanResult(ctx context.Context, l *models.Loan) (*models.BookOperationResult, error) {
+	res := &models.BookOperationResult{BookID: l.BookID, Loan: l}
+	b, err := s.storage.GetBook(ctx, l.BookID)
+	if _, deleted := err.(models.ErrNotFound); deleted {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Version = b.Version
+	return res, nil
+}
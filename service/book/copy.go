@@ -0,0 +1,63 @@
+package book
+
+import (
+	"context"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+func (s *Service) AddCopy(ctx context.Context, c *models.Copy) error {
+	return s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		return s.withStorage(tx).addCopy(ctx, c)
+	})
+}
+
+func (s *Service) addCopy(ctx context.Context, c *models.Copy) error {
+	_, err := s.storage.GetBook(ctx, c.BookID)
+	if err != nil {
+		return err
+	}
+	return s.storage.CreateCopy(ctx, c, s.holdExpiresAt())
+}
+
+func (s *Service) GetCopy(ctx context.Context, id int) (*models.Copy, error) {
+	return s.storage.GetCopy(ctx, id)
+}
+
+func (s *Service) GetCopies(ctx context.Context, bookID int) ([]models.Copy, error) {
+	_, err := s.storage.GetBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.GetCopies(ctx, bookID)
+}
+
+// RetireCopy takes the copy out of circulation, checked out copies have to be
+// returned first.
+func (s *Service) RetireCopy(ctx context.Context, id int) (*models.Copy, error) {
+	var c *models.Copy
+	err := s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		var err error
+		c, err = s.withStorage(tx).retireCopy(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Service) retireCopy(ctx context.Context, id int) (*models.Copy, error) {
+	c, err := s.storage.GetCopy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.RetiredAt != nil {
+		return nil, models.ErrConflict{Message: "copy is already retired"}
+	}
+	if c.Status != models.StatusCheckedIn {
+		return nil, models.ErrConflict{Message: "copy is checked out or on hold"}
+	}
+	return s.storage.RetireCopy(ctx, id, time.Now().UTC())
+}
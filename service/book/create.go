@@ -7,5 +7,23 @@ import (
 )
 
 func (s *Service) AddBook(ctx context.Context, b *models.Book) error {
+	err := normalizeISBN(b)
+	if err != nil {
+		return err
+	}
 	return s.storage.CreateBook(ctx, b)
 }
+
+// normalizeISBN stores ISBNs of books as ISBN-13 so an ISBN-10 and the
+// matching ISBN-13 identify the same book.
+func normalizeISBN(b *models.Book) error {
+	if b.ISBN == "" {
+		return nil
+	}
+	isbn, err := models.NormalizeISBN(b.ISBN)
+	if err != nil {
+		return err
+	}
+	b.ISBN = isbn
+	return nil
+}
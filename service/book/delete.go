@@ -1,7 +1,41 @@
 package book
 
-import "context"
+import (
+	"context"
+	"time"
 
-func (s *Service) DeleteBook(ctx context.Context, id int) error {
-	return s.storage.DeleteBook(ctx, id)
+	"github.com/libreria/models"
+)
+
+// DeleteBook soft-deletes the book, it can be restored until it is purged.
+// A non-zero version must be the current version of the book.
+func (s *Service) DeleteBook(ctx context.Context, id, version int) error {
+	return s.storage.DeleteBook(ctx, id, version)
+}
+
+// RestoreBook undoes the deletion of the book and returns it.
+func (s *Service) RestoreBook(ctx context.Context, id int) (*models.Book, error) {
+	err := s.storage.RestoreBook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.GetBook(ctx, id)
+}
+
+// PurgeBook permanently deletes the book. Books with open loans or unsettled
+// fines cannot be purged. A non-zero version must be the current version of the book.
+func (s *Service) PurgeBook(ctx context.Context, id, version int) error {
+	return s.storage.PurgeBook(ctx, id, version)
+}
+
+// PurgeDeletedBooks purges the books deleted longer than the retention period ago.
+func (s *Service) PurgeDeletedBooks(ctx context.Context) (int, error) {
+	if s.cfg.DeletedRetention == 0 {
+		return 0, nil
+	}
+	ctx = models.WithIdentity(ctx, retentionIdentity)
+	return s.storage.PurgeDeletedBooks(ctx, time.Now().UTC().Add(-s.cfg.DeletedRetention))
 }
+
+// retentionIdentity is the actor of the purges made by the retention job.
+var retentionIdentity = &models.Identity{Subject: "retention", Role: models.RoleAdmin}
@@ -0,0 +1,13 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// ExportBooks calls fn with every book found by the search, stopping at the
+// first error of fn.
+func (s *Service) ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error {
+	return s.storage.ExportBooks(ctx, bs, fn)
+}
@@ -0,0 +1,49 @@
+package book
+
+import (
+	"context"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// ProcessOverdueLoans marks open loans past their due date as overdue and
+// accrues their fines by the configured policy. It returns the number of
+// newly overdue loans and accrued fines.
+func (s *Service) ProcessOverdueLoans(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	marked, err := s.storage.MarkOverdueLoans(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	accrued, err := s.storage.AccrueFines(ctx, now, s.cfg.Fines)
+	if err != nil {
+		return marked, err
+	}
+	return marked + accrued, nil
+}
+
+func (s *Service) GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error) {
+	return s.storage.GetFines(ctx, fs, limit, offset)
+}
+
+// PayFine records the payment of the fine of a returned loan.
+func (s *Service) PayFine(ctx context.Context, id int) (*models.Fine, error) {
+	return s.settleFine(ctx, id, models.FinePaid)
+}
+
+// WaiveFine cancels the fine, it stops accruing.
+func (s *Service) WaiveFine(ctx context.Context, id int) (*models.Fine, error) {
+	return s.settleFine(ctx, id, models.FineWaived)
+}
+
+func (s *Service) settleFine(ctx context.Context, id int, status models.FineStatus) (*models.Fine, error) {
+	f, err := s.storage.GetFine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f.Status != models.FineOpen {
+		return nil, models.ErrConflict{Message: "fine is already settled"}
+	}
+	return s.storage.SettleFine(ctx, id, status, time.Now().UTC())
+}
@@ -10,6 +10,27 @@ func (s *Service) GetBook(ctx context.Context, id int) (*models.Book, error) {
 	return s.storage.GetBook(ctx, id)
 }
 
-func (s *Service) GetBooks(ctx context.Context, bs *models.BookSearch, limit, offset int) ([]models.Book, error) {
-	return s.storage.GetBooks(ctx, bs, limit, offset)
+// GetBookByISBN finds a book by its ISBN-10 or ISBN-13.
+func (s *Service) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	isbn, err := models.NormalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.GetBookByISBN(ctx, isbn)
+}
+
+// GetBooks finds books by the search. Listing the books of an author or a
+// publisher that does not exist fails with not found rather than returning no books.
+func (s *Service) GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error) {
+	if bs != nil && bs.AuthorID != nil {
+		if _, err := s.storage.GetAuthor(ctx, *bs.AuthorID); err != nil {
+			return nil, err
+		}
+	}
+	if bs != nil && bs.PublisherID != nil {
+		if _, err := s.storage.GetPublisher(ctx, *bs.PublisherID); err != nil {
+			return nil, err
+		}
+	}
+	return s.storage.GetBooks(ctx, bs, p)
 }
@@ -0,0 +1,84 @@
+package book
+
+import (
+	"context"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// AddHold queues the patron for the book. Books with an available copy are
+// checked out instead.
+func (s *Service) AddHold(ctx context.Context, h *models.Hold) error {
+	return s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		return s.withStorage(tx).addHold(ctx, h)
+	})
+}
+
+func (s *Service) addHold(ctx context.Context, h *models.Hold) error {
+	_, err := s.activePatron(ctx, h.PatronID)
+	if err != nil {
+		return err
+	}
+	b, err := s.storage.GetBook(ctx, h.BookID)
+	if err != nil {
+		return err
+	}
+	if b.AvailableCopies > 0 {
+		return models.ErrConflict{Message: "book has an available copy"}
+	}
+	return s.storage.CreateHold(ctx, h)
+}
+
+func (s *Service) GetHold(ctx context.Context, id int) (*models.Hold, error) {
+	return s.storage.GetHold(ctx, id)
+}
+
+func (s *Service) GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error) {
+	return s.storage.GetHolds(ctx, hs, limit, offset)
+}
+
+// CancelHold cancels the hold, a copy kept for it goes to the next hold in the queue.
+func (s *Service) CancelHold(ctx context.Context, id int) (*models.Hold, error) {
+	var h *models.Hold
+	err := s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		var err error
+		h, err = s.withStorage(tx).cancelHold(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (s *Service) cancelHold(ctx context.Context, id int) (*models.Hold, error) {
+	h, err := s.storage.GetHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Status.IsActive() {
+		return nil, models.ErrConflict{Message: "hold is not active"}
+	}
+	return s.storage.CancelHold(ctx, id, s.holdExpiresAt())
+}
+
+// ExpireHolds expires the ready holds that were not picked up in time.
+func (s *Service) ExpireHolds(ctx context.Context) (int, error) {
+	return s.storage.ExpireHolds(ctx, time.Now().UTC(), s.holdExpiresAt())
+}
+
+// readyHold returns the ready hold of the patron on the book, if any.
+func (s *Service) readyHold(ctx context.Context, bookID, patronID int) (*models.Hold, error) {
+	ready := models.HoldReady
+	holds, err := s.storage.GetHolds(ctx, &models.HoldSearch{BookID: &bookID, PatronID: &patronID, Status: &ready}, 1, 0)
+	if err != nil || len(holds) == 0 {
+		return nil, err
+	}
+	return &holds[0], nil
+}
+
+// holdExpiresAt is when a hold that becomes ready now expires.
+func (s *Service) holdExpiresAt() time.Time {
+	return time.Now().UTC().Add(s.cfg.HoldPickupWindow)
+}
@@ -0,0 +1,19 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// ImportBooks adds a batch of imported books and reports the outcome for each
+// of them. A dry run adds nothing.
+func (s *Service) ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error) {
+	for _, b := range books {
+		err := normalizeISBN(b)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.storage.ImportBooks(ctx, books, dryRun)
+}
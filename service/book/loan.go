@@ -0,0 +1,116 @@
+package book
+
+import (
+	"context"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// CheckoutBook lends a copy of the book to the borrower. If copyID is zero the
+// copy kept for the borrower's ready hold is lent, or else any available copy.
+// If dueAt is zero the loan is due after the configured loan period. The
+// checks and the loan are made in one transaction.
+func (s *Service) CheckoutBook(ctx context.Context, bookID, copyID, borrowerID int, dueAt time.Time) (*models.Loan, error) {
+	var l *models.Loan
+	err := s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		var err error
+		l, err = s.withStorage(tx).checkout(ctx, bookID, copyID, borrowerID, dueAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *Service) checkout(ctx context.Context, bookID, copyID, borrowerID int, dueAt time.Time) (*models.Loan, error) {
+	err := s.checkLoanLimit(ctx, borrowerID)
+	if err != nil {
+		return nil, err
+	}
+	if copyID == 0 {
+		h, err := s.readyHold(ctx, bookID, borrowerID)
+		if err != nil {
+			return nil, err
+		}
+		if h != nil {
+			copyID = *h.CopyID
+		}
+	}
+	if copyID != 0 {
+		err := s.checkTransition(ctx, bookID, copyID, borrowerID, models.StatusCheckedOut)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		b, err := s.storage.GetBook(ctx, bookID)
+		if err != nil {
+			return nil, err
+		}
+		if b.AvailableCopies == 0 {
+			return nil, models.ErrConflict{Message: "book has no available copy, place a hold"}
+		}
+	}
+	if dueAt.IsZero() {
+		dueAt = time.Now().UTC().Add(s.cfg.LoanPeriod)
+	}
+	l := &models.Loan{
+		BookID:     bookID,
+		CopyID:     copyID,
+		BorrowerID: borrowerID,
+		DueAt:      dueAt,
+	}
+	err = s.storage.CreateLoan(ctx, l)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// ReturnBook closes the open loan of the copy. If copyID is zero the book
+// must have exactly one copy checked out. The checks and the return are made
+// in one transaction.
+func (s *Service) ReturnBook(ctx context.Context, bookID, copyID int) (*models.Loan, error) {
+	var l *models.Loan
+	err := s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		var err error
+		l, err = s.withStorage(tx).returnCopy(ctx, bookID, copyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *Service) returnCopy(ctx context.Context, bookID, copyID int) (*models.Loan, error) {
+	if copyID == 0 {
+		_, err := s.storage.GetBook(ctx, bookID)
+		if err != nil {
+			return nil, err
+		}
+		open := true
+		loans, err := s.storage.GetLoans(ctx, &models.LoanSearch{BookID: &bookID, Open: &open}, 2, 0)
+		if err != nil {
+			return nil, err
+		}
+		switch len(loans) {
+		case 0:
+			return nil, models.ErrConflict{Message: "book is not checked out"}
+		case 1:
+			copyID = loans[0].CopyID
+		default:
+			return nil, models.ErrConflict{Message: "several copies of the book are checked out, use copy_id"}
+		}
+	}
+	err := s.checkTransition(ctx, bookID, copyID, 0, models.StatusCheckedIn)
+	if err != nil {
+		return nil, err
+	}
+	return s.storage.CloseLoan(ctx, copyID, time.Now().UTC(), s.holdExpiresAt())
+}
+
+func (s *Service) GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error) {
+	return s.storage.GetLoans(ctx, ls, limit, offset)
+}
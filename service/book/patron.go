@@ -0,0 +1,70 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// AddPatron adds an active patron, with the configured loan limit if none is given.
+func (s *Service) AddPatron(ctx context.Context, p *models.Patron) error {
+	if p.LoanLimit == 0 {
+		p.LoanLimit = s.cfg.LoanLimit
+	}
+	return s.storage.CreatePatron(ctx, p)
+}
+
+func (s *Service) GetPatron(ctx context.Context, id int) (*models.Patron, error) {
+	return s.storage.GetPatron(ctx, id)
+}
+
+func (s *Service) GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error) {
+	return s.storage.GetPatrons(ctx, ps, limit, offset)
+}
+
+func (s *Service) UpdatePatron(ctx context.Context, p *models.Patron) error {
+	if p.LoanLimit == 0 {
+		p.LoanLimit = s.cfg.LoanLimit
+	}
+	return s.storage.UpdatePatron(ctx, p)
+}
+
+// DeletePatron deletes a patron without loans and holds. It runs in a
+// transaction of its own so that no loan or hold is added meanwhile.
+func (s *Service) DeletePatron(ctx context.Context, id int) error {
+	return s.storage.RunInTx(ctx, func(tx StorageManager) error {
+		return tx.DeletePatron(ctx, id)
+	})
+}
+
+// activePatron returns the patron if it exists and is not suspended.
+func (s *Service) activePatron(ctx context.Context, id int) (*models.Patron, error) {
+	p, err := s.storage.GetPatron(ctx, id)
+	if _, ok := err.(models.ErrNotFound); ok {
+		return nil, models.ErrNotFound{Message: "patron does not exist"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PatronSuspended {
+		return nil, models.ErrConflict{Message: "patron is suspended"}
+	}
+	return p, nil
+}
+
+// checkLoanLimit makes sure the patron may borrow another book.
+func (s *Service) checkLoanLimit(ctx context.Context, patronID int) error {
+	p, err := s.activePatron(ctx, patronID)
+	if err != nil {
+		return err
+	}
+	open := true
+	loans, err := s.storage.GetLoans(ctx, &models.LoanSearch{BorrowerID: &patronID, Open: &open}, p.LoanLimit, 0)
+	if err != nil {
+		return err
+	}
+	if len(loans) >= p.LoanLimit {
+		return models.ErrConflict{Message: "patron has reached the loan limit"}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+func (s *Service) AddPublisher(ctx context.Context, p *models.Publisher) error {
+	return s.storage.CreatePublisher(ctx, p)
+}
+
+func (s *Service) GetPublisher(ctx context.Context, id int) (*models.Publisher, error) {
+	return s.storage.GetPublisher(ctx, id)
+}
+
+func (s *Service) GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error) {
+	return s.storage.GetPublishers(ctx, name, limit, offset)
+}
+
+func (s *Service) UpdatePublisher(ctx context.Context, p *models.Publisher) error {
+	return s.storage.UpdatePublisher(ctx, p)
+}
+
+func (s *Service) DeletePublisher(ctx context.Context, id int) error {
+	return s.storage.DeletePublisher(ctx, id)
+}
@@ -2,24 +2,90 @@ package book
 
 import (
 	"context"
+	"time"
 
 	"github.com/libreria/models"
 )
 
+// Config of the book service. HoldPickupWindow is how long a returned copy is
+// kept for a ready hold, expired holds are looked for every HoldExpiryInterval.
+// Overdue loans are looked for and their fines accrued every OverdueInterval.
+// A job whose interval is not positive is disabled. LoanLimit is the loan limit of patrons added without one. Books deleted
+// longer than DeletedRetention ago are purged every PurgeInterval, the zero
+// default keeps them forever so purging is opted into.
+type Config struct {
+	LoanPeriod         time.Duration     `mapstructure:"loan_period"          default:"336h"`
+	LoanLimit          int               `mapstructure:"loan_limit"           default:"5"`
+	HoldPickupWindow   time.Duration     `mapstructure:"hold_pickup_window"   default:"72h"`
+	HoldExpiryInterval time.Duration     `mapstructure:"hold_expiry_interval" default:"5m"`
+	OverdueInterval    time.Duration     `mapstructure:"overdue_interval"     default:"1h"`
+	DeletedRetention   time.Duration     `mapstructure:"deleted_retention"    default:"0"`
+	PurgeInterval      time.Duration     `mapstructure:"purge_interval"       default:"24h"`
+	Fines              models.FinePolicy `mapstructure:"fines"`
+}
+
+// StorageManager keeps the library. RunInTx runs fn with a storage whose
+// operations commit together if fn returns nil and roll back otherwise, fn may
+// be run again when the transaction conflicts with a concurrent one.
 type StorageManager interface {
+	RunInTx(ctx context.Context, fn func(tx StorageManager) error) error
 	GetBook(ctx context.Context, id int) (*models.Book, error)
-	GetBooks(ctx context.Context, bs *models.BookSearch, limit, offset int) ([]models.Book, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error)
+	ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error
 	UpdateBook(ctx context.Context, b *models.Book) error
 	CreateBook(ctx context.Context, b *models.Book) error
-	UpdateBookStatus(ctx context.Context, id, status int) error
-	RateBook(ctx context.Context, id, rate int) error
-	DeleteBook(ctx context.Context, id int) error
+	ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error)
+	RateBook(ctx context.Context, r *models.Rating) error
+	DeleteBook(ctx context.Context, id, version int) error
+	RestoreBook(ctx context.Context, id int) error
+	PurgeBook(ctx context.Context, id, version int) error
+	PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error)
+	CreateLoan(ctx context.Context, l *models.Loan) error
+	CloseLoan(ctx context.Context, copyID int, returnedAt, holdExpiresAt time.Time) (*models.Loan, error)
+	GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error)
+	MarkOverdueLoans(ctx context.Context, now time.Time) (int, error)
+	AccrueFines(ctx context.Context, now time.Time, p models.FinePolicy) (int, error)
+	GetFine(ctx context.Context, id int) (*models.Fine, error)
+	GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error)
+	SettleFine(ctx context.Context, id int, status models.FineStatus, settledAt time.Time) (*models.Fine, error)
+	CreateCopy(ctx context.Context, c *models.Copy, holdExpiresAt time.Time) error
+	GetCopy(ctx context.Context, id int) (*models.Copy, error)
+	GetCopies(ctx context.Context, bookID int) ([]models.Copy, error)
+	RetireCopy(ctx context.Context, id int, retiredAt time.Time) (*models.Copy, error)
+	CreateHold(ctx context.Context, h *models.Hold) error
+	GetHold(ctx context.Context, id int) (*models.Hold, error)
+	GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error)
+	CancelHold(ctx context.Context, id int, expiresAt time.Time) (*models.Hold, error)
+	ExpireHolds(ctx context.Context, now, expiresAt time.Time) (int, error)
+	CreatePatron(ctx context.Context, p *models.Patron) error
+	GetPatron(ctx context.Context, id int) (*models.Patron, error)
+	GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error)
+	UpdatePatron(ctx context.Context, p *models.Patron) error
+	DeletePatron(ctx context.Context, id int) error
+	CreateAuthor(ctx context.Context, a *models.Author) error
+	GetAuthor(ctx context.Context, id int) (*models.Author, error)
+	GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error)
+	UpdateAuthor(ctx context.Context, a *models.Author) error
+	DeleteAuthor(ctx context.Context, id int) error
+	CreatePublisher(ctx context.Context, p *models.Publisher) error
+	GetPublisher(ctx context.Context, id int) (*models.Publisher, error)
+	GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error)
+	UpdatePublisher(ctx context.Context, p *models.Publisher) error
+	DeletePublisher(ctx context.Context, id int) error
 }
 
 type Service struct {
+	cfg     Config
 	storage StorageManager
 }
 
-func New(storage StorageManager) *Service {
-	return &Service{storage: storage}
+func New(cfg Config, storage StorageManager) *Service {
+	return &Service{cfg: cfg, storage: storage}
+}
+
+// withStorage returns the service on the storage of a transaction.
+func (s *Service) withStorage(storage StorageManager) *Service {
+	return &Service{cfg: s.cfg, storage: storage}
 }
@@ -0,0 +1,44 @@
+package book
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+var statusConflicts = map[models.BookStatus]string{
+	models.StatusCheckedIn:  "copy is not checked out",
+	models.StatusCheckedOut: "copy is already checked out",
+}
+
+// checkTransition makes sure the copy exists, belongs to the book and may be
+// moved to the given status. A copy on hold may only be lent to the patron of the hold.
+func (s *Service) checkTransition(ctx context.Context, bookID, copyID, borrowerID int, to models.BookStatus) error {
+	c, err := s.storage.GetCopy(ctx, copyID)
+	if err != nil {
+		return err
+	}
+	if c.BookID != bookID {
+		return models.ErrNotFound{Message: "copy does not belong to the book"}
+	}
+	if c.RetiredAt != nil {
+		return models.ErrConflict{Message: "copy is retired"}
+	}
+	if !c.Status.CanTransitionTo(to) {
+		msg, ok := statusConflicts[to]
+		if !ok {
+			msg = "copy status cannot be changed"
+		}
+		return models.ErrConflict{Message: msg}
+	}
+	if c.Status == models.StatusOnHold {
+		h, err := s.readyHold(ctx, bookID, borrowerID)
+		if err != nil {
+			return err
+		}
+		if h == nil || *h.CopyID != copyID {
+			return models.ErrConflict{Message: "copy is on hold for another patron"}
+		}
+	}
+	return nil
+}
@@ -7,13 +7,19 @@ import (
 )
 
 func (s *Service) UpdateBook(ctx context.Context, b *models.Book) error {
+	err := normalizeISBN(b)
+	if err != nil {
+		return err
+	}
 	return s.storage.UpdateBook(ctx, b)
 }
 
-func (s *Service) UpdateBookStatus(ctx context.Context, id, status int) error {
-	return s.storage.UpdateBookStatus(ctx, id, status)
-}
-
-func (s *Service) RateBook(ctx context.Context, id, rate int) error {
-	return s.storage.RateBook(ctx, id, rate)
+// RateBook stores the rating of the book by the user, replacing the user's
+// previous rating of it.
+func (s *Service) RateBook(ctx context.Context, id int, userID string, rate int) error {
+	return s.storage.RateBook(ctx, &models.Rating{
+		BookID: id,
+		UserID: userID,
+		Rating: rate,
+	})
 }
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// GetBookHistory returns the audit entries of the book in the order they were made.
+func (s *Storage) GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error) {
+	var res []models.AuditEntry
+	err := s.read(func(st *state) error {
+		for _, e := range st.audit {
+			if e.BookID == bookID {
+				res = append(res, e)
+			}
+		}
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+// lockBook returns the book with its authors, the name follows the postgres
+// storage where the book is locked until the end of the transaction.
+func (st *state) lockBook(id int) (*models.Book, error) {
+	return st.lockBookQuery(id, false)
+}
+
+// lockDeletedBook is lockBook that finds soft-deleted books too.
+func (st *state) lockDeletedBook(id int) (*models.Book, error) {
+	return st.lockBookQuery(id, true)
+}
+
+func (st *state) lockBookQuery(id int, withDeleted bool) (*models.Book, error) {
+	b, ok := st.books[id]
+	if !ok || (b.DeletedAt != nil && !withDeleted) {
+		return nil, models.ErrNotFound{Message: "book does not exist"}
+	}
+	st.loadBookAuthors(&b)
+	return &b, nil
+}
+
+// saveBook stores a write of the book, which makes a new version of it like
+// every update of a book in postgres.
+func (st *state) saveBook(b models.Book) {
+	b.Version++
+	b.Authors = nil
+	b.UpdatedAt = dbTimePtr(time.Now())
+	st.books[b.ID] = b
+}
+
+// auditBook records the mutation of a book by the caller of ctx. before and
+// after are the book before and after the mutation, nil if it did not exist.
+func (st *state) auditBook(ctx context.Context, action models.AuditAction, bookID int, before, after *models.Book) error {
+	e := models.AuditEntry{
+		ID:        st.next("book_audit"),
+		BookID:    bookID,
+		Actor:     models.ActorFromContext(ctx),
+		Action:    action,
+		CreatedAt: now(),
+	}
+	var err error
+	e.Before, e.After, err = models.BookDiff(before, after)
+	if err != nil {
+		return err
+	}
+	st.audit = append(st.audit, e)
+	return nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func (s *Storage) CreateAuthor(ctx context.Context, a *models.Author) error {
+	return s.write(func(st *state) error {
+		id := st.next("authors")
+		if _, ok := st.authorByName(a.Name); ok {
+			return models.ErrConflict{Message: "author already exists"}
+		}
+		a.ID = id
+		t := now()
+		a.CreatedAt, a.UpdatedAt = &t, &t
+		st.authors[a.ID] = *a
+		return nil
+	})
+}
+
+func (s *Storage) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	var res *models.Author
+	err := s.read(func(st *state) error {
+		a, ok := st.authors[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &a
+		return nil
+	})
+	return res, err
+}
+
+func (s *Storage) GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error) {
+	var res []models.Author
+	err := s.read(func(st *state) error {
+		for _, a := range sorted(st.authors) {
+			if strings.Contains(textsearch.Fold(a.Name), textsearch.Fold(name)) {
+				res = append(res, a)
+			}
+		}
+		sortByName(res, func(a models.Author) (string, int) { return a.Name, a.ID })
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+// UpdateAuthor renames the author and the author names stored with the author's books.
+func (s *Storage) UpdateAuthor(ctx context.Context, a *models.Author) error {
+	return s.write(func(st *state) error {
+		stored, ok := st.authors[a.ID]
+		if !ok {
+			return models.ErrNotFound{Message: "author does not exist"}
+		}
+		if other, ok := st.authorByName(a.Name); ok && other.ID != a.ID {
+			return models.ErrConflict{Message: "author already exists"}
+		}
+		stored.Name = a.Name
+		stored.UpdatedAt = dbTimePtr(time.Now())
+		st.authors[a.ID] = stored
+		for _, b := range sorted(st.books) {
+			if !st.hasAuthor(b.ID, a.ID) {
+				continue
+			}
+			names := make([]string, 0, len(st.bookAuthors[b.ID]))
+			for _, id := range st.bookAuthors[b.ID] {
+				names = append(names, st.authors[id].Name)
+			}
+			b.Author = strings.Join(names, ", ")
+			st.saveBook(b)
+		}
+		return nil
+	})
+}
+
+func (st *state) hasAuthor(bookID, authorID int) bool {
+	for _, id := range st.bookAuthors[bookID] {
+		if id == authorID {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteAuthor deletes an author without books.
+func (s *Storage) DeleteAuthor(ctx context.Context, id int) error {
+	return s.write(func(st *state) error {
+		for bookID := range st.bookAuthors {
+			if st.hasAuthor(bookID, id) {
+				return models.ErrConflict{Message: "author has books"}
+			}
+		}
+		if _, ok := st.authors[id]; !ok {
+			return models.ErrNotFound{Message: "author does not exist"}
+		}
+		delete(st.authors, id)
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// CreateBook inserts the book with one copy and links it to its authors and publisher.
+// Every mutation of a book is recorded in its audit log with it.
+func (s *Storage) CreateBook(ctx context.Context, b *models.Book) error {
+	return s.write(func(st *state) error {
+		return st.createBook(ctx, b)
+	})
+}
+
+func (st *state) createBook(ctx context.Context, b *models.Book) error {
+	err := st.resolveBookRelations(b)
+	if err != nil {
+		return err
+	}
+	stored := *b
+	stored.ID = st.next("books")
+	if stored.ISBN != "" && st.isbnTaken(stored.ISBN, 0) {
+		return models.ErrConflict{Message: "book with this isbn already exists"}
+	}
+	stored.PublishDate = dbTime(stored.PublishDate)
+	if stored.Version == 0 {
+		stored.Version = 1
+	}
+	t := now()
+	stored.CreatedAt, stored.UpdatedAt = &t, &t
+	stored.Authors = nil
+	st.books[stored.ID] = stored
+	b.ID = stored.ID
+	st.linkBookAuthors(b)
+	// a new book comes with its first copy
+	st.insertCopy(models.Copy{BookID: b.ID})
+	st.refreshBookCopies(b.ID)
+	after, err := st.lockBook(b.ID)
+	if err != nil {
+		return err
+	}
+	b.TotalCopies, b.AvailableCopies, b.Status, b.Version = after.TotalCopies, after.AvailableCopies, after.Status, after.Version
+	return st.auditBook(ctx, models.AuditCreate, b.ID, nil, after)
+}
+
+// isbnTaken tells whether a book that is not deleted, other than the book
+// with the id, has the isbn.
+func (st *state) isbnTaken(isbn string, id int) bool {
+	for _, b := range st.books {
+		if b.ID != id && b.ISBN == isbn && b.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateBook writes the fields of the book that differ from the stored ones.
+// An update changing nothing leaves the book, its version and its audit log
+// untouched.
+func (s *Storage) UpdateBook(ctx context.Context, b *models.Book) error {
+	return s.write(func(st *state) error {
+		before, err := st.lockBook(b.ID)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, b.Version)
+		if err != nil {
+			return err
+		}
+		err = st.resolveBookRelations(b)
+		if err != nil {
+			return err
+		}
+		changed := bookChanged(before, b)
+		authorsChanged := !sameAuthors(before.Authors, b.Authors)
+		if !changed && !authorsChanged {
+			b.Version = before.Version
+			return nil
+		}
+		stored := st.books[b.ID]
+		if b.ISBN != "" && b.ISBN != stored.ISBN && st.isbnTaken(b.ISBN, b.ID) {
+			return models.ErrConflict{Message: "book with this isbn already exists"}
+		}
+		stored.Title = b.Title
+		stored.ISBN = b.ISBN
+		stored.Author = b.Author
+		stored.Publisher = b.Publisher
+		stored.PublisherID = b.PublisherID
+		stored.PublishDate = dbTime(b.PublishDate)
+		st.saveBook(stored)
+		if authorsChanged {
+			st.linkBookAuthors(b)
+		}
+		after, err := st.lockBook(b.ID)
+		if err != nil {
+			return err
+		}
+		b.Version = after.Version
+		return st.auditBook(ctx, models.AuditUpdate, b.ID, before, after)
+	})
+}
+
+// bookChanged tells whether an editable field of b differs from the stored book.
+func bookChanged(stored, b *models.Book) bool {
+	return b.Title != stored.Title ||
+		b.ISBN != stored.ISBN ||
+		b.Author != stored.Author ||
+		b.Publisher != stored.Publisher ||
+		b.PublisherID != stored.PublisherID ||
+		!dbTime(b.PublishDate).Equal(stored.PublishDate)
+}
+
+func sameAuthors(a, b []models.Author) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Storage) GetBook(ctx context.Context, id int) (*models.Book, error) {
+	var res *models.Book
+	err := s.read(func(st *state) error {
+		b, err := st.lockBook(id)
+		if err != nil {
+			return models.ErrNotFound{}
+		}
+		res = b
+		return nil
+	})
+	return res, err
+}
+
+func (s *Storage) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	var res *models.Book
+	err := s.read(func(st *state) error {
+		for _, b := range sorted(st.books) {
+			if isbn != "" && b.ISBN == isbn && b.DeletedAt == nil {
+				st.loadBookAuthors(&b)
+				res = &b
+				return nil
+			}
+		}
+		return models.ErrNotFound{}
+	})
+	return res, err
+}
+
+// RateBook stores the user's rating of the book, replacing the previous rating
+// of the same user, and recomputes the book's average rating.
+func (s *Storage) RateBook(ctx context.Context, r *models.Rating) error {
+	return s.write(func(st *state) error {
+		before, err := st.lockBook(r.BookID)
+		if err != nil {
+			return err
+		}
+		// the range of the rating check of postgres
+		if r.Rating < 1 || r.Rating > 3 {
+			return fmt.Errorf("rating %d violates the rating check", r.Rating)
+		}
+		k := ratingKey{bookID: r.BookID, userID: r.UserID}
+		t := now()
+		stored, ok := st.ratings[k]
+		if !ok {
+			stored = models.Rating{BookID: r.BookID, UserID: r.UserID, CreatedAt: &t}
+		}
+		stored.Rating = r.Rating
+		stored.UpdatedAt = &t
+		st.ratings[k] = stored
+		var sum, count int
+		for _, rt := range st.ratings {
+			if rt.BookID == r.BookID {
+				sum += rt.Rating
+				count++
+			}
+		}
+		b := st.books[r.BookID]
+		b.Rating = float64(sum) / float64(count)
+		b.RatingCount = count
+		st.saveBook(b)
+		after, err := st.lockBook(r.BookID)
+		if err != nil {
+			return err
+		}
+		return st.auditBook(ctx, models.AuditRate, r.BookID, before, after)
+	})
+}
+
+// DeleteBook soft-deletes the book if it is still at version, any version
+// matches if version is zero.
+func (s *Storage) DeleteBook(ctx context.Context, id, version int) error {
+	return s.write(func(st *state) error {
+		before, err := st.lockBook(id)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, version)
+		if err != nil {
+			return err
+		}
+		b := st.books[id]
+		b.DeletedAt = dbTimePtr(time.Now())
+		st.saveBook(b)
+		return st.auditBook(ctx, models.AuditDelete, id, before, nil)
+	})
+}
+
+// checkVersion makes sure the book is still at the version the caller read,
+// any version matches if version is zero.
+func checkVersion(b *models.Book, version int) error {
+	if version != 0 && b.Version != version {
+		return models.ErrPreconditionFailed{Message: "book has been modified"}
+	}
+	return nil
+}
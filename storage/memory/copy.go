@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// CreateCopy adds a copy to the book and counts it with the book's copies. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CreateCopy(ctx context.Context, c *models.Copy, holdExpiresAt time.Time) error {
+	return s.write(func(st *state) error {
+		if _, ok := st.books[c.BookID]; !ok {
+			return fmt.Errorf("book %d of the copy does not exist", c.BookID)
+		}
+		stored, err := st.insertCopy(*c)
+		if err != nil {
+			return err
+		}
+		err = st.passCopy(stored.ID, stored.BookID, models.StatusCheckedIn, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		*c = st.copies[stored.ID]
+		return nil
+	})
+}
+
+// insertCopy stores a new copy with the defaults of the copies table.
+func (st *state) insertCopy(c models.Copy) (models.Copy, error) {
+	c.ID = st.next("copies")
+	if c.Barcode == "" {
+		c.Barcode = fmt.Sprintf("LIB%09d", st.next("copies_barcode"))
+	}
+	if c.Condition == "" {
+		c.Condition = "good"
+	}
+	for _, other := range st.copies {
+		if other.Barcode == c.Barcode {
+			return models.Copy{}, models.ErrConflict{Message: "copy with this barcode already exists"}
+		}
+	}
+	t := now()
+	c.CreatedAt, c.UpdatedAt = &t, &t
+	st.copies[c.ID] = c
+	return c, nil
+}
+
+func (s *Storage) GetCopy(ctx context.Context, id int) (*models.Copy, error) {
+	var res *models.Copy
+	err := s.read(func(st *state) error {
+		c, ok := st.copies[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &c
+		return nil
+	})
+	return res, err
+}
+
+// GetCopies returns all copies of the book including the retired ones.
+func (s *Storage) GetCopies(ctx context.Context, bookID int) ([]models.Copy, error) {
+	var res []models.Copy
+	err := s.read(func(st *state) error {
+		for _, c := range sorted(st.copies) {
+			if c.BookID == bookID {
+				res = append(res, c)
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// RetireCopy takes a checked in copy out of circulation.
+func (s *Storage) RetireCopy(ctx context.Context, id int, retiredAt time.Time) (*models.Copy, error) {
+	var res models.Copy
+	err := s.write(func(st *state) error {
+		c, ok := st.copies[id]
+		if !ok || !c.IsAvailable() {
+			return models.ErrConflict{Message: "copy is checked out, on hold or already retired"}
+		}
+		c.RetiredAt = dbTimePtr(retiredAt)
+		c.UpdatedAt = dbTimePtr(time.Now())
+		st.copies[id] = c
+		res = c
+		st.refreshBookCopies(c.BookID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// availableCopy returns the first copy of the book that can be checked out.
+func (st *state) availableCopy(bookID int) (int, error) {
+	for _, c := range sorted(st.copies) {
+		if c.BookID == bookID && c.IsAvailable() {
+			return c.ID, nil
+		}
+	}
+	return 0, models.ErrConflict{Message: "book has no available copy"}
+}
+
+// updateCopyStatus moves the copy from one status to another. It fails with
+// a conflict if the copy is not in the expected status.
+func (st *state) updateCopyStatus(id int, from, to models.BookStatus) error {
+	c, ok := st.copies[id]
+	if !ok || c.Status != from || c.RetiredAt != nil {
+		return models.ErrConflict{Message: "copy status has been changed by another request"}
+	}
+	c.Status = to
+	c.UpdatedAt = dbTimePtr(time.Now())
+	st.copies[id] = c
+	return nil
+}
+
+// refreshBookCopies recomputes the copy counts and the status of the book.
+func (st *state) refreshBookCopies(bookID int) {
+	b, ok := st.books[bookID]
+	if !ok {
+		return
+	}
+	var total, available int
+	for _, c := range st.copies {
+		if c.BookID != bookID || c.RetiredAt != nil {
+			continue
+		}
+		total++
+		if c.Status == models.StatusCheckedIn {
+			available++
+		}
+	}
+	b.TotalCopies, b.AvailableCopies = total, available
+	b.Status = models.StatusCheckedOut
+	if available > 0 {
+		b.Status = models.StatusCheckedIn
+	}
+	st.saveBook(b)
+}
@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+)
+
+// Storage keeps the library in memory for tests and demos, the library is
+// lost on shutdown. It behaves like the postgres storage: every write is
+// atomic and transactions run one after another, so they never conflict.
+type Storage struct {
+	db *database
+	// tx is the state changed by the transaction of the storage, see RunInTx.
+	tx *state
+}
+
+type database struct {
+	mu    sync.Mutex
+	state *state
+}
+
+// state is a version of the library. Rows are stored by id, books without
+// their authors which are linked by bookAuthors.
+type state struct {
+	books       map[int]models.Book
+	bookAuthors map[int][]int
+	authors     map[int]models.Author
+	publishers  map[int]models.Publisher
+	copies      map[int]models.Copy
+	loans       map[int]models.Loan
+	holds       map[int]models.Hold
+	fines       map[int]models.Fine
+	patrons     map[int]models.Patron
+	ratings     map[ratingKey]models.Rating
+	audit       []models.AuditEntry
+	// seq holds the last values of the sequences, shared by all the versions
+	// of the library since sequences are not rolled back.
+	seq map[string]int
+}
+
+type ratingKey struct {
+	bookID int
+	userID string
+}
+
+func New() *Storage {
+	return &Storage{db: &database{state: &state{
+		books:       make(map[int]models.Book),
+		bookAuthors: make(map[int][]int),
+		authors:     make(map[int]models.Author),
+		publishers:  make(map[int]models.Publisher),
+		copies:      make(map[int]models.Copy),
+		loans:       make(map[int]models.Loan),
+		holds:       make(map[int]models.Hold),
+		fines:       make(map[int]models.Fine),
+		patrons:     make(map[int]models.Patron),
+		ratings:     make(map[ratingKey]models.Rating),
+		seq:         make(map[string]int),
+	}}}
+}
+
+// RunInTx runs fn with a storage whose writes are kept if fn returns nil and
+// dropped otherwise. Transactions hold the storage until they end, within a
+// transaction fn runs in it.
+func (s *Storage) RunInTx(ctx context.Context, fn func(tx book.StorageManager) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	tx := &Storage{db: s.db, tx: s.db.state.clone()}
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+	s.db.state = tx.tx
+	return nil
+}
+
+// read runs fn on the state of the transaction, or the current state.
+func (s *Storage) read(fn func(st *state) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	return fn(s.db.state)
+}
+
+// write runs fn on a copy of the state which replaces the state if fn
+// succeeds, so a failed write changes nothing. In a transaction fn changes
+// the state of the transaction, which is dropped when the transaction fails.
+func (s *Storage) write(fn func(st *state) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	st := s.db.state.clone()
+	err := fn(st)
+	if err != nil {
+		return err
+	}
+	s.db.state = st
+	return nil
+}
+
+func (st *state) clone() *state {
+	return &state{
+		books:       cloneMap(st.books),
+		bookAuthors: cloneMap(st.bookAuthors),
+		authors:     cloneMap(st.authors),
+		publishers:  cloneMap(st.publishers),
+		copies:      cloneMap(st.copies),
+		loans:       cloneMap(st.loans),
+		holds:       cloneMap(st.holds),
+		fines:       cloneMap(st.fines),
+		patrons:     cloneMap(st.patrons),
+		ratings:     cloneMap(st.ratings),
+		audit:       append([]models.AuditEntry(nil), st.audit...),
+		seq:         st.seq,
+	}
+}
+
+// cloneMap copies the rows of a table. Rows are replaced and never changed
+// in place, so copying their values is enough.
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	res := make(map[K]V, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+// next returns the next value of the sequence.
+func (st *state) next(name string) int {
+	st.seq[name]++
+	return st.seq[name]
+}
+
+// sorted returns the rows of a table in the order of their ids.
+func sorted[V any](m map[int]V) []V {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	res := make([]V, len(ids))
+	for i, id := range ids {
+		res[i] = m[id]
+	}
+	return res
+}
+
+// page returns the rows selected by limit and offset, a zero limit selects
+// all the rows after the offset like an omitted LIMIT.
+func page[V any](rows []V, limit, offset int) []V {
+	if offset >= len(rows) {
+		return []V{}
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
+
+// now is the current time as stored by postgres, in UTC with microseconds.
+func now() time.Time {
+	return dbTime(time.Now())
+}
+
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Round(time.Microsecond)
+}
+
+func dbTimePtr(t time.Time) *time.Time {
+	t = dbTime(t)
+	return &t
+}
@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// ExportBooks calls fn with every book matching the search in the order of
+// their ids. The books are collected first, fn runs without holding the storage.
+func (s *Storage) ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error {
+	var books []models.Book
+	_ = s.read(func(st *state) error {
+		for _, b := range st.searchBooks(bs) {
+			st.loadBookAuthors(&b.Book)
+			books = append(books, b.Book)
+		}
+		return nil
+	})
+	for i := range books {
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		err = fn(&books[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
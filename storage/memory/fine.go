@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+func (s *Storage) GetFine(ctx context.Context, id int) (*models.Fine, error) {
+	var res *models.Fine
+	err := s.read(func(st *state) error {
+		f, ok := st.fines[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &f
+		return nil
+	})
+	return res, err
+}
+
+func (s *Storage) GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error) {
+	var res []models.Fine
+	err := s.read(func(st *state) error {
+		for _, f := range sorted(st.fines) {
+			if fs != nil && fs.PatronID != nil && f.PatronID != *fs.PatronID {
+				continue
+			}
+			if fs != nil && fs.Status != nil && f.Status != *fs.Status {
+				continue
+			}
+			res = append(res, f)
+		}
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+// SettleFine pays or waives an open fine. A fine can only be paid once its
+// loan is returned and the fine stopped accruing.
+func (s *Storage) SettleFine(ctx context.Context, id int, status models.FineStatus, settledAt time.Time) (*models.Fine, error) {
+	var res models.Fine
+	err := s.write(func(st *state) error {
+		f, ok := st.fines[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		if f.Status != models.FineOpen {
+			return models.ErrConflict{Message: "fine is already settled"}
+		}
+		if status == models.FinePaid {
+			l, ok := st.loans[f.LoanID]
+			if ok && (l.ReturnedAt == nil || l.ReturnedAt.After(f.AccruedUntil)) {
+				return models.ErrConflict{Message: "fine is still accruing, return the book first"}
+			}
+		}
+		f.Status = status
+		f.SettledAt = dbTimePtr(settledAt)
+		f.UpdatedAt = dbTimePtr(time.Now())
+		st.fines[id] = f
+		res = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MarkOverdueLoans marks the open loans that are past their due date at now.
+func (s *Storage) MarkOverdueLoans(ctx context.Context, now time.Time) (int, error) {
+	var n int
+	err := s.write(func(st *state) error {
+		n = 0
+		for id, l := range st.loans {
+			if l.ReturnedAt == nil && l.OverdueAt == nil && l.DueAt.Before(now) {
+				l.OverdueAt = dbTimePtr(now)
+				st.loans[id] = l
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// AccrueFines creates or updates the open fines of the loans that are overdue
+// at now, or were returned late after their fine was last accrued. It returns
+// the number of created or updated fines.
+func (s *Storage) AccrueFines(ctx context.Context, now time.Time, p models.FinePolicy) (int, error) {
+	var n int
+	err := s.write(func(st *state) error {
+		n = 0
+		fines := make(map[int]models.Fine, len(st.fines))
+		for _, f := range st.fines {
+			fines[f.LoanID] = f
+		}
+		for _, l := range sorted(st.loans) {
+			end := dbTime(now)
+			if l.ReturnedAt != nil {
+				end = *l.ReturnedAt
+			}
+			if !l.DueAt.Add(p.GracePeriod).Before(end) {
+				continue
+			}
+			f, exists := fines[l.ID]
+			if exists && (f.Status != models.FineOpen || !f.AccruedUntil.Before(end)) {
+				continue
+			}
+			// the insert of postgres takes an id for every candidate row
+			id := st.next("fines")
+			days := math.Ceil(end.Sub(l.DueAt.Add(p.GracePeriod)).Seconds() / 86400)
+			amount := p.Amount(int(days))
+			t := now
+			if !exists {
+				f = models.Fine{ID: id, LoanID: l.ID, PatronID: l.BorrowerID, CreatedAt: dbTime(time.Now())}
+			} else if f.Amount == amount && f.AccruedUntil.Equal(end) {
+				continue
+			}
+			f.Amount = amount
+			f.AccruedUntil = end
+			f.UpdatedAt = dbTimePtr(t)
+			st.fines[f.ID] = f
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
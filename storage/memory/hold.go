@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+func (s *Storage) CreateHold(ctx context.Context, h *models.Hold) error {
+	return s.write(func(st *state) error {
+		if _, ok := st.books[h.BookID]; !ok {
+			return fmt.Errorf("book %d of the hold does not exist", h.BookID)
+		}
+		if _, ok := st.patrons[h.PatronID]; !ok {
+			return fmt.Errorf("patron %d of the hold does not exist", h.PatronID)
+		}
+		if h.Status.IsActive() {
+			for _, other := range st.holds {
+				if other.BookID == h.BookID && other.PatronID == h.PatronID && other.Status.IsActive() {
+					return models.ErrConflict{Message: "patron already has a hold on the book"}
+				}
+			}
+		}
+		h.ID = st.next("holds")
+		if h.CreatedAt.IsZero() {
+			h.CreatedAt = now()
+		}
+		h.UpdatedAt = dbTimePtr(time.Now())
+		st.holds[h.ID] = *h
+		return nil
+	})
+}
+
+func (s *Storage) GetHold(ctx context.Context, id int) (*models.Hold, error) {
+	var res *models.Hold
+	err := s.read(func(st *state) error {
+		h, ok := st.holds[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &h
+		return nil
+	})
+	return res, err
+}
+
+// GetHolds returns the holds in the order they were placed.
+func (s *Storage) GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error) {
+	var res []models.Hold
+	err := s.read(func(st *state) error {
+		for _, h := range sorted(st.holds) {
+			if hs != nil && !holdMatches(&h, hs) {
+				continue
+			}
+			res = append(res, h)
+		}
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+func holdMatches(h *models.Hold, hs *models.HoldSearch) bool {
+	if hs.BookID != nil && h.BookID != *hs.BookID {
+		return false
+	}
+	if hs.PatronID != nil && h.PatronID != *hs.PatronID {
+		return false
+	}
+	if hs.CopyID != nil && (h.CopyID == nil || *h.CopyID != *hs.CopyID) {
+		return false
+	}
+	if hs.Status != nil && h.Status != *hs.Status {
+		return false
+	}
+	return hs.Active == nil || *hs.Active == h.Status.IsActive()
+}
+
+// CancelHold cancels an active hold. A copy kept for the hold goes to the next
+// waiting hold, which is ready until expiresAt, or becomes available.
+func (s *Storage) CancelHold(ctx context.Context, id int, expiresAt time.Time) (*models.Hold, error) {
+	var res models.Hold
+	err := s.write(func(st *state) error {
+		h, ok := st.holds[id]
+		if !ok || !h.Status.IsActive() {
+			return models.ErrConflict{Message: "hold is not active"}
+		}
+		h.Status = models.HoldCancelled
+		h.UpdatedAt = dbTimePtr(time.Now())
+		st.holds[id] = h
+		res = h
+		if h.CopyID == nil {
+			return nil
+		}
+		return st.passCopy(*h.CopyID, h.BookID, models.StatusOnHold, expiresAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ExpireHolds expires the ready holds not picked up by now and passes their
+// copies on like CancelHold does. It returns the number of expired holds.
+func (s *Storage) ExpireHolds(ctx context.Context, now, expiresAt time.Time) (int, error) {
+	var n int
+	err := s.write(func(st *state) error {
+		n = 0
+		var expired []models.Hold
+		for _, h := range sorted(st.holds) {
+			if h.Status == models.HoldReady && h.ExpiresAt != nil && h.ExpiresAt.Before(now) {
+				h.Status = models.HoldExpired
+				h.UpdatedAt = dbTimePtr(time.Now())
+				st.holds[h.ID] = h
+				expired = append(expired, h)
+			}
+		}
+		for _, h := range expired {
+			err := st.passCopy(*h.CopyID, h.BookID, models.StatusOnHold, expiresAt)
+			if err != nil {
+				return err
+			}
+		}
+		n = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// passCopy gives a copy that became free to the first waiting hold of the book,
+// the hold is ready until expiresAt. Without waiting holds the copy becomes
+// available.
+func (st *state) passCopy(copyID, bookID int, from models.BookStatus, expiresAt time.Time) error {
+	to := models.StatusCheckedIn
+	for _, h := range sorted(st.holds) {
+		if h.BookID != bookID || h.Status != models.HoldWaiting {
+			continue
+		}
+		id := copyID
+		t := now()
+		h.Status = models.HoldReady
+		h.CopyID = &id
+		h.ReadyAt, h.UpdatedAt = &t, &t
+		h.ExpiresAt = dbTimePtr(expiresAt)
+		st.holds[h.ID] = h
+		to = models.StatusOnHold
+		break
+	}
+	if from != to {
+		err := st.updateCopyStatus(copyID, from, to)
+		if err != nil {
+			return err
+		}
+	}
+	st.refreshBookCopies(bookID)
+	return nil
+}
+
+// fulfillHold closes the ready hold of the borrower kept with the copy of the
+// loan. It returns the status the copy has to be checked out from.
+func (st *state) fulfillHold(l *models.Loan) models.BookStatus {
+	for _, h := range st.holds {
+		if h.CopyID != nil && *h.CopyID == l.CopyID && h.PatronID == l.BorrowerID && h.Status == models.HoldReady {
+			h.Status = models.HoldFulfilled
+			h.UpdatedAt = dbTimePtr(time.Now())
+			st.holds[h.ID] = h
+			return models.StatusOnHold
+		}
+	}
+	return models.StatusCheckedIn
+}
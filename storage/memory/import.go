@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// ImportBooks creates the books in one write, each on a copy of the state so
+// a duplicate or an invalid book is reported without failing the others. A
+// dry run reports the same outcomes and drops the write.
+func (s *Storage) ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error) {
+	res := make([]models.ImportResult, len(books))
+	err := s.write(func(st *state) error {
+		if dryRun {
+			st = st.clone()
+		}
+		for i, b := range books {
+			// like a savepoint, the book is created on a copy kept only on success
+			sp := st.clone()
+			err := sp.createBook(ctx, b)
+			switch e := err.(type) {
+			case nil:
+				*st = *sp
+				res[i] = models.ImportResult{Status: models.ImportCreated, BookID: b.ID}
+			case models.ErrConflict:
+				res[i] = models.ImportResult{Status: models.ImportDuplicate, Message: e.Message}
+			case models.ErrBadRequest:
+				res[i] = models.ImportResult{Status: models.ImportRejected, Message: e.Message}
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		// the books of a dry run were never created
+		for i := range res {
+			res[i].BookID = 0
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// CreateLoan opens a loan and checks the copy out. A loan without a copy is
+// made on any available copy of the book, a copy kept for a hold of the
+// borrower fulfills the hold.
+func (s *Storage) CreateLoan(ctx context.Context, l *models.Loan) error {
+	return s.write(func(st *state) error {
+		before, err := st.lockBook(l.BookID)
+		if err != nil {
+			return err
+		}
+		if _, ok := st.patrons[l.BorrowerID]; !ok {
+			return fmt.Errorf("borrower %d of the loan does not exist", l.BorrowerID)
+		}
+		if l.CopyID == 0 {
+			l.CopyID, err = st.availableCopy(l.BookID)
+			if err != nil {
+				return err
+			}
+		}
+		from := st.fulfillHold(l)
+		err = st.updateCopyStatus(l.CopyID, from, models.StatusCheckedOut)
+		if err != nil {
+			return err
+		}
+		for _, other := range st.loans {
+			if other.CopyID == l.CopyID && other.ReturnedAt == nil {
+				return models.ErrConflict{Message: "copy is already checked out"}
+			}
+		}
+		l.ID = st.next("loans")
+		if l.CheckedOutAt.IsZero() {
+			l.CheckedOutAt = now()
+		}
+		l.CheckedOutAt, l.DueAt = dbTime(l.CheckedOutAt), dbTime(l.DueAt)
+		st.loans[l.ID] = *l
+		st.refreshBookCopies(l.BookID)
+		after, err := st.lockBook(l.BookID)
+		if err != nil {
+			return err
+		}
+		return st.auditBook(ctx, models.AuditCheckout, l.BookID, before, after)
+	})
+}
+
+// CloseLoan closes the open loan of the copy and checks the copy back in. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CloseLoan(ctx context.Context, copyID int, returnedAt, holdExpiresAt time.Time) (*models.Loan, error) {
+	var res models.Loan
+	err := s.write(func(st *state) error {
+		var ok bool
+		for _, l := range st.loans {
+			if l.CopyID == copyID && l.ReturnedAt == nil {
+				res, ok = l, true
+				break
+			}
+		}
+		if !ok {
+			return models.ErrConflict{Message: "copy has no open loan"}
+		}
+		res.ReturnedAt = dbTimePtr(returnedAt)
+		st.loans[res.ID] = res
+		before, err := st.lockBook(res.BookID)
+		if _, deleted := err.(models.ErrNotFound); deleted {
+			// copies of deleted books are still returned, there is nothing to audit
+			return st.passCopy(copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		}
+		if err != nil {
+			return err
+		}
+		err = st.passCopy(copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		after, err := st.lockBook(res.BookID)
+		if err != nil {
+			return err
+		}
+		return st.auditBook(ctx, models.AuditCheckin, res.BookID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error) {
+	var res []models.Loan
+	err := s.read(func(st *state) error {
+		t := time.Now()
+		for _, l := range sorted(st.loans) {
+			if ls != nil && !loanMatches(&l, ls, t) {
+				continue
+			}
+			res = append(res, l)
+		}
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+func loanMatches(l *models.Loan, ls *models.LoanSearch, now time.Time) bool {
+	if ls.BookID != nil && l.BookID != *ls.BookID {
+		return false
+	}
+	if ls.BorrowerID != nil && l.BorrowerID != *ls.BorrowerID {
+		return false
+	}
+	if ls.Open != nil && *ls.Open != (l.ReturnedAt == nil) {
+		return false
+	}
+	return !ls.Overdue || l.IsOverdue(now)
+}
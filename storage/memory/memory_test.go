@@ -0,0 +1,17 @@
+//go:build unit
+// +build unit
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/libreria/service/book"
+	"github.com/libreria/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) book.StorageManager {
+		return New()
+	})
+}
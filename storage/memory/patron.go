@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func (s *Storage) CreatePatron(ctx context.Context, p *models.Patron) error {
+	return s.write(func(st *state) error {
+		p.ID = st.next("patrons")
+		if p.MembershipNumber == "" {
+			p.MembershipNumber = fmt.Sprintf("M%09d", st.next("patrons_membership_number"))
+		}
+		if st.membershipTaken(p.MembershipNumber, p.ID) {
+			return models.ErrConflict{Message: "patron with this membership number already exists"}
+		}
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = now()
+		}
+		p.UpdatedAt = dbTimePtr(time.Now())
+		st.patrons[p.ID] = *p
+		return nil
+	})
+}
+
+func (st *state) membershipTaken(number string, id int) bool {
+	for _, p := range st.patrons {
+		if p.ID != id && p.MembershipNumber == number {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Storage) GetPatron(ctx context.Context, id int) (*models.Patron, error) {
+	var res *models.Patron
+	err := s.read(func(st *state) error {
+		p, ok := st.patrons[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &p
+		return nil
+	})
+	return res, err
+}
+
+func (s *Storage) GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error) {
+	var res []models.Patron
+	err := s.read(func(st *state) error {
+		for _, p := range sorted(st.patrons) {
+			if ps != nil && !patronMatches(&p, ps) {
+				continue
+			}
+			res = append(res, p)
+		}
+		sortByName(res, func(p models.Patron) (string, int) { return p.Name, p.ID })
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+func patronMatches(p *models.Patron, ps *models.PatronSearch) bool {
+	if ps.Query != "" {
+		q := strings.ToLower(ps.Query)
+		if !strings.Contains(textsearch.Fold(p.Name), textsearch.Fold(ps.Query)) &&
+			!strings.Contains(strings.ToLower(p.Email), q) &&
+			!strings.Contains(strings.ToLower(p.MembershipNumber), q) {
+			return false
+		}
+	}
+	if ps.MembershipNumber != "" && p.MembershipNumber != ps.MembershipNumber {
+		return false
+	}
+	return ps.Status == nil || p.Status == *ps.Status
+}
+
+// UpdatePatron updates the patron, the membership number is kept if not given.
+func (s *Storage) UpdatePatron(ctx context.Context, p *models.Patron) error {
+	return s.write(func(st *state) error {
+		stored, ok := st.patrons[p.ID]
+		if !ok {
+			return models.ErrNotFound{Message: "patron does not exist"}
+		}
+		if p.MembershipNumber != "" {
+			if st.membershipTaken(p.MembershipNumber, p.ID) {
+				return models.ErrConflict{Message: "patron with this membership number already exists"}
+			}
+			stored.MembershipNumber = p.MembershipNumber
+		}
+		stored.Name = p.Name
+		stored.Email = p.Email
+		stored.Phone = p.Phone
+		stored.Address = p.Address
+		stored.Status = p.Status
+		stored.LoanLimit = p.LoanLimit
+		stored.UpdatedAt = dbTimePtr(time.Now())
+		st.patrons[p.ID] = stored
+		*p = stored
+		return nil
+	})
+}
+
+// DeletePatron deletes a patron who never borrowed or held a book, the others
+// are kept for the loan and hold history and may be suspended instead.
+func (s *Storage) DeletePatron(ctx context.Context, id int) error {
+	return s.write(func(st *state) error {
+		for _, l := range st.loans {
+			if l.BorrowerID == id {
+				return models.ErrConflict{Message: "patron has loans"}
+			}
+		}
+		for _, h := range st.holds {
+			if h.PatronID == id {
+				return models.ErrConflict{Message: "patron has holds"}
+			}
+		}
+		if _, ok := st.patrons[id]; !ok {
+			return models.ErrNotFound{Message: "patron does not exist"}
+		}
+		delete(st.patrons, id)
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func (s *Storage) CreatePublisher(ctx context.Context, p *models.Publisher) error {
+	return s.write(func(st *state) error {
+		id := st.next("publishers")
+		if _, ok := st.publisherByName(p.Name); ok {
+			return models.ErrConflict{Message: "publisher already exists"}
+		}
+		p.ID = id
+		t := now()
+		p.CreatedAt, p.UpdatedAt = &t, &t
+		st.publishers[p.ID] = *p
+		return nil
+	})
+}
+
+func (s *Storage) GetPublisher(ctx context.Context, id int) (*models.Publisher, error) {
+	var res *models.Publisher
+	err := s.read(func(st *state) error {
+		p, ok := st.publishers[id]
+		if !ok {
+			return models.ErrNotFound{}
+		}
+		res = &p
+		return nil
+	})
+	return res, err
+}
+
+func (s *Storage) GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error) {
+	var res []models.Publisher
+	err := s.read(func(st *state) error {
+		for _, p := range sorted(st.publishers) {
+			if strings.Contains(textsearch.Fold(p.Name), textsearch.Fold(name)) {
+				res = append(res, p)
+			}
+		}
+		sortByName(res, func(p models.Publisher) (string, int) { return p.Name, p.ID })
+		res = page(res, limit, offset)
+		return nil
+	})
+	return res, err
+}
+
+// UpdatePublisher renames the publisher and the publisher name stored with the publisher's books.
+func (s *Storage) UpdatePublisher(ctx context.Context, p *models.Publisher) error {
+	return s.write(func(st *state) error {
+		stored, ok := st.publishers[p.ID]
+		if !ok {
+			return models.ErrNotFound{Message: "publisher does not exist"}
+		}
+		if other, ok := st.publisherByName(p.Name); ok && other.ID != p.ID {
+			return models.ErrConflict{Message: "publisher already exists"}
+		}
+		stored.Name = p.Name
+		stored.UpdatedAt = dbTimePtr(time.Now())
+		st.publishers[p.ID] = stored
+		for _, b := range sorted(st.books) {
+			if b.PublisherID == p.ID {
+				b.Publisher = p.Name
+				st.saveBook(b)
+			}
+		}
+		return nil
+	})
+}
+
+// DeletePublisher deletes a publisher without books.
+func (s *Storage) DeletePublisher(ctx context.Context, id int) error {
+	return s.write(func(st *state) error {
+		// soft deleted books still reference the publisher
+		for _, b := range st.books {
+			if b.PublisherID == id {
+				return models.ErrConflict{Message: "publisher has books"}
+			}
+		}
+		if _, ok := st.publishers[id]; !ok {
+			return models.ErrNotFound{Message: "publisher does not exist"}
+		}
+		delete(st.publishers, id)
+		return nil
+	})
+}
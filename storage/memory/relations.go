@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libreria/models"
+)
+
+// resolveBookRelations loads the authors and the publisher referenced by id
+// and finds or creates the ones given by name only. The names are copied to
+// the author and publisher of the book.
+func (st *state) resolveBookRelations(b *models.Book) error {
+	authors := make([]models.Author, 0, len(b.Authors))
+	names := make([]string, 0, len(b.Authors))
+	seen := make(map[int]bool, len(b.Authors))
+	for _, a := range b.Authors {
+		if a.ID != 0 {
+			stored, ok := st.authors[a.ID]
+			if !ok {
+				return models.ErrBadRequest{Message: fmt.Sprintf("author %d does not exist", a.ID)}
+			}
+			a = stored
+		} else {
+			a = st.upsertAuthor(a.Name)
+		}
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		authors = append(authors, a)
+		names = append(names, a.Name)
+	}
+	b.Authors = authors
+	b.Author = strings.Join(names, ", ")
+
+	p := models.Publisher{ID: b.PublisherID, Name: b.Publisher}
+	if p.ID != 0 {
+		stored, ok := st.publishers[p.ID]
+		if !ok {
+			return models.ErrBadRequest{Message: fmt.Sprintf("publisher %d does not exist", p.ID)}
+		}
+		p = stored
+	} else {
+		p = st.upsertPublisher(p.Name)
+	}
+	b.PublisherID = p.ID
+	b.Publisher = p.Name
+	return nil
+}
+
+// upsertAuthor returns the author with the name in any case, created if
+// there is none. Like an upsert it takes an id even if the author exists.
+func (st *state) upsertAuthor(name string) models.Author {
+	id := st.next("authors")
+	if a, ok := st.authorByName(name); ok {
+		return a
+	}
+	t := now()
+	a := models.Author{ID: id, Name: name, CreatedAt: &t, UpdatedAt: &t}
+	st.authors[id] = a
+	return a
+}
+
+func (st *state) authorByName(name string) (models.Author, bool) {
+	for _, a := range st.authors {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return models.Author{}, false
+}
+
+// upsertPublisher is upsertAuthor for publishers.
+func (st *state) upsertPublisher(name string) models.Publisher {
+	id := st.next("publishers")
+	if p, ok := st.publisherByName(name); ok {
+		return p
+	}
+	t := now()
+	p := models.Publisher{ID: id, Name: name, CreatedAt: &t, UpdatedAt: &t}
+	st.publishers[id] = p
+	return p
+}
+
+func (st *state) publisherByName(name string) (models.Publisher, bool) {
+	for _, p := range st.publishers {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return models.Publisher{}, false
+}
+
+// linkBookAuthors replaces the author links of the book by its authors.
+func (st *state) linkBookAuthors(b *models.Book) {
+	if len(b.Authors) == 0 {
+		delete(st.bookAuthors, b.ID)
+		return
+	}
+	ids := make([]int, len(b.Authors))
+	for i, a := range b.Authors {
+		ids[i] = a.ID
+	}
+	st.bookAuthors[b.ID] = ids
+}
+
+// loadBookAuthors fills the authors of the book in their order.
+func (st *state) loadBookAuthors(b *models.Book) {
+	b.Authors = []models.Author{}
+	for _, id := range st.bookAuthors[b.ID] {
+		b.Authors = append(b.Authors, st.authors[id])
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// RestoreBook undoes the soft deletion of the book.
+func (s *Storage) RestoreBook(ctx context.Context, id int) error {
+	return s.write(func(st *state) error {
+		before, err := st.lockDeletedBook(id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return models.ErrConflict{Message: "book is not deleted"}
+		}
+		b := st.books[id]
+		if b.ISBN != "" && st.isbnTaken(b.ISBN, id) {
+			return models.ErrConflict{Message: "book with this isbn already exists"}
+		}
+		b.DeletedAt = nil
+		st.saveBook(b)
+		after, err := st.lockBook(id)
+		if err != nil {
+			return err
+		}
+		return st.auditBook(ctx, models.AuditRestore, id, before, after)
+	})
+}
+
+// PurgeBook permanently deletes the book, deleted or not, with its copies,
+// loans, fines, holds, ratings and author links if it is still at version.
+// The audit log of the book is kept.
+func (s *Storage) PurgeBook(ctx context.Context, id, version int) error {
+	return s.write(func(st *state) error {
+		return st.purgeBook(ctx, id, version)
+	})
+}
+
+// PurgeDeletedBooks permanently deletes the books soft-deleted before
+// deletedBefore. Books that cannot be purged yet are skipped.
+func (s *Storage) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var ids []int
+	_ = s.read(func(st *state) error {
+		for _, b := range sorted(st.books) {
+			if b.DeletedAt != nil && b.DeletedAt.Before(deletedBefore) {
+				ids = append(ids, b.ID)
+			}
+		}
+		return nil
+	})
+	var n int
+	for _, id := range ids {
+		err := s.write(func(st *state) error {
+			return st.purgeBook(ctx, id, 0)
+		})
+		var conflict models.ErrConflict
+		if errors.As(err, &conflict) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (st *state) purgeBook(ctx context.Context, id, version int) error {
+	before, err := st.lockDeletedBook(id)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(before, version)
+	if err != nil {
+		return err
+	}
+	for _, l := range st.loans {
+		if l.BookID == id && l.ReturnedAt == nil {
+			return models.ErrConflict{Message: "book has open loans"}
+		}
+	}
+	for _, f := range st.fines {
+		if st.loans[f.LoanID].BookID == id && f.Status == models.FineOpen {
+			return models.ErrConflict{Message: "book has unsettled fines"}
+		}
+	}
+	for fid, f := range st.fines {
+		if st.loans[f.LoanID].BookID == id {
+			delete(st.fines, fid)
+		}
+	}
+	deleteRows(st.holds, func(h models.Hold) bool { return h.BookID == id })
+	deleteRows(st.loans, func(l models.Loan) bool { return l.BookID == id })
+	deleteRows(st.copies, func(c models.Copy) bool { return c.BookID == id })
+	deleteRows(st.ratings, func(r models.Rating) bool { return r.BookID == id })
+	delete(st.bookAuthors, id)
+	delete(st.books, id)
+	return st.auditBook(ctx, models.AuditPurge, id, before, nil)
+}
+
+// deleteRows deletes the rows of a table that match.
+func deleteRows[K comparable, V any](m map[K]V, match func(V) bool) {
+	for k, v := range m {
+		if match(v) {
+			delete(m, k)
+		}
+	}
+}
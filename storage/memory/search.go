@@ -0,0 +1,330 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func (s *Storage) GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error) {
+	keys, err := bookSortKeys(bs, p.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if p.After != nil && len(p.After.Values) != len(keys) {
+		return nil, models.ErrBadRequest{Message: "cursor does not match sort"}
+	}
+	var res models.BookList
+	err = s.read(func(st *state) error {
+		books := st.searchBooks(bs)
+		if p.WithTotal {
+			res.Total = len(books)
+		}
+		sort.SliceStable(books, func(i, j int) bool {
+			return compareBooks(keys, books[i].values(keys), books[i].ID, books[j].values(keys), books[j].ID) < 0
+		})
+		if p.After != nil {
+			after := make([]interface{}, len(keys))
+			for i, k := range keys {
+				after[i] = k.cursorValue(p.After.Values[i])
+			}
+			var rest []rankedBook
+			for _, b := range books {
+				if compareBooks(keys, b.values(keys), b.ID, after, p.After.ID) > 0 {
+					rest = append(rest, b)
+				}
+			}
+			books = rest
+		} else {
+			books = page(books, 0, p.Offset)
+		}
+		if len(books) > p.Limit {
+			books = books[:p.Limit]
+			last := books[len(books)-1]
+			res.Next = &models.Cursor{ID: last.ID}
+			for i, sf := range p.Sort {
+				if sf.Field == models.SortRelevance {
+					res.Next.Values = append(res.Next.Values, last.rank)
+				} else {
+					res.Next.Values = append(res.Next.Values, last.values(keys)[i])
+				}
+			}
+		}
+		res.Books = make([]models.Book, len(books))
+		for i, b := range books {
+			st.loadBookAuthors(&b.Book)
+			res.Books[i] = b.Book
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// rankedBook is a book found by a search with the rank of its match of the
+// full-text query.
+type rankedBook struct {
+	models.Book
+	rank float64
+}
+
+func (b *rankedBook) values(keys []sortKey) []interface{} {
+	res := make([]interface{}, len(keys))
+	for i, k := range keys {
+		if k.field == models.SortRelevance {
+			res[i] = b.rank
+		} else {
+			res[i] = b.SortValue(k.field)
+		}
+	}
+	return res
+}
+
+// searchBooks returns the books matching the search in the order of their ids.
+func (st *state) searchBooks(bs *models.BookSearch) []rankedBook {
+	var q textsearch.Query
+	if bs != nil && bs.Query != "" {
+		q = textsearch.ParseQuery(bs.Query)
+	}
+	var res []rankedBook
+	for _, b := range sorted(st.books) {
+		if bs == nil {
+			if b.DeletedAt == nil {
+				res = append(res, rankedBook{Book: b})
+			}
+			continue
+		}
+		if !st.bookMatches(&b, bs) {
+			continue
+		}
+		rb := rankedBook{Book: b}
+		if bs.Query != "" {
+			fields := [][]string{textsearch.Tokens(b.Title), textsearch.Tokens(b.Author), textsearch.Tokens(b.Publisher)}
+			if !q.Matches(fields) {
+				continue
+			}
+			rb.rank = rank(q, fields)
+		}
+		res = append(res, rb)
+	}
+	return res
+}
+
+func (st *state) bookMatches(b *models.Book, bs *models.BookSearch) bool {
+	switch bs.Deleted {
+	case models.DeletedExclude:
+		if b.DeletedAt != nil {
+			return false
+		}
+	case models.DeletedOnly:
+		if b.DeletedAt == nil {
+			return false
+		}
+	}
+	// field filters ignore case and accents
+	if !strings.Contains(textsearch.Fold(b.Title), textsearch.Fold(bs.Title)) ||
+		!strings.Contains(textsearch.Fold(b.Author), textsearch.Fold(bs.Author)) ||
+		!strings.Contains(textsearch.Fold(b.Publisher), textsearch.Fold(bs.Publisher)) {
+		return false
+	}
+	if bs.Status != nil && b.Status != *bs.Status {
+		return false
+	}
+	if pds := bs.PublishDateSearch; pds != nil {
+		d, err := time.Parse("2006-01-02", pds.PublishDate)
+		if err != nil || b.PublishDate.IsZero() || !compareOp(compareTimes(b.PublishDate, d), pds.Condition) {
+			return false
+		}
+	}
+	if bs.AuthorID != nil && !st.hasAuthor(b.ID, *bs.AuthorID) {
+		return false
+	}
+	return bs.PublisherID == nil || b.PublisherID == *bs.PublisherID
+}
+
+// compareOp applies one of the operators of models.FilterMap to the result of a comparison.
+func compareOp(c int, op string) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "<>":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// bookSortFields are the fields books can be sorted by besides the relevance.
+var bookSortFields = map[string]bool{
+	"id":           true,
+	"title":        true,
+	"author":       true,
+	"publisher":    true,
+	"publish_date": true,
+	"rating":       true,
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+func bookSortKeys(bs *models.BookSearch, sort []models.SortField) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(sort))
+	for _, sf := range sort {
+		if sf.Field == models.SortRelevance {
+			if bs == nil || bs.Query == "" {
+				return nil, models.ErrBadRequest{Message: "sorting by relevance requires a search query"}
+			}
+		} else if !bookSortFields[sf.Field] {
+			return nil, models.ErrBadRequest{Message: "unknown sort field " + sf.Field}
+		}
+		keys = append(keys, sortKey{field: sf.Field, desc: sf.Desc})
+	}
+	return keys, nil
+}
+
+// cursorValue converts a value of a cursor, which may have been through JSON,
+// to the type of the key.
+func (k sortKey) cursorValue(v interface{}) interface{} {
+	switch k.field {
+	case "id":
+		if f, ok := v.(float64); ok {
+			return int(f)
+		}
+	case "publish_date":
+		if s, ok := v.(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err == nil {
+				return t
+			}
+		}
+	case "rating", models.SortRelevance:
+		if n, ok := v.(json.Number); ok {
+			f, err := n.Float64()
+			if err == nil {
+				return f
+			}
+		}
+	}
+	return v
+}
+
+// compareBooks compares two books by their values of the sort keys, then by
+// their ids.
+func compareBooks(keys []sortKey, a []interface{}, aID int, b []interface{}, bID int) int {
+	for i, k := range keys {
+		c := compareValues(a[i], b[i])
+		if k.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(aID, bID)
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return compareStrings(a, b)
+	case float64:
+		b, _ := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case int:
+		b, _ := b.(int)
+		return compareInts(a, b)
+	case time.Time:
+		b, _ := b.(time.Time)
+		return compareTimes(a, b)
+	}
+	return 0
+}
+
+// compareStrings orders strings roughly like a linguistic collation: by
+// their letters ignoring case and accents first.
+func compareStrings(a, b string) int {
+	if c := strings.Compare(textsearch.Fold(a), textsearch.Fold(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// sortByName orders rows by name like compareStrings, then by id.
+func sortByName[V any](rows []V, key func(V) (string, int)) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		ni, idi := key(rows[i])
+		nj, idj := key(rows[j])
+		if c := compareStrings(ni, nj); c != 0 {
+			return c < 0
+		}
+		return idi < idj
+	})
+}
+
+// fieldWeights are the weights of the title, author and publisher in the rank
+// of a match, the default weights of ts_rank for the labels A, B and C.
+var fieldWeights = []float64{1.0, 0.4, 0.2}
+
+// rank approximates ts_rank: the weighted count of the occurrences of the
+// words of the query relative to the length of the fields.
+func rank(q textsearch.Query, fields [][]string) float64 {
+	var (
+		score float64
+		total int
+	)
+	for i, words := range fields {
+		total += len(words)
+		for _, w := range words {
+			if q.HasWord(w) {
+				score += fieldWeights[i]
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return score / float64(total)
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/libreria/models"
+)
+
+// GetBookHistory returns the audit entries of the book in the order they were made.
+func (s *Storage) GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error) {
+	var res []models.AuditEntry
+	err := s.conn().ModelContext(ctx, &res).
+		Where("book_id = ?", bookID).
+		Order("id").Limit(limit).Offset(offset).
+		Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// lockBook selects the book with its authors and locks it until the end of the transaction.
+func lockBook(ctx context.Context, db orm.DB, id int) (*models.Book, error) {
+	return lockBookQuery(ctx, db, id, false)
+}
+
+// lockDeletedBook is lockBook that finds soft-deleted books too.
+func lockDeletedBook(ctx context.Context, db orm.DB, id int) (*models.Book, error) {
+	return lockBookQuery(ctx, db, id, true)
+}
+
+func lockBookQuery(ctx context.Context, db orm.DB, id int, withDeleted bool) (*models.Book, error) {
+	var b models.Book
+	q := db.ModelContext(ctx, &b).Where("id = ?", id).For("UPDATE")
+	if withDeleted {
+		q = q.AllWithDeleted()
+	}
+	err := q.Select()
+	if errors.Is(err, pg.ErrNoRows) {
+		return nil, models.ErrNotFound{Message: "book does not exist"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = loadBookAuthors(ctx, db, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// auditBook records the mutation of a book by the caller of ctx. before and
+// after are the book before and after the mutation, nil if it did not exist.
+func auditBook(ctx context.Context, db orm.DB, action models.AuditAction, bookID int, before, after *models.Book) error {
+	e := &models.AuditEntry{
+		BookID: bookID,
+		Actor:  models.ActorFromContext(ctx),
+		Action: action,
+	}
+	var err error
+	e.Before, e.After, err = models.BookDiff(before, after)
+	if err != nil {
+		return err
+	}
+	_, err = db.ModelContext(ctx, e).Insert()
+	return err
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+func (s *Storage) CreateAuthor(ctx context.Context, a *models.Author) error {
+	_, err := s.conn().ModelContext(ctx, a).Insert()
+	return toServiceError(err)
+}
+
+func (s *Storage) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	var res models.Author
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error) {
+	var res []models.Author
+	q := s.conn().ModelContext(ctx, &res).Order("name", "id").Limit(limit).Offset(offset)
+	if name != "" {
+		q = q.Where("unaccent_immutable(name) ILIKE unaccent_immutable(?)", containsPattern(name))
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdateAuthor renames the author and the author names stored with the author's books.
+func (s *Storage) UpdateAuthor(ctx context.Context, a *models.Author) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, a).WherePK().
+			Set("name = ?name").
+			Set("updated_at = now()").
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return models.ErrNotFound{Message: "author does not exist"}
+		}
+		_, err = tx.ExecContext(ctx, `
+UPDATE books
+SET author = (SELECT string_agg(authors.name, ', ' ORDER BY book_authors.position)
+              FROM book_authors
+                       JOIN authors ON authors.id = book_authors.author_id
+              WHERE book_authors.book_id = books.id)
+WHERE id IN (SELECT book_id FROM book_authors WHERE author_id = ?)`, a.ID)
+		return err
+	})
+	return toServiceError(err)
+}
+
+// DeleteAuthor deletes an author without books.
+func (s *Storage) DeleteAuthor(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		hasBooks, err := tx.ModelContext(ctx, (*models.BookAuthor)(nil)).Where("author_id = ?", id).Exists()
+		if err != nil {
+			return err
+		}
+		if hasBooks {
+			return models.ErrConflict{Message: "author has books"}
+		}
+		res, err := tx.ModelContext(ctx, (*models.Author)(nil)).Where("id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return models.ErrNotFound{Message: "author does not exist"}
+		}
+		return nil
+	})
+	return toServiceError(err)
+}
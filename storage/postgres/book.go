@@ -2,90 +2,228 @@ package postgres
 
 import (
 	"context"
-	"fmt"
 
+	"github.com/go-pg/pg/v10"
 	"github.com/libreria/models"
 )
 
+// CreateBook inserts the book with one copy and links it to its authors and publisher.
+// Every mutation of a book is recorded in its audit log in the same transaction.
 func (s *Storage) CreateBook(ctx context.Context, b *models.Book) error {
-	_, err := s.db.WithContext(ctx).Model(b).Insert()
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		return createBook(ctx, tx, b)
+	})
 	return toServiceError(err)
 }
 
-func (s *Storage) UpdateBook(ctx context.Context, b *models.Book) error {
-	res, err := s.db.WithContext(ctx).Model(b).WherePK().
-		Set("title = ?title").
-		Set("author = ?author").
-		Set("publisher = ?publisher").
-		Set("publish_date = ?publish_date").
-		Update()
+func createBook(ctx context.Context, tx *pg.Tx, b *models.Book) error {
+	err := resolveBookRelations(ctx, tx, b)
 	if err != nil {
-		return toServiceError(err)
+		return err
 	}
-	if res.RowsAffected() == 0 {
-		return models.ErrNotFound{Message: "book does not exist"}
+	_, err = tx.ModelContext(ctx, b).Insert()
+	if err != nil {
+		return err
 	}
-	return nil
-}
-
-func (s *Storage) GetBook(ctx context.Context, id int) (*models.Book, error) {
-	var res models.Book
-	err := s.db.WithContext(ctx).Model(&res).Where("id = ?", id).First()
+	err = linkBookAuthors(ctx, tx, b)
 	if err != nil {
-		return nil, toServiceError(err)
+		return err
 	}
-	return &res, nil
+	// a new book comes with its first copy
+	_, err = tx.ModelContext(ctx, &models.Copy{BookID: b.ID}).Insert()
+	if err != nil {
+		return err
+	}
+	err = refreshBookCopies(ctx, tx, b.ID)
+	if err != nil {
+		return err
+	}
+	err = tx.ModelContext(ctx, b).WherePK().Column("total_copies", "available_copies", "status", "version").Select()
+	if err != nil {
+		return err
+	}
+	after, err := lockBook(ctx, tx, b.ID)
+	if err != nil {
+		return err
+	}
+	return auditBook(ctx, tx, models.AuditCreate, b.ID, nil, after)
 }
 
-func (s *Storage) GetBooks(ctx context.Context, bs *models.BookSearch, limit, offset int) ([]models.Book, error) {
-	var res []models.Book
-	q := s.db.WithContext(ctx).Model(&res).Limit(limit).Offset(offset)
-	if bs != nil {
-		if bs.Title != "" {
-			q = q.Where("title LIKE ?", "%"+bs.Title+"%")
+// UpdateBook writes the columns of the book that differ from the stored ones.
+// An update changing nothing leaves the book, its version and its audit log
+// untouched.
+func (s *Storage) UpdateBook(ctx context.Context, b *models.Book) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		before, err := lockBook(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, b.Version)
+		if err != nil {
+			return err
+		}
+		err = resolveBookRelations(ctx, tx, b)
+		if err != nil {
+			return err
 		}
-		if bs.Author != "" {
-			q = q.Where("author LIKE ?", "%"+bs.Author+"%")
+		columns := changedBookColumns(before, b)
+		authorsChanged := !sameAuthors(before.Authors, b.Authors)
+		if len(columns) == 0 && !authorsChanged {
+			b.Version = before.Version
+			return nil
 		}
-		if bs.Publisher != "" {
-			q = q.Where("publisher LIKE ?", "%"+bs.Publisher+"%")
+		q := tx.ModelContext(ctx, b).WherePK().Set("updated_at = now()")
+		for _, c := range columns {
+			q = q.Set(c)
 		}
-		if bs.Status != nil {
-			q = q.Where("status = ?", *bs.Status)
+		_, err = q.Update()
+		if err != nil {
+			return err
 		}
-		if bs.PublishDateSearch != nil {
-			q = q.Where(fmt.Sprintf("publish_date %s ?::date", bs.PublishDateSearch.Condition), bs.PublishDateSearch.PublishDate)
+		if authorsChanged {
+			err = linkBookAuthors(ctx, tx, b)
+			if err != nil {
+				return err
+			}
+		}
+		after, err := lockBook(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+		b.Version = after.Version
+		return auditBook(ctx, tx, models.AuditUpdate, b.ID, before, after)
+	})
+	return toServiceError(err)
+}
+
+// changedBookColumns returns the assignments of the editable columns of b
+// that differ from the stored book.
+func changedBookColumns(stored, b *models.Book) []string {
+	var res []string
+	if b.Title != stored.Title {
+		res = append(res, "title = ?title")
+	}
+	if b.ISBN != stored.ISBN {
+		res = append(res, "isbn = NULLIF(?isbn, '')")
+	}
+	if b.Author != stored.Author {
+		res = append(res, "author = ?author")
+	}
+	if b.Publisher != stored.Publisher {
+		res = append(res, "publisher = ?publisher")
+	}
+	if b.PublisherID != stored.PublisherID {
+		res = append(res, "publisher_id = ?publisher_id")
+	}
+	if !b.PublishDate.Equal(stored.PublishDate) {
+		res = append(res, "publish_date = ?publish_date")
+	}
+	return res
+}
+
+func sameAuthors(a, b []models.Author) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
 		}
 	}
-	err := q.Select()
+	return true
+}
+
+func (s *Storage) GetBook(ctx context.Context, id int) (*models.Book, error) {
+	var res models.Book
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	err = loadBookAuthors(ctx, s.conn(), &res)
 	if err != nil {
 		return nil, toServiceError(err)
 	}
-	return res, nil
+	return &res, nil
 }
 
-func (s *Storage) UpdateBookStatus(ctx context.Context, id, status int) error {
-	_, err := s.db.WithContext(ctx).Model((*models.Book)(nil)).
-		Set("status = ?", status).Where("id = ?", id).Update()
-	return toServiceError(err)
+func (s *Storage) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	var res models.Book
+	err := s.conn().ModelContext(ctx, &res).Where("isbn = ?", isbn).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	err = loadBookAuthors(ctx, s.conn(), &res)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
 }
 
-func (s *Storage) RateBook(ctx context.Context, id, rate int) error {
-	_, err := s.db.WithContext(ctx).Model((*models.Book)(nil)).Exec(`
+// RateBook stores the user's rating of the book, replacing the previous rating
+// of the same user, and recomputes the book's average rating.
+func (s *Storage) RateBook(ctx context.Context, r *models.Rating) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		// lock the book so concurrent ratings are aggregated one after another
+		before, err := lockBook(ctx, tx, r.BookID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ModelContext(ctx, r).
+			OnConflict("(book_id, user_id) DO UPDATE").
+			Set("rating = EXCLUDED.rating").
+			Set("updated_at = now()").
+			Insert()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ModelContext(ctx, (*models.Book)(nil)).Exec(`
 UPDATE ?TableName
-SET rating = avg_rating
+SET rating       = aggregate.avg_rating,
+    rating_count = aggregate.rating_count,
+    updated_at   = now()
 FROM (
-         SELECT id,
-                CASE
-                    WHEN rating = 0 THEN 3.0
-                    WHEN rating > 0 THEN ROUND(CAST((? + rating) / 2 AS NUMERIC), 2) END avg_rating
-         FROM ?TableName
-         WHERE id = ?) AS subquery
-WHERE books.id = subquery.id`, rate, id)
+         SELECT AVG(rating) avg_rating, COUNT(*) rating_count
+         FROM book_ratings
+         WHERE book_id = ?) AS aggregate
+WHERE books.id = ?`, r.BookID, r.BookID)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, r.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditRate, r.BookID, before, after)
+	})
 	return toServiceError(err)
 }
 
-func (s *Storage) DeleteBook(ctx context.Context, id int) error {
-	_, err := s.db.WithContext(ctx).Model((*models.Book)(nil)).Where("id = ?", id).Delete()
+// DeleteBook soft-deletes the book if it is still at version, any version
+// matches if version is zero.
+func (s *Storage) DeleteBook(ctx context.Context, id, version int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		before, err := lockBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, version)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ModelContext(ctx, (*models.Book)(nil)).Where("id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditDelete, id, before, nil)
+	})
 	return toServiceError(err)
 }
+
+// checkVersion makes sure the book is still at the version the caller read,
+// any version matches if version is zero.
+func checkVersion(b *models.Book, version int) error {
+	if version != 0 && b.Version != version {
+		return models.ErrPreconditionFailed{Message: "book has been modified"}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/libreria/models"
+)
+
+// CreateCopy adds a copy to the book and counts it with the book's copies. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CreateCopy(ctx context.Context, c *models.Copy, holdExpiresAt time.Time) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, c).Returning("*").Insert()
+		if err != nil {
+			return err
+		}
+		err = passCopy(ctx, tx, c.ID, c.BookID, models.StatusCheckedIn, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		return tx.ModelContext(ctx, c).WherePK().Column("status").Select()
+	})
+	return toServiceError(err)
+}
+
+func (s *Storage) GetCopy(ctx context.Context, id int) (*models.Copy, error) {
+	var res models.Copy
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// GetCopies returns all copies of the book including the retired ones.
+func (s *Storage) GetCopies(ctx context.Context, bookID int) ([]models.Copy, error) {
+	var res []models.Copy
+	err := s.conn().ModelContext(ctx, &res).Where("book_id = ?", bookID).Order("id").Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// RetireCopy takes a checked in copy out of circulation.
+func (s *Storage) RetireCopy(ctx context.Context, id int, retiredAt time.Time) (*models.Copy, error) {
+	var res models.Copy
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		r, err := tx.ModelContext(ctx, &res).
+			Set("retired_at = ?", retiredAt).
+			Set("updated_at = now()").
+			Where("id = ?", id).
+			Where("retired_at IS NULL").
+			Where("status = ?", models.StatusCheckedIn).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		if r.RowsAffected() == 0 {
+			return models.ErrConflict{Message: "copy is checked out, on hold or already retired"}
+		}
+		return refreshBookCopies(ctx, tx, res.BookID)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// availableCopy locks a copy of the book that can be checked out. Copies
+// locked by concurrent checkouts are skipped.
+func availableCopy(ctx context.Context, db orm.DB, bookID int) (int, error) {
+	var c models.Copy
+	err := db.ModelContext(ctx, &c).
+		Column("id").
+		Where("book_id = ?", bookID).
+		Where("retired_at IS NULL").
+		Where("status = ?", models.StatusCheckedIn).
+		Order("id").
+		Limit(1).
+		For("UPDATE SKIP LOCKED").
+		Select()
+	if err == pg.ErrNoRows {
+		return 0, models.ErrConflict{Message: "book has no available copy"}
+	}
+	return c.ID, err
+}
+
+// updateCopyStatus moves the copy from one status to another. It fails with
+// a conflict if the copy is not in the expected status any more, e.g. because
+// a concurrent request changed it after the service checked the transition.
+func updateCopyStatus(ctx context.Context, db orm.DB, id int, from, to models.BookStatus) error {
+	res, err := db.ModelContext(ctx, (*models.Copy)(nil)).
+		Set("status = ?", to).
+		Set("updated_at = now()").
+		Where("id = ?", id).
+		Where("status = ?", from).
+		Where("retired_at IS NULL").
+		Update()
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return models.ErrConflict{Message: "copy status has been changed by another request"}
+	}
+	return nil
+}
+
+// refreshBookCopies recomputes the copy counts and the status of the book.
+func refreshBookCopies(ctx context.Context, db orm.DB, bookID int) error {
+	_, err := db.ModelContext(ctx, (*models.Book)(nil)).Exec(`
+UPDATE ?TableName
+SET total_copies     = aggregate.total,
+    available_copies = aggregate.available,
+    status           = CASE WHEN aggregate.available > 0 THEN ? ELSE ? END,
+    updated_at       = now()
+FROM (
+         SELECT COUNT(*) total, COUNT(*) FILTER (WHERE status = ?) available
+         FROM copies
+         WHERE book_id = ?
+           AND retired_at IS NULL) AS aggregate
+WHERE books.id = ?`,
+		models.StatusCheckedIn, models.StatusCheckedOut, models.StatusCheckedIn, bookID, bookID)
+	return err
+}
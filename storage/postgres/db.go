@@ -4,14 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"net/url"
 	"sync"
 	"time"
 
 	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
 	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/libreria/storage/postgres/migrations"
 	log "github.com/sirupsen/logrus"
 )
 
+// Config of the postgres storage. AutoMigrate applies the migrations not
+// applied yet when the storage starts.
 type Config struct {
 	Host         string        `mapstructure:"host"          default:"localhost"`
 	Port         string        `mapstructure:"port"          default:"5432"`
@@ -21,13 +29,123 @@ type Config struct {
 	MaxRetries   int           `mapstructure:"max_retries"   default:"5"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"  default:"10s"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout" default:"10s"`
+	AutoMigrate  bool          `mapstructure:"auto_migrate"  default:"false"`
 }
 
+// ConnString returns the URL of the database, for the migrations.
+func (c Config) ConnString() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Host + ":" + c.Port,
+		Path:     c.Name,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+const (
+	// uniqueViolation is the postgres error code of a unique constraint violation.
+	uniqueViolation = "23505"
+	// serializationFailure and deadlockDetected are the postgres error codes
+	// of transactions that may succeed when retried.
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	// txAttempts is how many times RunInTx tries a transaction.
+	txAttempts = 5
+)
+
+// uniqueConstraints describes violations of unique constraints to the client.
+var uniqueConstraints = map[string]string{
+	"loans_copy_id_open_idx":             "copy is already checked out",
+	"copies_barcode_idx":                 "copy with this barcode already exists",
+	"holds_book_id_patron_id_active_idx": "patron already has a hold on the book",
+	"books_isbn_idx":                     "book with this isbn already exists",
+	"patrons_membership_number_idx":      "patron with this membership number already exists",
+	"authors_name_idx":                   "author already exists",
+	"publishers_name_idx":                "publisher already exists",
+}
+
+// Storage keeps the library in postgres. The storage of a transaction runs
+// all its queries in the transaction, see runInTx.
 type Storage struct {
 	db *pg.DB
+	tx *pg.Tx
+}
+
+// conn returns the transaction of the storage, or the database.
+func (s *Storage) conn() orm.DB {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// runInTx runs fn in the transaction of the storage, or else in a new
+// transaction. Only a new transaction is committed or rolled back.
+func (s *Storage) runInTx(ctx context.Context, fn func(tx *pg.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	return s.db.RunInTransaction(ctx, fn)
+}
+
+// RunInTx runs fn with a storage whose queries all run in one serializable
+// transaction, committed if fn returns nil and rolled back otherwise. The
+// transaction is retried when it fails to serialize with concurrent ones or
+// deadlocks. Within a transaction fn runs in it and is not retried.
+func (s *Storage) RunInTx(ctx context.Context, fn func(tx book.StorageManager) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	return retryTx(ctx, func() error {
+		return s.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+			_, err := tx.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE")
+			if err != nil {
+				return err
+			}
+			return fn(&Storage{db: s.db, tx: tx})
+		})
+	})
+}
+
+// retryTx runs the transaction run until it does not fail with a retryable
+// error, at most txAttempts times. A transaction that keeps failing so is a
+// conflict the client may retry later, other errors of run are service
+// errors already.
+func retryTx(ctx context.Context, run func() error) error {
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		err := run()
+		if !isRetryable(err) {
+			return err
+		}
+		log.WithError(err).Debugf("transaction attempt %d of %d failed", attempt, txAttempts)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return models.ErrConflict{Message: "concurrent update, retry"}
+}
+
+// isRetryable tells whether the transaction failed with an error that may not
+// happen again.
+func isRetryable(err error) bool {
+	var pgErr pg.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	code := pgErr.Field('C')
+	return code == serializationFailure || code == deadlockDetected
 }
 
 func New(globalCtx context.Context, wg *sync.WaitGroup, cfg Config) (*Storage, error) {
+	if cfg.AutoMigrate {
+		err := migrations.Migrate(cfg.ConnString(), io.Discard, "up")
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate: %w", err)
+		}
+		log.Info("db is migrated")
+	}
 	db := pg.Connect(&pg.Options{
 		Addr:         cfg.Host + ":" + cfg.Port,
 		User:         cfg.User,
@@ -63,5 +181,13 @@ func toServiceError(err error) error {
 	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pg.ErrNoRows) {
 		return models.ErrNotFound{}
 	}
+	var pgErr pg.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation {
+		msg, ok := uniqueConstraints[pgErr.Field('n')]
+		if !ok {
+			msg = pgErr.Field('M')
+		}
+		return models.ErrConflict{Message: msg}
+	}
 	return err
 }
@@ -0,0 +1,94 @@
+//go:build unit
+// +build unit
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/libreria/models"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// pgError is a postgres error with the code.
+type pgError string
+
+func (e pgError) Error() string {
+	return "ERROR #" + string(e)
+}
+
+func (e pgError) Field(field byte) string {
+	if field == 'C' {
+		return string(e)
+	}
+	return ""
+}
+
+func (e pgError) IntegrityViolation() bool {
+	return false
+}
+
+func TestIsRetryable(t *testing.T) {
+	a := assert.New(t) // assertion object for comparing values
+
+	a.True(isRetryable(pgError(serializationFailure)))
+	a.True(isRetryable(pgError(deadlockDetected)))
+	a.True(isRetryable(fmt.Errorf("commit: %w", pgError(serializationFailure))))
+	a.False(isRetryable(pgError(uniqueViolation)))
+	a.False(isRetryable(models.ErrConflict{Message: "book is checked out"}))
+	a.False(isRetryable(errors.New("connection refused")))
+	a.False(isRetryable(nil))
+}
+
+func TestRetryTx(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t) // assertion object for comparing values
+	ctx := context.Background()
+
+	t.Run("retried", func(t *testing.T) {
+		attempts := 0
+		err := retryTx(ctx, func() error {
+			attempts++
+			if attempts < 3 {
+				return pgError(deadlockDetected)
+			}
+			return nil
+		})
+		a.NoError(err)
+		a.Equal(3, attempts)
+	})
+	t.Run("exhausted", func(t *testing.T) {
+		attempts := 0
+		err := retryTx(ctx, func() error {
+			attempts++
+			return pgError(serializationFailure)
+		})
+		a.Equal(models.ErrConflict{Message: "concurrent update, retry"}, err)
+		a.Equal(txAttempts, attempts)
+	})
+	t.Run("not_retryable", func(t *testing.T) {
+		attempts := 0
+		err := retryTx(ctx, func() error {
+			attempts++
+			return models.ErrNotFound{Message: "book does not exist"}
+		})
+		a.Equal(models.ErrNotFound{Message: "book does not exist"}, err)
+		a.Equal(1, attempts)
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		attempts := 0
+		err := retryTx(ctx, func() error {
+			attempts++
+			return pgError(serializationFailure)
+		})
+		a.IsType(models.ErrConflict{}, err)
+		a.Equal(1, attempts)
+	})
+}
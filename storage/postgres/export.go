@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+// exportChunkSize is how many books ExportBooks reads at a time.
+const exportChunkSize = 500
+
+// ExportBooks calls fn with every book found by the search in the order of
+// their ids. The books are read in chunks from a snapshot of the catalog, so
+// the export is consistent and never held in memory as a whole.
+func (s *Storage) ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error {
+	err := s.db.RunInTransaction(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ExecContext(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY")
+		if err != nil {
+			return err
+		}
+		lastID := 0
+		for {
+			var books []models.Book
+			q := tx.ModelContext(ctx, &books)
+			applyBookSearch(q, bs)
+			err = q.Where("id > ?", lastID).Order("id").Limit(exportChunkSize).Select()
+			if err != nil {
+				return err
+			}
+			ptrs := make([]*models.Book, len(books))
+			for i := range books {
+				ptrs[i] = &books[i]
+			}
+			err = loadBookAuthors(ctx, tx, ptrs...)
+			if err != nil {
+				return err
+			}
+			for _, b := range ptrs {
+				if err := fn(b); err != nil {
+					return err
+				}
+			}
+			if len(books) < exportChunkSize {
+				return nil
+			}
+			lastID = books[len(books)-1].ID
+		}
+	})
+	return toServiceError(err)
+}
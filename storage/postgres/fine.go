@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+func (s *Storage) GetFine(ctx context.Context, id int) (*models.Fine, error) {
+	var res models.Fine
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error) {
+	var res []models.Fine
+	q := s.conn().ModelContext(ctx, &res).Order("id").Limit(limit).Offset(offset)
+	if fs != nil {
+		if fs.PatronID != nil {
+			q = q.Where("patron_id = ?", *fs.PatronID)
+		}
+		if fs.Status != nil {
+			q = q.Where("status = ?", *fs.Status)
+		}
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// SettleFine pays or waives an open fine. A fine can only be paid once its
+// loan is returned and the fine stopped accruing.
+func (s *Storage) SettleFine(ctx context.Context, id int, status models.FineStatus, settledAt time.Time) (*models.Fine, error) {
+	var res models.Fine
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		err := tx.ModelContext(ctx, &res).Where("id = ?", id).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+		if res.Status != models.FineOpen {
+			return models.ErrConflict{Message: "fine is already settled"}
+		}
+		if status == models.FinePaid {
+			accruing, err := tx.ModelContext(ctx, (*models.Loan)(nil)).
+				Where("id = ?", res.LoanID).
+				WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+					return q.Where("returned_at IS NULL").WhereOr("returned_at > ?", res.AccruedUntil), nil
+				}).
+				Exists()
+			if err != nil {
+				return err
+			}
+			if accruing {
+				return models.ErrConflict{Message: "fine is still accruing, return the book first"}
+			}
+		}
+		_, err = tx.ModelContext(ctx, &res).
+			Set("status = ?", status).
+			Set("settled_at = ?", settledAt).
+			Set("updated_at = now()").
+			WherePK().
+			Returning("*").
+			Update()
+		return err
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// MarkOverdueLoans marks the open loans that are past their due date at now.
+func (s *Storage) MarkOverdueLoans(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.conn().ModelContext(ctx, (*models.Loan)(nil)).
+		Set("overdue_at = ?", now).
+		Where("returned_at IS NULL").
+		Where("overdue_at IS NULL").
+		Where("due_at < ?", now).
+		Update()
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// AccrueFines creates or updates the open fines of the loans that are overdue
+// at now, or were returned late after their fine was last accrued. It returns
+// the number of created or updated fines.
+func (s *Storage) AccrueFines(ctx context.Context, now time.Time, p models.FinePolicy) (int, error) {
+	// LEAST ignores a NULL maximum
+	var maxAmount *int
+	if p.MaxAmount > 0 {
+		maxAmount = &p.MaxAmount
+	}
+	res, err := s.conn().ModelContext(ctx, (*models.Fine)(nil)).Exec(`
+INSERT INTO ?TableName (loan_id, patron_id, amount, accrued_until)
+SELECT loans.id,
+       loans.borrower_id,
+       LEAST(?2::INTEGER, ?3 * CEIL(EXTRACT(EPOCH FROM COALESCE(loans.returned_at, ?0) - loans.due_at - ?1 * INTERVAL '1 second') / 86400))::INTEGER,
+       COALESCE(loans.returned_at, ?0)
+FROM loans
+         LEFT JOIN fines ON fines.loan_id = loans.id
+WHERE loans.due_at + ?1 * INTERVAL '1 second' < COALESCE(loans.returned_at, ?0)
+  AND (fines.id IS NULL OR (fines.status = ?4 AND fines.accrued_until < COALESCE(loans.returned_at, ?0)))
+ON CONFLICT (loan_id) DO UPDATE
+    SET amount        = EXCLUDED.amount,
+        accrued_until = EXCLUDED.accrued_until,
+        updated_at    = now()
+    WHERE fines.amount <> EXCLUDED.amount
+       OR fines.accrued_until <> EXCLUDED.accrued_until`,
+		now, p.GracePeriod.Seconds(), maxAmount, p.DailyRate, models.FineOpen)
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/libreria/models"
+)
+
+func (s *Storage) CreateHold(ctx context.Context, h *models.Hold) error {
+	_, err := s.conn().ModelContext(ctx, h).Returning("*").Insert()
+	return toServiceError(err)
+}
+
+func (s *Storage) GetHold(ctx context.Context, id int) (*models.Hold, error) {
+	var res models.Hold
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// GetHolds returns the holds in the order they were placed.
+func (s *Storage) GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error) {
+	var res []models.Hold
+	q := s.conn().ModelContext(ctx, &res).Order("id").Limit(limit).Offset(offset)
+	if hs != nil {
+		if hs.BookID != nil {
+			q = q.Where("book_id = ?", *hs.BookID)
+		}
+		if hs.PatronID != nil {
+			q = q.Where("patron_id = ?", *hs.PatronID)
+		}
+		if hs.CopyID != nil {
+			q = q.Where("copy_id = ?", *hs.CopyID)
+		}
+		if hs.Status != nil {
+			q = q.Where("status = ?", *hs.Status)
+		}
+		if hs.Active != nil {
+			if *hs.Active {
+				q = q.Where("status IN (?, ?)", models.HoldWaiting, models.HoldReady)
+			} else {
+				q = q.Where("status NOT IN (?, ?)", models.HoldWaiting, models.HoldReady)
+			}
+		}
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// CancelHold cancels an active hold. A copy kept for the hold goes to the next
+// waiting hold, which is ready until expiresAt, or becomes available.
+func (s *Storage) CancelHold(ctx context.Context, id int, expiresAt time.Time) (*models.Hold, error) {
+	var res models.Hold
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		r, err := tx.ModelContext(ctx, &res).
+			Set("status = ?", models.HoldCancelled).
+			Set("updated_at = now()").
+			Where("id = ?", id).
+			Where("status IN (?, ?)", models.HoldWaiting, models.HoldReady).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		if r.RowsAffected() == 0 {
+			return models.ErrConflict{Message: "hold is not active"}
+		}
+		if res.CopyID == nil {
+			return nil
+		}
+		return passCopy(ctx, tx, *res.CopyID, res.BookID, models.StatusOnHold, expiresAt)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// ExpireHolds expires the ready holds not picked up by now and passes their
+// copies on like CancelHold does. It returns the number of expired holds.
+func (s *Storage) ExpireHolds(ctx context.Context, now, expiresAt time.Time) (int, error) {
+	var expired []models.Hold
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		_, err := tx.ModelContext(ctx, &expired).
+			Set("status = ?", models.HoldExpired).
+			Set("updated_at = now()").
+			Where("status = ?", models.HoldReady).
+			Where("expires_at < ?", now).
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		for _, h := range expired {
+			err = passCopy(ctx, tx, *h.CopyID, h.BookID, models.StatusOnHold, expiresAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return len(expired), nil
+}
+
+// passCopy gives a copy that became free to the first waiting hold of the book,
+// the hold is ready until expiresAt. Without waiting holds the copy becomes
+// available.
+func passCopy(ctx context.Context, db orm.DB, copyID, bookID int, from models.BookStatus, expiresAt time.Time) error {
+	res, err := db.ModelContext(ctx, (*models.Hold)(nil)).Exec(`
+UPDATE ?TableName
+SET status     = ?,
+    copy_id    = ?,
+    ready_at   = now(),
+    expires_at = ?,
+    updated_at = now()
+WHERE id = (SELECT id
+            FROM holds
+            WHERE book_id = ?
+              AND status = ?
+            ORDER BY id
+            LIMIT 1 FOR UPDATE SKIP LOCKED)`,
+		models.HoldReady, copyID, expiresAt, bookID, models.HoldWaiting)
+	if err != nil {
+		return err
+	}
+	to := models.StatusCheckedIn
+	if res.RowsAffected() > 0 {
+		to = models.StatusOnHold
+	}
+	if from != to {
+		err = updateCopyStatus(ctx, db, copyID, from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return refreshBookCopies(ctx, db, bookID)
+}
+
+// fulfillHold closes the ready hold of the borrower kept with the copy of the
+// loan. It returns the status the copy has to be checked out from.
+func fulfillHold(ctx context.Context, db orm.DB, l *models.Loan) (models.BookStatus, error) {
+	res, err := db.ModelContext(ctx, (*models.Hold)(nil)).
+		Set("status = ?", models.HoldFulfilled).
+		Set("updated_at = now()").
+		Where("copy_id = ?", l.CopyID).
+		Where("patron_id = ?", l.BorrowerID).
+		Where("status = ?", models.HoldReady).
+		Update()
+	if err != nil {
+		return 0, err
+	}
+	if res.RowsAffected() > 0 {
+		return models.StatusOnHold, nil
+	}
+	return models.StatusCheckedIn, nil
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+// errDryRun rolls back the transaction of a dry run.
+var errDryRun = errors.New("dry run")
+
+// ImportBooks creates the books in one transaction, each in a savepoint so a
+// duplicate or an invalid book is reported without failing the others. A dry
+// run reports the same outcomes and rolls the transaction back.
+func (s *Storage) ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error) {
+	res := make([]models.ImportResult, len(books))
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		for i, b := range books {
+			_, err := tx.ExecContext(ctx, "SAVEPOINT import_book")
+			if err != nil {
+				return err
+			}
+			err = createBook(ctx, tx, b)
+			if err == nil {
+				res[i] = models.ImportResult{Status: models.ImportCreated, BookID: b.ID}
+				_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT import_book")
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			_, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_book")
+			if rbErr != nil {
+				return rbErr
+			}
+			switch e := toServiceError(err).(type) {
+			case models.ErrConflict:
+				res[i] = models.ImportResult{Status: models.ImportDuplicate, Message: e.Message}
+			case models.ErrBadRequest:
+				res[i] = models.ImportResult{Status: models.ImportRejected, Message: e.Message}
+			default:
+				return err
+			}
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err == errDryRun {
+		// the books of a dry run were never created
+		for i := range res {
+			res[i].BookID = 0
+		}
+		return res, nil
+	}
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+// CreateLoan opens a loan and checks the copy out in a single transaction.
+// A loan without a copy is made on any available copy of the book, a copy
+// kept for a hold of the borrower fulfills the hold.
+func (s *Storage) CreateLoan(ctx context.Context, l *models.Loan) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		before, err := lockBook(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		if l.CopyID == 0 {
+			l.CopyID, err = availableCopy(ctx, tx, l.BookID)
+			if err != nil {
+				return err
+			}
+		}
+		from, err := fulfillHold(ctx, tx, l)
+		if err != nil {
+			return err
+		}
+		err = updateCopyStatus(ctx, tx, l.CopyID, from, models.StatusCheckedOut)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ModelContext(ctx, l).Insert()
+		if err != nil {
+			return err
+		}
+		err = refreshBookCopies(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditCheckout, l.BookID, before, after)
+	})
+	return toServiceError(err)
+}
+
+// CloseLoan closes the open loan of the copy and checks the copy back in. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CloseLoan(ctx context.Context, copyID int, returnedAt, holdExpiresAt time.Time) (*models.Loan, error) {
+	var res models.Loan
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		r, err := tx.ModelContext(ctx, &res).
+			Set("returned_at = ?", returnedAt).
+			Where("copy_id = ?", copyID).
+			Where("returned_at IS NULL").
+			Returning("*").
+			Update()
+		if err != nil {
+			return err
+		}
+		if r.RowsAffected() == 0 {
+			return models.ErrConflict{Message: "copy has no open loan"}
+		}
+		before, err := lockBook(ctx, tx, res.BookID)
+		if _, deleted := err.(models.ErrNotFound); deleted {
+			// copies of deleted books are still returned, there is nothing to audit
+			return passCopy(ctx, tx, copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		}
+		if err != nil {
+			return err
+		}
+		err = passCopy(ctx, tx, copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, res.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditCheckin, res.BookID, before, after)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error) {
+	var res []models.Loan
+	q := s.conn().ModelContext(ctx, &res).Order("id").Limit(limit).Offset(offset)
+	if ls != nil {
+		if ls.BookID != nil {
+			q = q.Where("book_id = ?", *ls.BookID)
+		}
+		if ls.BorrowerID != nil {
+			q = q.Where("borrower_id = ?", *ls.BorrowerID)
+		}
+		if ls.Open != nil {
+			if *ls.Open {
+				q = q.Where("returned_at IS NULL")
+			} else {
+				q = q.Where("returned_at IS NOT NULL")
+			}
+		}
+		if ls.Overdue {
+			q = q.Where("returned_at IS NULL").Where("due_at < now()")
+		}
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,17 @@
+CREATE TABLE loans
+(
+    id             SERIAL                    NOT NULL
+        CONSTRAINT loans_pkey
+            PRIMARY KEY,
+    book_id        INTEGER                   NOT NULL
+        CONSTRAINT loans_book_id_fkey
+            REFERENCES books,
+    borrower_id    INTEGER                   NOT NULL,
+    checked_out_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    due_at         TIMESTAMPTZ               NOT NULL,
+    returned_at    TIMESTAMPTZ
+);
+
+-- a book can have at most one open loan at a time
+CREATE UNIQUE INDEX loans_book_id_open_idx ON loans (book_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_borrower_id_idx ON loans (borrower_id);
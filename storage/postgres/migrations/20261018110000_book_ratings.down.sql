@@ -0,0 +1,3 @@
+ALTER TABLE books
+    DROP COLUMN rating_count;
+DROP TABLE book_ratings;
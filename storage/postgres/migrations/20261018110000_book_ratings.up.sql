@@ -0,0 +1,19 @@
+CREATE TABLE book_ratings
+(
+    book_id    INTEGER                 NOT NULL
+        CONSTRAINT book_ratings_book_id_fkey
+            REFERENCES books,
+    user_id    TEXT                    NOT NULL,
+    rating     INTEGER                 NOT NULL
+        CONSTRAINT book_ratings_rating_check
+            CHECK (rating BETWEEN 1 AND 3),
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    CONSTRAINT book_ratings_pkey
+        PRIMARY KEY (book_id, user_id)
+);
+
+-- the old running average cannot be split into per-user ratings, so it is
+-- kept as is until the first rating recomputes it from book_ratings
+ALTER TABLE books
+    ADD COLUMN rating_count INTEGER DEFAULT 0 NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE books
+    DROP COLUMN search_vector;
+DROP FUNCTION unaccent_immutable(TEXT);
+DROP EXTENSION IF EXISTS unaccent;
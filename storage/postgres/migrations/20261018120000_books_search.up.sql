@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent is only STABLE because its dictionary can be changed, pinning the
+-- dictionary makes it usable in generated columns and indexes
+CREATE FUNCTION unaccent_immutable(TEXT) RETURNS TEXT
+    LANGUAGE SQL
+    IMMUTABLE
+    PARALLEL SAFE
+    STRICT
+AS
+$$
+SELECT public.unaccent('public.unaccent', $1)
+$$;
+
+-- the 'simple' configuration does not stem words, so names of authors and
+-- publishers are matched as they are written
+ALTER TABLE books
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+                setweight(to_tsvector('simple', unaccent_immutable(title)), 'A') ||
+                setweight(to_tsvector('simple', unaccent_immutable(author)), 'B') ||
+                setweight(to_tsvector('simple', unaccent_immutable(publisher)), 'C')
+        ) STORED;
+
+CREATE INDEX books_search_vector_idx ON books USING GIN (search_vector);
@@ -0,0 +1,62 @@
+CREATE TABLE authors
+(
+    id         SERIAL                  NOT NULL
+        CONSTRAINT authors_pkey
+            PRIMARY KEY,
+    name       TEXT                    NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+CREATE UNIQUE INDEX authors_name_idx ON authors (LOWER(name));
+
+CREATE TABLE publishers
+(
+    id         SERIAL                  NOT NULL
+        CONSTRAINT publishers_pkey
+            PRIMARY KEY,
+    name       TEXT                    NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+CREATE UNIQUE INDEX publishers_name_idx ON publishers (LOWER(name));
+
+CREATE TABLE book_authors
+(
+    book_id   INTEGER           NOT NULL
+        CONSTRAINT book_authors_book_id_fkey
+            REFERENCES books,
+    author_id INTEGER           NOT NULL
+        CONSTRAINT book_authors_author_id_fkey
+            REFERENCES authors,
+    position  INTEGER DEFAULT 0 NOT NULL,
+    CONSTRAINT book_authors_pkey
+        PRIMARY KEY (book_id, author_id)
+);
+CREATE INDEX book_authors_author_id_idx ON book_authors (author_id);
+
+ALTER TABLE books
+    ADD COLUMN publisher_id INTEGER
+        CONSTRAINT books_publisher_id_fkey
+            REFERENCES publishers;
+CREATE INDEX books_publisher_id_idx ON books (publisher_id);
+
+-- move the free text names into the new tables, names differing only by case are merged
+INSERT INTO authors (name)
+SELECT DISTINCT ON (LOWER(author)) author
+FROM books
+ORDER BY LOWER(author), author;
+
+INSERT INTO book_authors (book_id, author_id)
+SELECT books.id, authors.id
+FROM books
+         JOIN authors ON LOWER(authors.name) = LOWER(books.author);
+
+INSERT INTO publishers (name)
+SELECT DISTINCT ON (LOWER(publisher)) publisher
+FROM books
+ORDER BY LOWER(publisher), publisher;
+
+UPDATE books
+SET publisher_id = publishers.id
+FROM publishers
+WHERE LOWER(publishers.name) = LOWER(books.publisher);
@@ -0,0 +1,4 @@
+ALTER TABLE books
+    ADD COLUMN isbn TEXT;
+-- deleted books do not hold their isbn so the book can be added again
+CREATE UNIQUE INDEX books_isbn_idx ON books (isbn) WHERE deleted_at IS NULL;
@@ -0,0 +1,19 @@
+ALTER TABLE books
+    DROP COLUMN total_copies,
+    DROP COLUMN available_copies;
+
+-- without copies a book has at most one open loan, so the open loans of a book
+-- but its first one are returned
+UPDATE loans
+SET returned_at = NOW()
+WHERE returned_at IS NULL
+  AND id NOT IN (SELECT MIN(id) FROM loans WHERE returned_at IS NULL GROUP BY book_id);
+
+DROP INDEX loans_book_id_idx;
+DROP INDEX loans_copy_id_open_idx;
+ALTER TABLE loans
+    DROP COLUMN copy_id;
+CREATE UNIQUE INDEX loans_book_id_open_idx ON loans (book_id) WHERE returned_at IS NULL;
+
+DROP TABLE copies;
+DROP SEQUENCE copies_barcode_seq;
@@ -0,0 +1,51 @@
+CREATE SEQUENCE copies_barcode_seq;
+
+CREATE TABLE copies
+(
+    id         SERIAL                  NOT NULL
+        CONSTRAINT copies_pkey
+            PRIMARY KEY,
+    book_id    INTEGER                 NOT NULL
+        CONSTRAINT copies_book_id_fkey
+            REFERENCES books,
+    -- copies added without a barcode get a generated one
+    barcode    TEXT                    NOT NULL
+        DEFAULT 'LIB' || LPAD(NEXTVAL('copies_barcode_seq')::TEXT, 9, '0'),
+    condition  TEXT      DEFAULT 'good' NOT NULL,
+    location   TEXT      DEFAULT ''     NOT NULL,
+    status     INTEGER   DEFAULT 0      NOT NULL,
+    retired_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()  NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW()  NOT NULL
+);
+CREATE UNIQUE INDEX copies_barcode_idx ON copies (barcode);
+CREATE INDEX copies_book_id_idx ON copies (book_id);
+
+-- every existing book becomes a single copy in the status of the book
+INSERT INTO copies (book_id, status)
+SELECT id, COALESCE(status, 0)
+FROM books
+ORDER BY id;
+
+ALTER TABLE loans
+    ADD COLUMN copy_id INTEGER
+        CONSTRAINT loans_copy_id_fkey
+            REFERENCES copies;
+UPDATE loans
+SET copy_id = copies.id
+FROM copies
+WHERE copies.book_id = loans.book_id;
+ALTER TABLE loans
+    ALTER COLUMN copy_id SET NOT NULL;
+
+-- a copy can have at most one open loan at a time
+DROP INDEX loans_book_id_open_idx;
+CREATE UNIQUE INDEX loans_copy_id_open_idx ON loans (copy_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_book_id_idx ON loans (book_id);
+
+ALTER TABLE books
+    ADD COLUMN total_copies     INTEGER DEFAULT 0 NOT NULL,
+    ADD COLUMN available_copies INTEGER DEFAULT 0 NOT NULL;
+UPDATE books
+SET total_copies     = 1,
+    available_copies = CASE WHEN COALESCE(status, 0) = 0 THEN 1 ELSE 0 END;
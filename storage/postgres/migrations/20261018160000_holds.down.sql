@@ -0,0 +1,14 @@
+-- copies kept for holds become available again
+UPDATE copies
+SET status = 0
+WHERE status = 2;
+UPDATE books
+SET available_copies = (SELECT COUNT(*)
+                        FROM copies
+                        WHERE copies.book_id = books.id
+                          AND copies.status = 0
+                          AND copies.retired_at IS NULL);
+UPDATE books
+SET status = CASE WHEN available_copies > 0 THEN 0 ELSE 1 END;
+
+DROP TABLE holds;
@@ -0,0 +1,23 @@
+CREATE TABLE holds
+(
+    id         SERIAL                    NOT NULL
+        CONSTRAINT holds_pkey
+            PRIMARY KEY,
+    book_id    INTEGER                   NOT NULL
+        CONSTRAINT holds_book_id_fkey
+            REFERENCES books,
+    patron_id  INTEGER                   NOT NULL,
+    copy_id    INTEGER
+        CONSTRAINT holds_copy_id_fkey
+            REFERENCES copies,
+    status     INTEGER DEFAULT 0         NOT NULL,
+    ready_at   TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+
+-- a patron can have one waiting or ready hold per book
+CREATE UNIQUE INDEX holds_book_id_patron_id_active_idx ON holds (book_id, patron_id) WHERE status IN (0, 1);
+CREATE INDEX holds_patron_id_idx ON holds (patron_id);
+CREATE INDEX holds_expires_at_idx ON holds (expires_at) WHERE status = 1;
@@ -0,0 +1,4 @@
+DROP TABLE fines;
+
+ALTER TABLE loans
+    DROP COLUMN overdue_at;
@@ -0,0 +1,22 @@
+ALTER TABLE loans
+    ADD COLUMN overdue_at TIMESTAMPTZ;
+
+CREATE TABLE fines
+(
+    id            SERIAL                    NOT NULL
+        CONSTRAINT fines_pkey
+            PRIMARY KEY,
+    loan_id       INTEGER                   NOT NULL
+        CONSTRAINT fines_loan_id_fkey
+            REFERENCES loans,
+    patron_id     INTEGER                   NOT NULL,
+    amount        INTEGER DEFAULT 0         NOT NULL,
+    status        INTEGER DEFAULT 0         NOT NULL,
+    accrued_until TIMESTAMPTZ               NOT NULL,
+    settled_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+    updated_at    TIMESTAMPTZ DEFAULT NOW() NOT NULL
+);
+-- a loan has at most one fine, accrual updates it
+CREATE UNIQUE INDEX fines_loan_id_idx ON fines (loan_id);
+CREATE INDEX fines_patron_id_idx ON fines (patron_id);
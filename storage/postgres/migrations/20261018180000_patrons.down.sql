@@ -0,0 +1,9 @@
+ALTER TABLE fines
+    DROP CONSTRAINT fines_patron_id_fkey;
+ALTER TABLE holds
+    DROP CONSTRAINT holds_patron_id_fkey;
+ALTER TABLE loans
+    DROP CONSTRAINT loans_borrower_id_fkey;
+
+DROP TABLE patrons;
+DROP SEQUENCE patrons_membership_number_seq;
@@ -0,0 +1,42 @@
+CREATE SEQUENCE patrons_membership_number_seq;
+
+CREATE TABLE patrons
+(
+    id                SERIAL                  NOT NULL
+        CONSTRAINT patrons_pkey
+            PRIMARY KEY,
+    -- patrons added without a membership number get a generated one
+    membership_number TEXT                    NOT NULL
+        DEFAULT 'M' || LPAD(NEXTVAL('patrons_membership_number_seq')::TEXT, 9, '0'),
+    name              TEXT                    NOT NULL,
+    email             TEXT      DEFAULT ''    NOT NULL,
+    phone             TEXT      DEFAULT ''    NOT NULL,
+    address           TEXT      DEFAULT ''    NOT NULL,
+    status            INTEGER   DEFAULT 0     NOT NULL,
+    loan_limit        INTEGER                 NOT NULL,
+    created_at        TIMESTAMP DEFAULT NOW() NOT NULL,
+    updated_at        TIMESTAMP DEFAULT NOW() NOT NULL
+);
+CREATE UNIQUE INDEX patrons_membership_number_idx ON patrons (membership_number);
+
+-- the borrowers of the existing loans, holds and fines become patrons so that
+-- they can be referenced
+INSERT INTO patrons (id, name, loan_limit)
+SELECT id, 'Patron ' || id, 5
+FROM (SELECT borrower_id AS id FROM loans
+      UNION
+      SELECT patron_id FROM holds
+      UNION
+      SELECT patron_id FROM fines) borrowers
+ORDER BY id;
+SELECT SETVAL('patrons_id_seq', COALESCE(MAX(id), 0) + 1, FALSE) FROM patrons;
+
+ALTER TABLE loans
+    ADD CONSTRAINT loans_borrower_id_fkey
+        FOREIGN KEY (borrower_id) REFERENCES patrons;
+ALTER TABLE holds
+    ADD CONSTRAINT holds_patron_id_fkey
+        FOREIGN KEY (patron_id) REFERENCES patrons;
+ALTER TABLE fines
+    ADD CONSTRAINT fines_patron_id_fkey
+        FOREIGN KEY (patron_id) REFERENCES patrons;
@@ -0,0 +1,2 @@
+DROP TABLE book_audit;
+DROP FUNCTION book_audit_append_only();
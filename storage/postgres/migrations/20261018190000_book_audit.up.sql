@@ -0,0 +1,28 @@
+CREATE TABLE book_audit
+(
+    id         BIGSERIAL               NOT NULL
+        CONSTRAINT book_audit_pkey
+            PRIMARY KEY,
+    -- no foreign key, the history outlives purged books
+    book_id    INTEGER                 NOT NULL,
+    actor      TEXT                    NOT NULL,
+    action     TEXT                    NOT NULL,
+    before     JSONB,
+    after      JSONB,
+    created_at TIMESTAMP DEFAULT NOW() NOT NULL
+);
+CREATE INDEX book_audit_book_id_idx ON book_audit (book_id, id);
+
+-- the audit log is append-only
+CREATE FUNCTION book_audit_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'book_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER book_audit_append_only
+    BEFORE UPDATE OR DELETE
+    ON book_audit
+    FOR EACH ROW
+EXECUTE PROCEDURE book_audit_append_only();
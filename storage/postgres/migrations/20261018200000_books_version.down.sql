@@ -0,0 +1,5 @@
+DROP TRIGGER books_increment_version ON books;
+DROP FUNCTION books_increment_version();
+
+ALTER TABLE books
+    DROP COLUMN version;
@@ -0,0 +1,17 @@
+ALTER TABLE books
+    ADD COLUMN version INTEGER DEFAULT 1 NOT NULL;
+
+-- every write of a book makes a new version of it
+CREATE FUNCTION books_increment_version() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_increment_version
+    BEFORE UPDATE
+    ON books
+    FOR EACH ROW
+EXECUTE PROCEDURE books_increment_version();
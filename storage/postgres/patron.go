@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+func (s *Storage) CreatePatron(ctx context.Context, p *models.Patron) error {
+	_, err := s.conn().ModelContext(ctx, p).Returning("*").Insert()
+	return toServiceError(err)
+}
+
+func (s *Storage) GetPatron(ctx context.Context, id int) (*models.Patron, error) {
+	var res models.Patron
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error) {
+	var res []models.Patron
+	q := s.conn().ModelContext(ctx, &res).Order("name", "id").Limit(limit).Offset(offset)
+	if ps != nil {
+		if ps.Query != "" {
+			pattern := containsPattern(ps.Query)
+			q = q.WhereGroup(func(q *pg.Query) (*pg.Query, error) {
+				return q.Where("unaccent_immutable(name) ILIKE unaccent_immutable(?)", pattern).
+					WhereOr("email ILIKE ?", pattern).
+					WhereOr("membership_number ILIKE ?", pattern), nil
+			})
+		}
+		if ps.MembershipNumber != "" {
+			q = q.Where("membership_number = ?", ps.MembershipNumber)
+		}
+		if ps.Status != nil {
+			q = q.Where("status = ?", *ps.Status)
+		}
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdatePatron updates the patron, the membership number is kept if not given.
+func (s *Storage) UpdatePatron(ctx context.Context, p *models.Patron) error {
+	res, err := s.conn().ModelContext(ctx, p).WherePK().
+		Set("membership_number = COALESCE(NULLIF(?membership_number, ''), membership_number)").
+		Set("name = ?name").
+		Set("email = ?email").
+		Set("phone = ?phone").
+		Set("address = ?address").
+		Set("status = ?status").
+		Set("loan_limit = ?loan_limit").
+		Set("updated_at = now()").
+		Returning("*").
+		Update()
+	if err != nil {
+		return toServiceError(err)
+	}
+	if res.RowsAffected() == 0 {
+		return models.ErrNotFound{Message: "patron does not exist"}
+	}
+	return nil
+}
+
+// DeletePatron deletes a patron who never borrowed or held a book, the others
+// are kept for the loan and hold history and may be suspended instead.
+func (s *Storage) DeletePatron(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		hasLoans, err := tx.ModelContext(ctx, (*models.Loan)(nil)).Where("borrower_id = ?", id).Exists()
+		if err != nil {
+			return err
+		}
+		if hasLoans {
+			return models.ErrConflict{Message: "patron has loans"}
+		}
+		hasHolds, err := tx.ModelContext(ctx, (*models.Hold)(nil)).Where("patron_id = ?", id).Exists()
+		if err != nil {
+			return err
+		}
+		if hasHolds {
+			return models.ErrConflict{Message: "patron has holds"}
+		}
+		res, err := tx.ModelContext(ctx, (*models.Patron)(nil)).Where("id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return models.ErrNotFound{Message: "patron does not exist"}
+		}
+		return nil
+	})
+	return toServiceError(err)
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/libreria/models"
+)
+
+func (s *Storage) CreatePublisher(ctx context.Context, p *models.Publisher) error {
+	_, err := s.conn().ModelContext(ctx, p).Insert()
+	return toServiceError(err)
+}
+
+func (s *Storage) GetPublisher(ctx context.Context, id int) (*models.Publisher, error) {
+	var res models.Publisher
+	err := s.conn().ModelContext(ctx, &res).Where("id = ?", id).First()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error) {
+	var res []models.Publisher
+	q := s.conn().ModelContext(ctx, &res).Order("name", "id").Limit(limit).Offset(offset)
+	if name != "" {
+		q = q.Where("unaccent_immutable(name) ILIKE unaccent_immutable(?)", containsPattern(name))
+	}
+	err := q.Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdatePublisher renames the publisher and the publisher name stored with the publisher's books.
+func (s *Storage) UpdatePublisher(ctx context.Context, p *models.Publisher) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		res, err := tx.ModelContext(ctx, p).WherePK().
+			Set("name = ?name").
+			Set("updated_at = now()").
+			Update()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return models.ErrNotFound{Message: "publisher does not exist"}
+		}
+		_, err = tx.ModelContext(ctx, (*models.Book)(nil)).AllWithDeleted().
+			Set("publisher = ?", p.Name).
+			Where("publisher_id = ?", p.ID).
+			Update()
+		return err
+	})
+	return toServiceError(err)
+}
+
+// DeletePublisher deletes a publisher without books.
+func (s *Storage) DeletePublisher(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		// soft deleted books still reference the publisher
+		hasBooks, err := tx.ModelContext(ctx, (*models.Book)(nil)).AllWithDeleted().Where("publisher_id = ?", id).Exists()
+		if err != nil {
+			return err
+		}
+		if hasBooks {
+			return models.ErrConflict{Message: "publisher has books"}
+		}
+		res, err := tx.ModelContext(ctx, (*models.Publisher)(nil)).Where("id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return models.ErrNotFound{Message: "publisher does not exist"}
+		}
+		return nil
+	})
+	return toServiceError(err)
+}
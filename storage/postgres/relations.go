@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/libreria/models"
+)
+
+// resolveBookRelations loads the authors and the publisher referenced by id
+// and finds or creates the ones given by name only. The names are copied to
+// the author and publisher columns of the book.
+func resolveBookRelations(ctx context.Context, db orm.DB, b *models.Book) error {
+	authors := make([]models.Author, 0, len(b.Authors))
+	names := make([]string, 0, len(b.Authors))
+	seen := make(map[int]bool, len(b.Authors))
+	for _, a := range b.Authors {
+		var err error
+		if a.ID != 0 {
+			err = db.ModelContext(ctx, &a).WherePK().Select()
+			if err == pg.ErrNoRows {
+				return models.ErrBadRequest{Message: fmt.Sprintf("author %d does not exist", a.ID)}
+			}
+		} else {
+			_, err = db.QueryOneContext(ctx, &a, `
+INSERT INTO authors (name)
+VALUES (?)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = authors.name
+RETURNING *`, a.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		authors = append(authors, a)
+		names = append(names, a.Name)
+	}
+	b.Authors = authors
+	b.Author = strings.Join(names, ", ")
+
+	p := models.Publisher{ID: b.PublisherID, Name: b.Publisher}
+	var err error
+	if p.ID != 0 {
+		err = db.ModelContext(ctx, &p).WherePK().Select()
+		if err == pg.ErrNoRows {
+			return models.ErrBadRequest{Message: fmt.Sprintf("publisher %d does not exist", p.ID)}
+		}
+	} else {
+		_, err = db.QueryOneContext(ctx, &p, `
+INSERT INTO publishers (name)
+VALUES (?)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = publishers.name
+RETURNING *`, p.Name)
+	}
+	if err != nil {
+		return err
+	}
+	b.PublisherID = p.ID
+	b.Publisher = p.Name
+	return nil
+}
+
+// linkBookAuthors replaces the author links of the book by its authors.
+func linkBookAuthors(ctx context.Context, db orm.DB, b *models.Book) error {
+	_, err := db.ModelContext(ctx, (*models.BookAuthor)(nil)).Where("book_id = ?", b.ID).Delete()
+	if err != nil {
+		return err
+	}
+	if len(b.Authors) == 0 {
+		return nil
+	}
+	links := make([]models.BookAuthor, len(b.Authors))
+	for i, a := range b.Authors {
+		links[i] = models.BookAuthor{BookID: b.ID, AuthorID: a.ID, Position: i}
+	}
+	_, err = db.ModelContext(ctx, &links).Insert()
+	return err
+}
+
+// loadBookAuthors fills the authors of the books in their order.
+func loadBookAuthors(ctx context.Context, db orm.DB, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Book, len(books))
+	ids := make([]int, len(books))
+	for i, b := range books {
+		byID[b.ID] = b
+		ids[i] = b.ID
+		b.Authors = []models.Author{}
+	}
+	var rows []struct {
+		BookID int
+		models.Author
+	}
+	_, err := db.QueryContext(ctx, &rows, `
+SELECT book_authors.book_id, authors.*
+FROM book_authors
+         JOIN authors ON authors.id = book_authors.author_id
+WHERE book_authors.book_id IN (?)
+ORDER BY book_authors.book_id, book_authors.position`, pg.In(ids))
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		byID[r.BookID].Authors = append(byID[r.BookID].Authors, r.Author)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/libreria/models"
+)
+
+// RestoreBook undoes the soft deletion of the book.
+func (s *Storage) RestoreBook(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		before, err := lockDeletedBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return models.ErrConflict{Message: "book is not deleted"}
+		}
+		_, err = tx.ModelContext(ctx, (*models.Book)(nil)).AllWithDeleted().
+			Set("deleted_at = NULL").
+			Set("updated_at = now()").
+			Where("id = ?", id).
+			Update()
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditRestore, id, before, after)
+	})
+	return toServiceError(err)
+}
+
+// PurgeBook permanently deletes the book, deleted or not, with its copies,
+// loans, fines, holds, ratings and author links if it is still at version.
+// The audit log of the book is kept.
+func (s *Storage) PurgeBook(ctx context.Context, id, version int) error {
+	err := s.runInTx(ctx, func(tx *pg.Tx) error {
+		return purgeBook(ctx, tx, id, version)
+	})
+	return toServiceError(err)
+}
+
+// PurgeDeletedBooks permanently deletes the books soft-deleted before
+// deletedBefore. Books that cannot be purged yet are skipped.
+func (s *Storage) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var ids []int
+	err := s.conn().ModelContext(ctx, (*models.Book)(nil)).Deleted().
+		Column("id").
+		Where("deleted_at < ?", deletedBefore).
+		Order("id").
+		Select(&ids)
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	var n int
+	for _, id := range ids {
+		err = s.runInTx(ctx, func(tx *pg.Tx) error {
+			return purgeBook(ctx, tx, id, 0)
+		})
+		var conflict models.ErrConflict
+		if errors.As(err, &conflict) {
+			continue
+		}
+		if err != nil {
+			return n, toServiceError(err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func purgeBook(ctx context.Context, db orm.DB, id, version int) error {
+	before, err := lockDeletedBook(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(before, version)
+	if err != nil {
+		return err
+	}
+	open, err := db.ModelContext(ctx, (*models.Loan)(nil)).
+		Where("book_id = ?", id).
+		Where("returned_at IS NULL").
+		Exists()
+	if err != nil {
+		return err
+	}
+	if open {
+		return models.ErrConflict{Message: "book has open loans"}
+	}
+	unsettled, err := db.ModelContext(ctx, (*models.Fine)(nil)).
+		Where("loan_id IN (SELECT id FROM loans WHERE book_id = ?)", id).
+		Where("status = ?", models.FineOpen).
+		Exists()
+	if err != nil {
+		return err
+	}
+	if unsettled {
+		return models.ErrConflict{Message: "book has unsettled fines"}
+	}
+	_, err = db.ModelContext(ctx, (*models.Fine)(nil)).
+		Where("loan_id IN (SELECT id FROM loans WHERE book_id = ?)", id).
+		Delete()
+	if err != nil {
+		return err
+	}
+	for _, model := range []interface{}{
+		(*models.Hold)(nil),
+		(*models.Loan)(nil),
+		(*models.Copy)(nil),
+		(*models.Rating)(nil),
+		(*models.BookAuthor)(nil),
+	} {
+		_, err = db.ModelContext(ctx, model).Where("book_id = ?", id).Delete()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = db.ModelContext(ctx, (*models.Book)(nil)).Where("id = ?", id).ForceDelete()
+	if err != nil {
+		return err
+	}
+	return auditBook(ctx, db, models.AuditPurge, id, before, nil)
+}
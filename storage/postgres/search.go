@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v10"
+	"github.com/go-pg/pg/v10/orm"
+	"github.com/go-pg/pg/v10/types"
+	"github.com/libreria/models"
+)
+
+// tsQuery parses a web search style full-text query the same way the
+// search_vector column is built: without stemming and accents.
+const tsQuery = "websearch_to_tsquery('simple', unaccent_immutable(?))"
+
+func (s *Storage) GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error) {
+	var (
+		books []models.Book
+		res   models.BookList
+		err   error
+	)
+	keys, err := bookSortKeys(bs, p.Sort)
+	if err != nil {
+		return nil, err
+	}
+	q := s.conn().ModelContext(ctx, &books)
+	applyBookSearch(q, bs)
+	if p.WithTotal {
+		res.Total, err = q.Clone().Count()
+		if err != nil {
+			return nil, toServiceError(err)
+		}
+	}
+	for _, k := range keys {
+		if k.desc {
+			q = q.OrderExpr("? DESC", k.expr)
+		} else {
+			q = q.OrderExpr("? ASC", k.expr)
+		}
+	}
+	q = q.Order("id")
+	if p.After != nil {
+		if len(p.After.Values) != len(keys) {
+			return nil, models.ErrBadRequest{Message: "cursor does not match sort"}
+		}
+		q = q.WhereGroup(keysetCondition(keys, p.After))
+	} else {
+		q = q.Offset(p.Offset)
+	}
+	// fetch one more row to find out whether there is a next page
+	err = q.Limit(p.Limit + 1).Select()
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	if len(books) > p.Limit {
+		books = books[:p.Limit]
+		res.Next, err = s.bookCursor(ctx, &books[len(books)-1], p.Sort, keys)
+		if err != nil {
+			return nil, toServiceError(err)
+		}
+	}
+	ptrs := make([]*models.Book, len(books))
+	for i := range books {
+		ptrs[i] = &books[i]
+	}
+	err = loadBookAuthors(ctx, s.conn(), ptrs...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	res.Books = books
+	return &res, nil
+}
+
+// bookCursor builds the cursor pointing after the book. Values that are not
+// columns of the book, like the relevance, are computed by the database.
+func (s *Storage) bookCursor(ctx context.Context, b *models.Book, sort []models.SortField, keys []sortKey) (*models.Cursor, error) {
+	c := &models.Cursor{ID: b.ID}
+	for i, sf := range sort {
+		if sf.Field != models.SortRelevance {
+			c.Values = append(c.Values, b.SortValue(sf.Field))
+			continue
+		}
+		var v float64
+		_, err := s.conn().QueryOneContext(ctx, pg.Scan(&v), "SELECT ? FROM books WHERE id = ?", keys[i].expr, b.ID)
+		if err != nil {
+			return nil, err
+		}
+		c.Values = append(c.Values, v)
+	}
+	return c, nil
+}
+
+// bookSortColumns maps sort fields to columns, anything else is rejected.
+var bookSortColumns = map[string]string{
+	"id":           "id",
+	"title":        "title",
+	"author":       "author",
+	"publisher":    "publisher",
+	"publish_date": "publish_date",
+	"rating":       "rating",
+}
+
+type sortKey struct {
+	expr types.ValueAppender
+	desc bool
+}
+
+func bookSortKeys(bs *models.BookSearch, sort []models.SortField) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(sort))
+	for _, sf := range sort {
+		if sf.Field == models.SortRelevance {
+			if bs == nil || bs.Query == "" {
+				return nil, models.ErrBadRequest{Message: "sorting by relevance requires a search query"}
+			}
+			keys = append(keys, sortKey{
+				// double precision values survive the round trip through the cursor exactly
+				expr: pg.SafeQuery("ts_rank(search_vector, "+tsQuery+")::float8", bs.Query),
+				desc: sf.Desc,
+			})
+			continue
+		}
+		col, ok := bookSortColumns[sf.Field]
+		if !ok {
+			return nil, models.ErrBadRequest{Message: "unknown sort field " + sf.Field}
+		}
+		keys = append(keys, sortKey{expr: pg.Ident(col), desc: sf.Desc})
+	}
+	return keys, nil
+}
+
+// keysetCondition selects the rows following the cursor in the sort order:
+// (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z) for "a,-b".
+func keysetCondition(keys []sortKey, after *models.Cursor) func(*orm.Query) (*orm.Query, error) {
+	return func(q *orm.Query) (*orm.Query, error) {
+		for i := 0; i <= len(keys); i++ {
+			i := i
+			q = q.WhereOrGroup(func(q *orm.Query) (*orm.Query, error) {
+				for j := 0; j < i; j++ {
+					q = q.Where("? = ?", keys[j].expr, after.Values[j])
+				}
+				if i == len(keys) {
+					return q.Where("id > ?", after.ID), nil
+				}
+				op := ">"
+				if keys[i].desc {
+					op = "<"
+				}
+				return q.Where("? "+op+" ?", keys[i].expr, after.Values[i]), nil
+			})
+		}
+		return q, nil
+	}
+}
+
+func applyBookSearch(q *orm.Query, bs *models.BookSearch) {
+	if bs == nil {
+		return
+	}
+	if bs.Query != "" {
+		q.Where("search_vector @@ "+tsQuery, bs.Query)
+	}
+	// field filters ignore case and accents
+	if bs.Title != "" {
+		q.Where("unaccent_immutable(title) ILIKE unaccent_immutable(?)", containsPattern(bs.Title))
+	}
+	if bs.Author != "" {
+		q.Where("unaccent_immutable(author) ILIKE unaccent_immutable(?)", containsPattern(bs.Author))
+	}
+	if bs.Publisher != "" {
+		q.Where("unaccent_immutable(publisher) ILIKE unaccent_immutable(?)", containsPattern(bs.Publisher))
+	}
+	if bs.Status != nil {
+		q.Where("status = ?", *bs.Status)
+	}
+	if bs.PublishDateSearch != nil {
+		q.Where(fmt.Sprintf("publish_date %s ?::date", bs.PublishDateSearch.Condition), bs.PublishDateSearch.PublishDate)
+	}
+	if bs.AuthorID != nil {
+		q.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", *bs.AuthorID)
+	}
+	if bs.PublisherID != nil {
+		q.Where("publisher_id = ?", *bs.PublisherID)
+	}
+	switch bs.Deleted {
+	case models.DeletedInclude:
+		q.AllWithDeleted()
+	case models.DeletedOnly:
+		q.Deleted()
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern makes a LIKE pattern matching values containing s literally.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
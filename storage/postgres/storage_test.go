@@ -0,0 +1,47 @@
+// +build integration
+
+package postgres
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/libreria/config/reader"
+	"github.com/libreria/service/book"
+	"github.com/libreria/storage/postgres/migrations"
+	"github.com/libreria/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	PostgresStorageTest Config `mapstructure:"postgres_storage_test"`
+}
+
+// TestStorage runs the storage conformance suite on a database of its own,
+// emptied before every test.
+func TestStorage(t *testing.T) {
+	req := require.New(t)
+	cfg := new(testConfig)
+	req.NoError(reader.Read(cfg))
+	c := cfg.PostgresStorageTest
+	req.NoError(migrations.Migrate(c.ConnString(), io.Discard, "down"))
+	req.NoError(migrations.Migrate(c.ConnString(), io.Discard, "up"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	s, err := New(ctx, wg, c)
+	req.NoError(err)
+	storagetest.Run(t, func(t *testing.T) book.StorageManager {
+		_, err := s.db.Exec(`
+TRUNCATE books, book_authors, authors, publishers, copies, loans, holds, fines, patrons, book_ratings, book_audit
+    RESTART IDENTITY CASCADE`)
+		require.NoError(t, err)
+		return s
+	})
+}
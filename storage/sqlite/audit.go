@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/libreria/models"
+)
+
+// GetBookHistory returns the audit entries of the book in the order they were made.
+func (s *Storage) GetBookHistory(ctx context.Context, bookID, limit, offset int) ([]models.AuditEntry, error) {
+	res, err := queryRows(ctx, s.conn(), scanAuditEntry, `
+SELECT id, book_id, actor, action, before, after, created_at
+FROM book_audit
+WHERE book_id = ?
+ORDER BY id`+limitOffset(limit, offset), bookID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+func scanAuditEntry(row scanner) (models.AuditEntry, error) {
+	var (
+		e             models.AuditEntry
+		before, after sql.NullString
+	)
+	err := row.Scan(&e.ID, &e.BookID, &e.Actor, &e.Action, &before, &after, timeColumn{&e.CreatedAt})
+	if err != nil {
+		return e, err
+	}
+	e.Before, err = jsonObject(before)
+	if err != nil {
+		return e, err
+	}
+	e.After, err = jsonObject(after)
+	return e, err
+}
+
+// jsonObject decodes a JSON object column, NULL is nil.
+func jsonObject(s sql.NullString) (map[string]interface{}, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var res map[string]interface{}
+	err := json.Unmarshal([]byte(s.String), &res)
+	return res, err
+}
+
+// lockBook selects the book with its authors. The name follows the postgres
+// storage, SQLite needs no row locks as its transactions run one at a time.
+func lockBook(ctx context.Context, q querier, id int) (*models.Book, error) {
+	return lockBookQuery(ctx, q, id, false)
+}
+
+// lockDeletedBook is lockBook that finds soft-deleted books too.
+func lockDeletedBook(ctx context.Context, q querier, id int) (*models.Book, error) {
+	return lockBookQuery(ctx, q, id, true)
+}
+
+func lockBookQuery(ctx context.Context, q querier, id int, withDeleted bool) (*models.Book, error) {
+	query := "SELECT " + bookColumns + " FROM books WHERE id = ?"
+	if !withDeleted {
+		query += " AND deleted_at IS NULL"
+	}
+	b, err := scanBook(q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNotFound{Message: "book does not exist"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = loadBookAuthors(ctx, q, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// auditBook records the mutation of a book by the caller of ctx. before and
+// after are the book before and after the mutation, nil if it did not exist.
+func auditBook(ctx context.Context, q querier, action models.AuditAction, bookID int, before, after *models.Book) error {
+	b, a, err := models.BookDiff(before, after)
+	if err != nil {
+		return err
+	}
+	bJSON, err := jsonValue(b)
+	if err != nil {
+		return err
+	}
+	aJSON, err := jsonValue(a)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `
+INSERT INTO book_audit (book_id, actor, action, before, after, created_at)
+VALUES (?, ?, ?, ?, ?, ?)`,
+		bookID, models.ActorFromContext(ctx), string(action), bJSON, aJSON, formatTime(now()))
+	return err
+}
+
+// jsonValue encodes a JSON object column, nil is NULL.
+func jsonValue(m map[string]interface{}) (interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
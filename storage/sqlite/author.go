@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func scanAuthor(row scanner) (models.Author, error) {
+	var a models.Author
+	err := scanNamed(row, &a.ID, &a.Name, &a.CreatedAt, &a.UpdatedAt)
+	return a, err
+}
+
+func (s *Storage) CreateAuthor(ctx context.Context, a *models.Author) error {
+	err := insertNamed(ctx, s.conn(), "authors", a.Name, &a.ID, &a.CreatedAt, &a.UpdatedAt)
+	return toServiceError(err)
+}
+
+func (s *Storage) GetAuthor(ctx context.Context, id int) (*models.Author, error) {
+	res, err := scanAuthor(s.conn().QueryRowContext(ctx, "SELECT "+namedColumns+" FROM authors WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetAuthors(ctx context.Context, name string, limit, offset int) ([]models.Author, error) {
+	res, err := queryRows(ctx, s.conn(), scanAuthor, `
+SELECT `+namedColumns+`
+FROM authors
+WHERE instr(name_folded, ?) > 0
+ORDER BY name_folded, name, id`+limitOffset(limit, offset), textsearch.Fold(name))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdateAuthor renames the author and the author names stored with the author's books.
+func (s *Storage) UpdateAuthor(ctx context.Context, a *models.Author) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		err := renameNamed(ctx, tx, "authors", a.ID, a.Name, "author does not exist")
+		if err != nil {
+			return err
+		}
+		books, err := queryRows(ctx, tx, func(row scanner) (*models.Book, error) {
+			var b models.Book
+			return &b, row.Scan(&b.ID)
+		}, "SELECT book_id FROM book_authors WHERE author_id = ? ORDER BY book_id", a.ID)
+		if err != nil {
+			return err
+		}
+		err = loadBookAuthors(ctx, tx, books...)
+		if err != nil {
+			return err
+		}
+		t := formatTime(now())
+		for _, b := range books {
+			names := make([]string, len(b.Authors))
+			for i, ba := range b.Authors {
+				names[i] = ba.Name
+			}
+			author := strings.Join(names, ", ")
+			_, err = tx.ExecContext(ctx, "UPDATE books SET author = ?, author_folded = ?, updated_at = ? WHERE id = ?",
+				author, textsearch.Fold(author), t, b.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return toServiceError(err)
+}
+
+// renameNamed renames an author or a publisher.
+func renameNamed(ctx context.Context, q querier, table string, id int, name, notFound string) error {
+	res, err := q.ExecContext(ctx, "UPDATE "+table+" SET name = ?, name_lower = ?, name_folded = ?, updated_at = ? WHERE id = ?",
+		name, strings.ToLower(name), textsearch.Fold(name), formatTime(now()), id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNotFound{Message: notFound}
+	}
+	return nil
+}
+
+// DeleteAuthor deletes an author without books.
+func (s *Storage) DeleteAuthor(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var hasBooks bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM book_authors WHERE author_id = ?)", id).Scan(&hasBooks)
+		if err != nil {
+			return err
+		}
+		if hasBooks {
+			return models.ErrConflict{Message: "author has books"}
+		}
+		return deleteRow(ctx, tx, "authors", id, "author does not exist")
+	})
+	return toServiceError(err)
+}
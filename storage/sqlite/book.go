@@ -0,0 +1,268 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+// bookColumns are the columns of a book read by scanBook.
+const bookColumns = `books.id, books.title, COALESCE(books.isbn, ''), books.author, books.publisher,
+books.publisher_id, books.publish_date, books.rating, books.rating_count, books.status,
+books.total_copies, books.available_copies, books.version, books.deleted_at,
+books.created_at, books.updated_at`
+
+// scanBook scans a book, followed by the extra columns of the row into extra.
+func scanBook(row scanner, extra ...interface{}) (models.Book, error) {
+	var b models.Book
+	dest := []interface{}{&b.ID, &b.Title, &b.ISBN, &b.Author, &b.Publisher,
+		&b.PublisherID, timeColumn{&b.PublishDate}, &b.Rating, &b.RatingCount, &b.Status,
+		&b.TotalCopies, &b.AvailableCopies, &b.Version, nullTimeColumn{&b.DeletedAt},
+		nullTimeColumn{&b.CreatedAt}, nullTimeColumn{&b.UpdatedAt}}
+	err := row.Scan(append(dest, extra...)...)
+	return b, err
+}
+
+// nullString stores the empty string as NULL, so books without an isbn do not
+// collide in the unique index.
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// CreateBook inserts the book with one copy and links it to its authors and publisher.
+// Every mutation of a book is recorded in its audit log in the same transaction.
+func (s *Storage) CreateBook(ctx context.Context, b *models.Book) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		return createBook(ctx, tx, b)
+	})
+	return toServiceError(err)
+}
+
+func createBook(ctx context.Context, q querier, b *models.Book) error {
+	err := resolveBookRelations(ctx, q, b)
+	if err != nil {
+		return err
+	}
+	version := b.Version
+	if version == 0 {
+		version = 1
+	}
+	t := formatTime(now())
+	err = q.QueryRowContext(ctx, `
+INSERT INTO books (title, isbn, author, publisher, publisher_id, publish_date, rating, rating_count, status,
+                   version, title_folded, author_folded, publisher_folded, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+		b.Title, nullString(b.ISBN), b.Author, b.Publisher, b.PublisherID, zeroNull(b.PublishDate), b.Rating, b.RatingCount, b.Status,
+		version, textsearch.Fold(b.Title), textsearch.Fold(b.Author), textsearch.Fold(b.Publisher), t, t).Scan(&b.ID)
+	if err != nil {
+		return err
+	}
+	err = linkBookAuthors(ctx, q, b)
+	if err != nil {
+		return err
+	}
+	// a new book comes with its first copy
+	_, err = insertCopy(ctx, q, &models.Copy{BookID: b.ID})
+	if err != nil {
+		return err
+	}
+	err = refreshBookCopies(ctx, q, b.ID)
+	if err != nil {
+		return err
+	}
+	after, err := lockBook(ctx, q, b.ID)
+	if err != nil {
+		return err
+	}
+	b.TotalCopies, b.AvailableCopies, b.Status, b.Version = after.TotalCopies, after.AvailableCopies, after.Status, after.Version
+	return auditBook(ctx, q, models.AuditCreate, b.ID, nil, after)
+}
+
+// UpdateBook writes the columns of the book that differ from the stored ones.
+// An update changing nothing leaves the book, its version and its audit log
+// untouched.
+func (s *Storage) UpdateBook(ctx context.Context, b *models.Book) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockBook(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, b.Version)
+		if err != nil {
+			return err
+		}
+		err = resolveBookRelations(ctx, tx, b)
+		if err != nil {
+			return err
+		}
+		columns, args := changedBookColumns(before, b)
+		authorsChanged := !sameAuthors(before.Authors, b.Authors)
+		if len(columns) == 0 && !authorsChanged {
+			b.Version = before.Version
+			return nil
+		}
+		query := "UPDATE books SET updated_at = ?"
+		for _, c := range columns {
+			query += ", " + c
+		}
+		args = append([]interface{}{formatTime(now())}, args...)
+		_, err = tx.ExecContext(ctx, query+" WHERE id = ?", append(args, b.ID)...)
+		if err != nil {
+			return err
+		}
+		if authorsChanged {
+			err = linkBookAuthors(ctx, tx, b)
+			if err != nil {
+				return err
+			}
+		}
+		after, err := lockBook(ctx, tx, b.ID)
+		if err != nil {
+			return err
+		}
+		b.Version = after.Version
+		return auditBook(ctx, tx, models.AuditUpdate, b.ID, before, after)
+	})
+	return toServiceError(err)
+}
+
+// changedBookColumns returns the assignments of the editable columns of b
+// that differ from the stored book with their arguments.
+func changedBookColumns(stored, b *models.Book) ([]string, []interface{}) {
+	var (
+		columns []string
+		args    []interface{}
+	)
+	set := func(column string, arg interface{}) {
+		columns = append(columns, column+" = ?")
+		args = append(args, arg)
+	}
+	if b.Title != stored.Title {
+		set("title", b.Title)
+		set("title_folded", textsearch.Fold(b.Title))
+	}
+	if b.ISBN != stored.ISBN {
+		set("isbn", nullString(b.ISBN))
+	}
+	if b.Author != stored.Author {
+		set("author", b.Author)
+		set("author_folded", textsearch.Fold(b.Author))
+	}
+	if b.Publisher != stored.Publisher {
+		set("publisher", b.Publisher)
+		set("publisher_folded", textsearch.Fold(b.Publisher))
+	}
+	if b.PublisherID != stored.PublisherID {
+		set("publisher_id", b.PublisherID)
+	}
+	if !dbTime(b.PublishDate).Equal(stored.PublishDate) {
+		set("publish_date", zeroNull(b.PublishDate))
+	}
+	return columns, args
+}
+
+func sameAuthors(a, b []models.Author) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Storage) GetBook(ctx context.Context, id int) (*models.Book, error) {
+	return s.getBook(ctx, "id = ?", id)
+}
+
+func (s *Storage) GetBookByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	return s.getBook(ctx, "isbn = ?", isbn)
+}
+
+// getBook returns the first book that is not deleted matching the condition.
+func (s *Storage) getBook(ctx context.Context, cond string, arg interface{}) (*models.Book, error) {
+	res, err := scanBook(s.conn().QueryRowContext(ctx, "SELECT "+bookColumns+" FROM books WHERE "+cond+" AND deleted_at IS NULL ORDER BY id LIMIT 1", arg))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	err = loadBookAuthors(ctx, s.conn(), &res)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// RateBook stores the user's rating of the book, replacing the previous rating
+// of the same user, and recomputes the book's average rating.
+func (s *Storage) RateBook(ctx context.Context, r *models.Rating) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockBook(ctx, tx, r.BookID)
+		if err != nil {
+			return err
+		}
+		t := formatTime(now())
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO book_ratings (book_id, user_id, rating, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (book_id, user_id) DO UPDATE SET rating     = excluded.rating,
+                                             updated_at = excluded.updated_at`,
+			r.BookID, r.UserID, r.Rating, t, t)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+UPDATE books
+SET rating       = (SELECT AVG(rating) FROM book_ratings WHERE book_id = books.id),
+    rating_count = (SELECT COUNT(*) FROM book_ratings WHERE book_id = books.id),
+    updated_at   = ?
+WHERE id = ?`, t, r.BookID)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, r.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditRate, r.BookID, before, after)
+	})
+	return toServiceError(err)
+}
+
+// DeleteBook soft-deletes the book if it is still at version, any version
+// matches if version is zero.
+func (s *Storage) DeleteBook(ctx context.Context, id, version int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(before, version)
+		if err != nil {
+			return err
+		}
+		t := formatTime(now())
+		_, err = tx.ExecContext(ctx, "UPDATE books SET deleted_at = ?, updated_at = ? WHERE id = ?", t, t, id)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditDelete, id, before, nil)
+	})
+	return toServiceError(err)
+}
+
+// checkVersion makes sure the book is still at the version the caller read,
+// any version matches if version is zero.
+func checkVersion(b *models.Book, version int) error {
+	if version != 0 && b.Version != version {
+		return models.ErrPreconditionFailed{Message: "book has been modified"}
+	}
+	return nil
+}
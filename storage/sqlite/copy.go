@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// copyColumns are the columns of a copy read by scanCopy.
+const copyColumns = "id, book_id, barcode, condition, location, status, retired_at, created_at, updated_at"
+
+func scanCopy(row scanner) (models.Copy, error) {
+	var c models.Copy
+	err := row.Scan(&c.ID, &c.BookID, &c.Barcode, &c.Condition, &c.Location, &c.Status,
+		nullTimeColumn{&c.RetiredAt}, nullTimeColumn{&c.CreatedAt}, nullTimeColumn{&c.UpdatedAt})
+	return c, err
+}
+
+// CreateCopy adds a copy to the book and counts it with the book's copies. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CreateCopy(ctx context.Context, c *models.Copy, holdExpiresAt time.Time) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		stored, err := insertCopy(ctx, tx, c)
+		if err != nil {
+			return err
+		}
+		err = passCopy(ctx, tx, stored.ID, stored.BookID, models.StatusCheckedIn, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		stored, err = scanCopy(tx.QueryRowContext(ctx, "SELECT "+copyColumns+" FROM copies WHERE id = ?", stored.ID))
+		*c = stored
+		return err
+	})
+	return toServiceError(err)
+}
+
+// insertCopy stores a new copy with the defaults of the copies table of postgres.
+func insertCopy(ctx context.Context, q querier, c *models.Copy) (models.Copy, error) {
+	stored := *c
+	if stored.Barcode == "" {
+		n, err := nextValue(ctx, q, "copies_barcode")
+		if err != nil {
+			return stored, err
+		}
+		stored.Barcode = fmt.Sprintf("LIB%09d", n)
+	}
+	if stored.Condition == "" {
+		stored.Condition = "good"
+	}
+	t := now()
+	stored.CreatedAt, stored.UpdatedAt = &t, &t
+	err := q.QueryRowContext(ctx, `
+INSERT INTO copies (book_id, barcode, condition, location, status, retired_at, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+		stored.BookID, stored.Barcode, stored.Condition, stored.Location, stored.Status,
+		nullTime(stored.RetiredAt), formatTime(t), formatTime(t)).Scan(&stored.ID)
+	return stored, err
+}
+
+func (s *Storage) GetCopy(ctx context.Context, id int) (*models.Copy, error) {
+	res, err := scanCopy(s.conn().QueryRowContext(ctx, "SELECT "+copyColumns+" FROM copies WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// GetCopies returns all copies of the book including the retired ones.
+func (s *Storage) GetCopies(ctx context.Context, bookID int) ([]models.Copy, error) {
+	res, err := queryRows(ctx, s.conn(), scanCopy, "SELECT "+copyColumns+" FROM copies WHERE book_id = ? ORDER BY id", bookID)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// RetireCopy takes a checked in copy out of circulation.
+func (s *Storage) RetireCopy(ctx context.Context, id int, retiredAt time.Time) (*models.Copy, error) {
+	var res models.Copy
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = scanCopy(tx.QueryRowContext(ctx, `
+UPDATE copies
+SET retired_at = ?,
+    updated_at = ?
+WHERE id = ?
+  AND retired_at IS NULL
+  AND status = ?
+RETURNING `+copyColumns, formatTime(retiredAt), formatTime(now()), id, models.StatusCheckedIn))
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrConflict{Message: "copy is checked out, on hold or already retired"}
+		}
+		if err != nil {
+			return err
+		}
+		return refreshBookCopies(ctx, tx, res.BookID)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// availableCopy returns the first copy of the book that can be checked out.
+func availableCopy(ctx context.Context, q querier, bookID int) (int, error) {
+	var id int
+	err := q.QueryRowContext(ctx, `
+SELECT id
+FROM copies
+WHERE book_id = ?
+  AND retired_at IS NULL
+  AND status = ?
+ORDER BY id
+LIMIT 1`, bookID, models.StatusCheckedIn).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, models.ErrConflict{Message: "book has no available copy"}
+	}
+	return id, err
+}
+
+// updateCopyStatus moves the copy from one status to another. It fails with
+// a conflict if the copy is not in the expected status.
+func updateCopyStatus(ctx context.Context, q querier, id int, from, to models.BookStatus) error {
+	res, err := q.ExecContext(ctx, `
+UPDATE copies
+SET status     = ?,
+    updated_at = ?
+WHERE id = ?
+  AND status = ?
+  AND retired_at IS NULL`, to, formatTime(now()), id, from)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrConflict{Message: "copy status has been changed by another request"}
+	}
+	return nil
+}
+
+// refreshBookCopies recomputes the copy counts and the status of the book.
+func refreshBookCopies(ctx context.Context, q querier, bookID int) error {
+	_, err := q.ExecContext(ctx, `
+UPDATE books
+SET total_copies     = aggregate.total,
+    available_copies = aggregate.available,
+    status           = CASE WHEN aggregate.available > 0 THEN ? ELSE ? END,
+    updated_at       = ?
+FROM (
+         SELECT COUNT(*) total, COUNT(*) FILTER (WHERE status = ?) available
+         FROM copies
+         WHERE book_id = ?
+           AND retired_at IS NULL) AS aggregate
+WHERE books.id = ?`,
+		models.StatusCheckedIn, models.StatusCheckedOut, formatTime(now()), models.StatusCheckedIn, bookID, bookID)
+	return err
+}
@@ -0,0 +1,307 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	log "github.com/sirupsen/logrus"
+)
+
+// Config of the SQLite storage. Path is the database file, created with the
+// schema if it does not exist.
+type Config struct {
+	Path string `mapstructure:"path" default:"libreria.db"`
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// uniqueConstraints describes violations of unique constraints to the
+// client, by the columns SQLite reports for them.
+var uniqueConstraints = map[string]string{
+	"loans.copy_id":                  "copy is already checked out",
+	"copies.barcode":                 "copy with this barcode already exists",
+	"holds.book_id, holds.patron_id": "patron already has a hold on the book",
+	"books.isbn":                     "book with this isbn already exists",
+	"patrons.membership_number":      "patron with this membership number already exists",
+	"authors.name_lower":             "author already exists",
+	"publishers.name_lower":          "publisher already exists",
+}
+
+// Storage keeps the library in a SQLite database file, for single-node
+// deployments without postgres. The database has a single connection, so
+// queries and transactions run one after another and never conflict. The
+// storage of a transaction runs all its queries in the transaction.
+type Storage struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// querier runs queries on the database or in a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns the transaction of the storage, or the database.
+func (s *Storage) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// runInTx runs fn in the transaction of the storage, or else in a new
+// transaction. Only a new transaction is committed or rolled back.
+func (s *Storage) runInTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// RunInTx runs fn with a storage whose queries all run in one transaction,
+// committed if fn returns nil and rolled back otherwise. Transactions hold
+// the database until they end, so they are never retried. Within a
+// transaction fn runs in it.
+func (s *Storage) RunInTx(ctx context.Context, fn func(tx book.StorageManager) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	// the errors of fn are service errors already
+	return s.runInTx(ctx, func(tx *sql.Tx) error {
+		return fn(&Storage{db: s.db, tx: tx})
+	})
+}
+
+// openDB opens the database file with the driver built into the binary, see
+// driver.go. It is nil when the binary is built without SQLite.
+var openDB func(path string) (*sql.DB, error)
+
+// newMigrateDriver returns the golang-migrate driver of the database.
+var newMigrateDriver func(db *sql.DB) (database.Driver, error)
+
+// New opens the database and brings its schema up to date.
+func New(globalCtx context.Context, wg *sync.WaitGroup, cfg Config) (*Storage, error) {
+	if openDB == nil {
+		return nil, errors.New("sqlite storage is not built in, build with -tags sqlite")
+	}
+	db, err := openDB(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	// a single connection serializes the transactions, SQLite has one writer anyway
+	db.SetMaxOpenConns(1)
+	err = migrateUp(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", cfg.Path, err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-globalCtx.Done()
+		err := db.Close()
+		if err != nil {
+			log.Errorf("db close error: %v", err)
+			return
+		}
+		log.Info("db is closed")
+	}()
+	return &Storage{db: db}, nil
+}
+
+// migrateUp applies the migrations embedded in the binary.
+func migrateUp(db *sql.DB) error {
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		return err
+	}
+	driver, err := newMigrateDriver(db)
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		return err
+	}
+	err = m.Up()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+func toServiceError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound{}
+	}
+	// SQLite names the columns of the violated constraint in the message
+	if _, cols, ok := strings.Cut(err.Error(), "UNIQUE constraint failed: "); ok {
+		cols, _, _ = strings.Cut(cols, " (")
+		msg, ok := uniqueConstraints[cols]
+		if !ok {
+			msg = "UNIQUE constraint failed: " + cols
+		}
+		return models.ErrConflict{Message: msg}
+	}
+	return err
+}
+
+// timeLayout stores times as text in UTC with microseconds like postgres,
+// the text sorts like the times.
+const timeLayout = "2006-01-02 15:04:05.000000"
+
+// now is the current time as stored, in UTC with microseconds.
+func now() time.Time {
+	return dbTime(time.Now())
+}
+
+func dbTime(t time.Time) time.Time {
+	return t.UTC().Round(time.Microsecond)
+}
+
+func formatTime(t time.Time) string {
+	return dbTime(t).Format(timeLayout)
+}
+
+// nullTime formats the time, nil is NULL.
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+// zeroNull formats the time, the zero time is NULL.
+func zeroNull(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return formatTime(t)
+}
+
+// timeColumn scans a time formatted by formatTime, NULL is the zero time.
+type timeColumn struct{ t *time.Time }
+
+func (c timeColumn) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*c.t = time.Time{}
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	t, err := time.Parse(timeLayout, s)
+	if err != nil {
+		return err
+	}
+	*c.t = t
+	return nil
+}
+
+// nullTimeColumn scans a time that may be NULL.
+type nullTimeColumn struct{ t **time.Time }
+
+func (c nullTimeColumn) Scan(src interface{}) error {
+	if src == nil {
+		*c.t = nil
+		return nil
+	}
+	var t time.Time
+	err := timeColumn{&t}.Scan(src)
+	if err != nil {
+		return err
+	}
+	*c.t = &t
+	return nil
+}
+
+// scanner is a row of *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRows runs the query and scans all its rows before returning, the
+// single connection of the database is free again once it returns.
+func queryRows[T any](ctx context.Context, q querier, scan func(row scanner) (T, error), query string, args ...interface{}) ([]T, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res []T
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// nextValue returns the next value of the sequence. Unlike in postgres it is
+// rolled back with the transaction.
+func nextValue(ctx context.Context, q querier, name string) (int, error) {
+	var v int
+	err := q.QueryRowContext(ctx, "UPDATE sequences SET value = value + 1 WHERE name = ? RETURNING value", name).Scan(&v)
+	return v, err
+}
+
+// limitOffset returns the LIMIT clause of a page, a zero limit selects all
+// the rows after the offset.
+func limitOffset(limit, offset int) string {
+	if limit <= 0 {
+		limit = -1
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+}
+
+// where collects the conditions of a query with their arguments.
+type where struct {
+	conds []string
+	args  []interface{}
+}
+
+func (w *where) add(cond string, args ...interface{}) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+// String returns the WHERE clause of the conditions, empty without conditions.
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
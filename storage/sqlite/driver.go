@@ -0,0 +1,24 @@
+//go:build sqlite
+// +build sqlite
+
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "modernc.org/sqlite" // for the sqlite driver
+)
+
+// The pure-Go driver is only built with the sqlite tag, it makes the binary
+// a good deal larger.
+func init() {
+	openDB = func(path string) (*sql.DB, error) {
+		// SQLite leaves foreign keys unchecked and fails at once on a locked database by default
+		return sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	}
+	newMigrateDriver = func(db *sql.DB) (database.Driver, error) {
+		return migratesqlite.WithInstance(db, &migratesqlite.Config{})
+	}
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/libreria/models"
+)
+
+// exportChunkSize is how many books ExportBooks reads at a time.
+const exportChunkSize = 500
+
+// ExportBooks calls fn with every book found by the search in the order of
+// their ids. The books are read in chunks and fn runs between the reads, so
+// the export neither holds the database nor the whole catalog. Books written
+// during the export are seen if the export has not passed them yet.
+func (s *Storage) ExportBooks(ctx context.Context, bs *models.BookSearch, fn func(b *models.Book) error) error {
+	lastID := 0
+	for {
+		w := bookSearch(bs)
+		w.add("books.id > ?", lastID)
+		books, err := queryRows(ctx, s.conn(), func(row scanner) (models.Book, error) {
+			return scanBook(row)
+		}, "SELECT "+bookColumns+" FROM books"+w.String()+" ORDER BY books.id"+limitOffset(exportChunkSize, 0), w.args...)
+		if err != nil {
+			return toServiceError(err)
+		}
+		ptrs := make([]*models.Book, len(books))
+		for i := range books {
+			ptrs[i] = &books[i]
+		}
+		err = loadBookAuthors(ctx, s.conn(), ptrs...)
+		if err != nil {
+			return toServiceError(err)
+		}
+		for _, b := range ptrs {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		if len(books) < exportChunkSize {
+			return nil
+		}
+		lastID = books[len(books)-1].ID
+	}
+}
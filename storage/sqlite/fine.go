@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// fineColumns are the columns of a fine read by scanFine.
+const fineColumns = "id, loan_id, patron_id, amount, status, accrued_until, settled_at, created_at, updated_at"
+
+func scanFine(row scanner) (models.Fine, error) {
+	var f models.Fine
+	err := row.Scan(&f.ID, &f.LoanID, &f.PatronID, &f.Amount, &f.Status, timeColumn{&f.AccruedUntil},
+		nullTimeColumn{&f.SettledAt}, timeColumn{&f.CreatedAt}, nullTimeColumn{&f.UpdatedAt})
+	return f, err
+}
+
+func (s *Storage) GetFine(ctx context.Context, id int) (*models.Fine, error) {
+	res, err := scanFine(s.conn().QueryRowContext(ctx, "SELECT "+fineColumns+" FROM fines WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetFines(ctx context.Context, fs *models.FineSearch, limit, offset int) ([]models.Fine, error) {
+	var w where
+	if fs != nil {
+		if fs.PatronID != nil {
+			w.add("patron_id = ?", *fs.PatronID)
+		}
+		if fs.Status != nil {
+			w.add("status = ?", *fs.Status)
+		}
+	}
+	res, err := queryRows(ctx, s.conn(), scanFine, "SELECT "+fineColumns+" FROM fines"+w.String()+" ORDER BY id"+limitOffset(limit, offset), w.args...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// SettleFine pays or waives an open fine. A fine can only be paid once its
+// loan is returned and the fine stopped accruing.
+func (s *Storage) SettleFine(ctx context.Context, id int, status models.FineStatus, settledAt time.Time) (*models.Fine, error) {
+	var res models.Fine
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = scanFine(tx.QueryRowContext(ctx, "SELECT "+fineColumns+" FROM fines WHERE id = ?", id))
+		if err != nil {
+			return err
+		}
+		if res.Status != models.FineOpen {
+			return models.ErrConflict{Message: "fine is already settled"}
+		}
+		if status == models.FinePaid {
+			var accruing bool
+			err = tx.QueryRowContext(ctx, `
+SELECT EXISTS(SELECT 1 FROM loans WHERE id = ? AND (returned_at IS NULL OR returned_at > ?))`,
+				res.LoanID, formatTime(res.AccruedUntil)).Scan(&accruing)
+			if err != nil {
+				return err
+			}
+			if accruing {
+				return models.ErrConflict{Message: "fine is still accruing, return the book first"}
+			}
+		}
+		res, err = scanFine(tx.QueryRowContext(ctx, `
+UPDATE fines
+SET status     = ?,
+    settled_at = ?,
+    updated_at = ?
+WHERE id = ?
+RETURNING `+fineColumns, status, formatTime(settledAt), formatTime(now()), id))
+		return err
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// MarkOverdueLoans marks the open loans that are past their due date at now.
+func (s *Storage) MarkOverdueLoans(ctx context.Context, now time.Time) (int, error) {
+	res, err := s.conn().ExecContext(ctx, `
+UPDATE loans
+SET overdue_at = ?
+WHERE returned_at IS NULL
+  AND overdue_at IS NULL
+  AND due_at < ?`, formatTime(now), formatTime(now))
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return int(n), nil
+}
+
+// accrual is a loan overdue after the grace period with its fine, if any.
+type accrual struct {
+	loan models.Loan
+	fine *models.Fine
+}
+
+// AccrueFines creates or updates the open fines of the loans that are overdue
+// at now, or were returned late after their fine was last accrued. It returns
+// the number of created or updated fines. The amounts are computed here, SQLite
+// has no interval arithmetic.
+func (s *Storage) AccrueFines(ctx context.Context, now time.Time, p models.FinePolicy) (int, error) {
+	var n int
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		n = 0
+		end := formatTime(now)
+		accruals, err := queryRows(ctx, tx, func(row scanner) (accrual, error) {
+			var (
+				a      accrual
+				fineID sql.NullInt64
+				f      models.Fine
+			)
+			err := row.Scan(&a.loan.ID, &a.loan.BorrowerID, timeColumn{&a.loan.DueAt}, nullTimeColumn{&a.loan.ReturnedAt},
+				&fineID, &f.Amount, timeColumn{&f.AccruedUntil})
+			if fineID.Valid {
+				f.ID = int(fineID.Int64)
+				a.fine = &f
+			}
+			return a, err
+		}, `
+SELECT loans.id, loans.borrower_id, loans.due_at, loans.returned_at, fines.id, COALESCE(fines.amount, 0), fines.accrued_until
+FROM loans
+         LEFT JOIN fines ON fines.loan_id = loans.id
+WHERE loans.due_at < COALESCE(loans.returned_at, ?)
+  AND (fines.id IS NULL OR (fines.status = ? AND fines.accrued_until < COALESCE(loans.returned_at, ?)))
+ORDER BY loans.id`, end, models.FineOpen, end)
+		if err != nil {
+			return err
+		}
+		for _, a := range accruals {
+			until := dbTime(now)
+			if a.loan.ReturnedAt != nil {
+				until = *a.loan.ReturnedAt
+			}
+			start := a.loan.DueAt.Add(p.GracePeriod)
+			if !start.Before(until) {
+				continue
+			}
+			days := math.Ceil(until.Sub(start).Seconds() / 86400)
+			amount := p.Amount(int(days))
+			t := formatTime(time.Now())
+			if a.fine == nil {
+				_, err = tx.ExecContext(ctx, `
+INSERT INTO fines (loan_id, patron_id, amount, accrued_until, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)`, a.loan.ID, a.loan.BorrowerID, amount, formatTime(until), t, t)
+			} else if a.fine.Amount == amount && a.fine.AccruedUntil.Equal(until) {
+				continue
+			} else {
+				_, err = tx.ExecContext(ctx, `
+UPDATE fines
+SET amount        = ?,
+    accrued_until = ?,
+    updated_at    = ?
+WHERE id = ?`, amount, formatTime(until), t, a.fine.ID)
+			}
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return n, nil
+}
@@ -0,0 +1,207 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// holdColumns are the columns of a hold read by scanHold.
+const holdColumns = "id, book_id, patron_id, copy_id, status, ready_at, expires_at, created_at, updated_at"
+
+func scanHold(row scanner) (models.Hold, error) {
+	var (
+		h      models.Hold
+		copyID sql.NullInt64
+	)
+	err := row.Scan(&h.ID, &h.BookID, &h.PatronID, &copyID, &h.Status, nullTimeColumn{&h.ReadyAt},
+		nullTimeColumn{&h.ExpiresAt}, timeColumn{&h.CreatedAt}, nullTimeColumn{&h.UpdatedAt})
+	if copyID.Valid {
+		id := int(copyID.Int64)
+		h.CopyID = &id
+	}
+	return h, err
+}
+
+func (s *Storage) CreateHold(ctx context.Context, h *models.Hold) error {
+	t := now()
+	if h.CreatedAt.IsZero() {
+		h.CreatedAt = t
+	}
+	h.CreatedAt, h.UpdatedAt = dbTime(h.CreatedAt), &t
+	var copyID interface{}
+	if h.CopyID != nil {
+		copyID = *h.CopyID
+	}
+	err := s.conn().QueryRowContext(ctx, `
+INSERT INTO holds (book_id, patron_id, copy_id, status, ready_at, expires_at, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+		h.BookID, h.PatronID, copyID, h.Status, nullTime(h.ReadyAt), nullTime(h.ExpiresAt),
+		formatTime(h.CreatedAt), formatTime(t)).Scan(&h.ID)
+	return toServiceError(err)
+}
+
+func (s *Storage) GetHold(ctx context.Context, id int) (*models.Hold, error) {
+	res, err := scanHold(s.conn().QueryRowContext(ctx, "SELECT "+holdColumns+" FROM holds WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// GetHolds returns the holds in the order they were placed.
+func (s *Storage) GetHolds(ctx context.Context, hs *models.HoldSearch, limit, offset int) ([]models.Hold, error) {
+	var w where
+	if hs != nil {
+		if hs.BookID != nil {
+			w.add("book_id = ?", *hs.BookID)
+		}
+		if hs.PatronID != nil {
+			w.add("patron_id = ?", *hs.PatronID)
+		}
+		if hs.CopyID != nil {
+			w.add("copy_id = ?", *hs.CopyID)
+		}
+		if hs.Status != nil {
+			w.add("status = ?", *hs.Status)
+		}
+		if hs.Active != nil {
+			if *hs.Active {
+				w.add("status IN (?, ?)", models.HoldWaiting, models.HoldReady)
+			} else {
+				w.add("status NOT IN (?, ?)", models.HoldWaiting, models.HoldReady)
+			}
+		}
+	}
+	res, err := queryRows(ctx, s.conn(), scanHold, "SELECT "+holdColumns+" FROM holds"+w.String()+" ORDER BY id"+limitOffset(limit, offset), w.args...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// CancelHold cancels an active hold. A copy kept for the hold goes to the next
+// waiting hold, which is ready until expiresAt, or becomes available.
+func (s *Storage) CancelHold(ctx context.Context, id int, expiresAt time.Time) (*models.Hold, error) {
+	var res models.Hold
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = scanHold(tx.QueryRowContext(ctx, `
+UPDATE holds
+SET status     = ?,
+    updated_at = ?
+WHERE id = ?
+  AND status IN (?, ?)
+RETURNING `+holdColumns, models.HoldCancelled, formatTime(now()), id, models.HoldWaiting, models.HoldReady))
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrConflict{Message: "hold is not active"}
+		}
+		if err != nil {
+			return err
+		}
+		if res.CopyID == nil {
+			return nil
+		}
+		return passCopy(ctx, tx, *res.CopyID, res.BookID, models.StatusOnHold, expiresAt)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// ExpireHolds expires the ready holds not picked up by now and passes their
+// copies on like CancelHold does. It returns the number of expired holds.
+func (s *Storage) ExpireHolds(ctx context.Context, now, expiresAt time.Time) (int, error) {
+	var expired []models.Hold
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		expired, err = queryRows(ctx, tx, scanHold, `
+UPDATE holds
+SET status     = ?,
+    updated_at = ?
+WHERE status = ?
+  AND expires_at < ?
+RETURNING `+holdColumns, models.HoldExpired, formatTime(time.Now()), models.HoldReady, formatTime(now))
+		if err != nil {
+			return err
+		}
+		for _, h := range expired {
+			err = passCopy(ctx, tx, *h.CopyID, h.BookID, models.StatusOnHold, expiresAt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	return len(expired), nil
+}
+
+// passCopy gives a copy that became free to the first waiting hold of the book,
+// the hold is ready until expiresAt. Without waiting holds the copy becomes
+// available.
+func passCopy(ctx context.Context, q querier, copyID, bookID int, from models.BookStatus, expiresAt time.Time) error {
+	t := formatTime(now())
+	res, err := q.ExecContext(ctx, `
+UPDATE holds
+SET status     = ?,
+    copy_id    = ?,
+    ready_at   = ?,
+    expires_at = ?,
+    updated_at = ?
+WHERE id = (SELECT id
+            FROM holds
+            WHERE book_id = ?
+              AND status = ?
+            ORDER BY id
+            LIMIT 1)`,
+		models.HoldReady, copyID, t, formatTime(expiresAt), t, bookID, models.HoldWaiting)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	to := models.StatusCheckedIn
+	if n > 0 {
+		to = models.StatusOnHold
+	}
+	if from != to {
+		err = updateCopyStatus(ctx, q, copyID, from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return refreshBookCopies(ctx, q, bookID)
+}
+
+// fulfillHold closes the ready hold of the borrower kept with the copy of the
+// loan. It returns the status the copy has to be checked out from.
+func fulfillHold(ctx context.Context, q querier, l *models.Loan) (models.BookStatus, error) {
+	res, err := q.ExecContext(ctx, `
+UPDATE holds
+SET status     = ?,
+    updated_at = ?
+WHERE copy_id = ?
+  AND patron_id = ?
+  AND status = ?`, models.HoldFulfilled, formatTime(now()), l.CopyID, l.BorrowerID, models.HoldReady)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		return models.StatusOnHold, nil
+	}
+	return models.StatusCheckedIn, nil
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/libreria/models"
+)
+
+// ImportBooks creates the books in one transaction, each in a savepoint so a
+// duplicate or an invalid book is reported without failing the others. A dry
+// run reports the same outcomes and rolls the books back.
+func (s *Storage) ImportBooks(ctx context.Context, books []*models.Book, dryRun bool) ([]models.ImportResult, error) {
+	res := make([]models.ImportResult, len(books))
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		// a dry run in the transaction of the storage is undone by a savepoint
+		_, err := tx.ExecContext(ctx, "SAVEPOINT import_books")
+		if err != nil {
+			return err
+		}
+		for i, b := range books {
+			_, err = tx.ExecContext(ctx, "SAVEPOINT import_book")
+			if err != nil {
+				return err
+			}
+			err = createBook(ctx, tx, b)
+			if err == nil {
+				res[i] = models.ImportResult{Status: models.ImportCreated, BookID: b.ID}
+				_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT import_book")
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			_, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_book")
+			if rbErr != nil {
+				return rbErr
+			}
+			switch e := toServiceError(err).(type) {
+			case models.ErrConflict:
+				res[i] = models.ImportResult{Status: models.ImportDuplicate, Message: e.Message}
+			case models.ErrBadRequest:
+				res[i] = models.ImportResult{Status: models.ImportRejected, Message: e.Message}
+			default:
+				return err
+			}
+		}
+		if dryRun {
+			_, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_books")
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT import_books")
+		return err
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	if dryRun {
+		// the books of a dry run were never created
+		for i := range res {
+			res[i].BookID = 0
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// loanColumns are the columns of a loan read by scanLoan.
+const loanColumns = "id, book_id, copy_id, borrower_id, checked_out_at, due_at, returned_at, overdue_at"
+
+func scanLoan(row scanner) (models.Loan, error) {
+	var l models.Loan
+	err := row.Scan(&l.ID, &l.BookID, &l.CopyID, &l.BorrowerID, timeColumn{&l.CheckedOutAt}, timeColumn{&l.DueAt},
+		nullTimeColumn{&l.ReturnedAt}, nullTimeColumn{&l.OverdueAt})
+	return l, err
+}
+
+// CreateLoan opens a loan and checks the copy out in a single transaction.
+// A loan without a copy is made on any available copy of the book, a copy
+// kept for a hold of the borrower fulfills the hold.
+func (s *Storage) CreateLoan(ctx context.Context, l *models.Loan) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockBook(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		if l.CopyID == 0 {
+			l.CopyID, err = availableCopy(ctx, tx, l.BookID)
+			if err != nil {
+				return err
+			}
+		}
+		from, err := fulfillHold(ctx, tx, l)
+		if err != nil {
+			return err
+		}
+		err = updateCopyStatus(ctx, tx, l.CopyID, from, models.StatusCheckedOut)
+		if err != nil {
+			return err
+		}
+		if l.CheckedOutAt.IsZero() {
+			l.CheckedOutAt = now()
+		}
+		l.CheckedOutAt, l.DueAt = dbTime(l.CheckedOutAt), dbTime(l.DueAt)
+		err = tx.QueryRowContext(ctx, `
+INSERT INTO loans (book_id, copy_id, borrower_id, checked_out_at, due_at, returned_at, overdue_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+			l.BookID, l.CopyID, l.BorrowerID, formatTime(l.CheckedOutAt), formatTime(l.DueAt),
+			nullTime(l.ReturnedAt), nullTime(l.OverdueAt)).Scan(&l.ID)
+		if err != nil {
+			return err
+		}
+		err = refreshBookCopies(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, l.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditCheckout, l.BookID, before, after)
+	})
+	return toServiceError(err)
+}
+
+// CloseLoan closes the open loan of the copy and checks the copy back in. The
+// copy goes to the first waiting hold of the book, which is ready until holdExpiresAt.
+func (s *Storage) CloseLoan(ctx context.Context, copyID int, returnedAt, holdExpiresAt time.Time) (*models.Loan, error) {
+	var res models.Loan
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		res, err = scanLoan(tx.QueryRowContext(ctx, `
+UPDATE loans
+SET returned_at = ?
+WHERE copy_id = ?
+  AND returned_at IS NULL
+RETURNING `+loanColumns, formatTime(returnedAt), copyID))
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrConflict{Message: "copy has no open loan"}
+		}
+		if err != nil {
+			return err
+		}
+		before, err := lockBook(ctx, tx, res.BookID)
+		if _, deleted := err.(models.ErrNotFound); deleted {
+			// copies of deleted books are still returned, there is nothing to audit
+			return passCopy(ctx, tx, copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		}
+		if err != nil {
+			return err
+		}
+		err = passCopy(ctx, tx, copyID, res.BookID, models.StatusCheckedOut, holdExpiresAt)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, res.BookID)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditCheckin, res.BookID, before, after)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetLoans(ctx context.Context, ls *models.LoanSearch, limit, offset int) ([]models.Loan, error) {
+	var w where
+	if ls != nil {
+		if ls.BookID != nil {
+			w.add("book_id = ?", *ls.BookID)
+		}
+		if ls.BorrowerID != nil {
+			w.add("borrower_id = ?", *ls.BorrowerID)
+		}
+		if ls.Open != nil {
+			if *ls.Open {
+				w.add("returned_at IS NULL")
+			} else {
+				w.add("returned_at IS NOT NULL")
+			}
+		}
+		if ls.Overdue {
+			w.add("returned_at IS NULL AND due_at < ?", formatTime(now()))
+		}
+	}
+	res, err := queryRows(ctx, s.conn(), scanLoan, "SELECT "+loanColumns+" FROM loans"+w.String()+" ORDER BY id"+limitOffset(limit, offset), w.args...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
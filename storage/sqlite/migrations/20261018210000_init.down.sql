@@ -0,0 +1,13 @@
+DROP TABLE book_audit;
+DROP TABLE fines;
+DROP TABLE holds;
+DROP TABLE loans;
+DROP TABLE copies;
+DROP TABLE book_ratings;
+DROP TABLE book_authors;
+DROP TABLE books_search;
+DROP TABLE books;
+DROP TABLE authors;
+DROP TABLE publishers;
+DROP TABLE patrons;
+DROP TABLE sequences;
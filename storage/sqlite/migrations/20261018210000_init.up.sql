@@ -0,0 +1,249 @@
+-- the schema of the postgres migrations for SQLite. Times are stored as text
+-- in UTC with microseconds, see timeLayout. The *_folded columns hold the
+-- text without case and accents for the filters, the *_lower columns the
+-- text without case for the unique names.
+
+-- sequences emulates the postgres sequences of generated numbers
+CREATE TABLE sequences
+(
+    name  TEXT    NOT NULL PRIMARY KEY,
+    value INTEGER NOT NULL
+);
+
+INSERT INTO sequences (name, value)
+VALUES ('copies_barcode', 0),
+       ('patrons_membership_number', 0);
+
+CREATE TABLE publishers
+(
+    id          INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    name        TEXT    NOT NULL,
+    name_lower  TEXT    NOT NULL,
+    name_folded TEXT    NOT NULL,
+    created_at  TEXT    NOT NULL,
+    updated_at  TEXT    NOT NULL
+);
+
+CREATE UNIQUE INDEX publishers_name_idx ON publishers (name_lower);
+
+CREATE TABLE authors
+(
+    id          INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    name        TEXT    NOT NULL,
+    name_lower  TEXT    NOT NULL,
+    name_folded TEXT    NOT NULL,
+    created_at  TEXT    NOT NULL,
+    updated_at  TEXT    NOT NULL
+);
+
+CREATE UNIQUE INDEX authors_name_idx ON authors (name_lower);
+
+CREATE TABLE books
+(
+    id               INTEGER           NOT NULL PRIMARY KEY AUTOINCREMENT,
+    title            TEXT              NOT NULL,
+    isbn             TEXT,
+    author           TEXT              NOT NULL,
+    publisher        TEXT              NOT NULL,
+    publisher_id     INTEGER           NOT NULL REFERENCES publishers,
+    publish_date     TEXT,
+    rating           REAL    DEFAULT 0 NOT NULL,
+    rating_count     INTEGER DEFAULT 0 NOT NULL,
+    status           INTEGER DEFAULT 0 NOT NULL,
+    total_copies     INTEGER DEFAULT 0 NOT NULL,
+    available_copies INTEGER DEFAULT 0 NOT NULL,
+    version          INTEGER DEFAULT 1 NOT NULL,
+    title_folded     TEXT              NOT NULL,
+    author_folded    TEXT              NOT NULL,
+    publisher_folded TEXT              NOT NULL,
+    deleted_at       TEXT,
+    created_at       TEXT              NOT NULL,
+    updated_at       TEXT              NOT NULL
+);
+
+-- deleted books keep their isbn, a book with it can be added again
+CREATE UNIQUE INDEX books_isbn_idx ON books (isbn) WHERE deleted_at IS NULL;
+CREATE INDEX books_publisher_id_idx ON books (publisher_id);
+
+-- every write of a book makes a new version of it, the update of the trigger
+-- does not fire it again since recursive triggers are off
+CREATE TRIGGER books_increment_version
+    AFTER UPDATE
+    ON books
+    FOR EACH ROW
+BEGIN
+    UPDATE books SET version = OLD.version + 1 WHERE id = NEW.id;
+END;
+
+-- books_search is the full-text index of the books, weighted like the
+-- search_vector column of postgres when ranked
+CREATE VIRTUAL TABLE books_search USING fts5
+(
+    title,
+    author,
+    publisher,
+    content = 'books',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER books_search_insert
+    AFTER INSERT
+    ON books
+BEGIN
+    INSERT INTO books_search (rowid, title, author, publisher)
+    VALUES (NEW.id, NEW.title, NEW.author, NEW.publisher);
+END;
+
+CREATE TRIGGER books_search_delete
+    AFTER DELETE
+    ON books
+BEGIN
+    INSERT INTO books_search (books_search, rowid, title, author, publisher)
+    VALUES ('delete', OLD.id, OLD.title, OLD.author, OLD.publisher);
+END;
+
+CREATE TRIGGER books_search_update
+    AFTER UPDATE OF title, author, publisher
+    ON books
+BEGIN
+    INSERT INTO books_search (books_search, rowid, title, author, publisher)
+    VALUES ('delete', OLD.id, OLD.title, OLD.author, OLD.publisher);
+    INSERT INTO books_search (rowid, title, author, publisher)
+    VALUES (NEW.id, NEW.title, NEW.author, NEW.publisher);
+END;
+
+CREATE TABLE book_authors
+(
+    book_id   INTEGER NOT NULL REFERENCES books,
+    author_id INTEGER NOT NULL REFERENCES authors,
+    position  INTEGER NOT NULL,
+    PRIMARY KEY (book_id, author_id)
+);
+
+CREATE INDEX book_authors_author_id_idx ON book_authors (author_id);
+
+CREATE TABLE book_ratings
+(
+    book_id    INTEGER NOT NULL REFERENCES books,
+    user_id    TEXT    NOT NULL,
+    rating     INTEGER NOT NULL
+        CONSTRAINT book_ratings_rating_check
+            CHECK (rating BETWEEN 1 AND 3),
+    created_at TEXT    NOT NULL,
+    updated_at TEXT    NOT NULL,
+    PRIMARY KEY (book_id, user_id)
+);
+
+CREATE TABLE copies
+(
+    id         INTEGER                 NOT NULL PRIMARY KEY AUTOINCREMENT,
+    book_id    INTEGER                 NOT NULL REFERENCES books,
+    barcode    TEXT                    NOT NULL,
+    condition  TEXT    DEFAULT 'good'  NOT NULL,
+    location   TEXT    DEFAULT ''      NOT NULL,
+    status     INTEGER DEFAULT 0       NOT NULL,
+    retired_at TEXT,
+    created_at TEXT                    NOT NULL,
+    updated_at TEXT                    NOT NULL
+);
+
+CREATE UNIQUE INDEX copies_barcode_idx ON copies (barcode);
+CREATE INDEX copies_book_id_idx ON copies (book_id);
+
+CREATE TABLE loans
+(
+    id             INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    book_id        INTEGER NOT NULL REFERENCES books,
+    copy_id        INTEGER NOT NULL REFERENCES copies,
+    borrower_id    INTEGER NOT NULL REFERENCES patrons,
+    checked_out_at TEXT    NOT NULL,
+    due_at         TEXT    NOT NULL,
+    returned_at    TEXT,
+    overdue_at     TEXT
+);
+
+-- a copy can have at most one open loan at a time
+CREATE UNIQUE INDEX loans_copy_id_open_idx ON loans (copy_id) WHERE returned_at IS NULL;
+CREATE INDEX loans_book_id_idx ON loans (book_id);
+CREATE INDEX loans_borrower_id_idx ON loans (borrower_id);
+
+CREATE TABLE holds
+(
+    id         INTEGER           NOT NULL PRIMARY KEY AUTOINCREMENT,
+    book_id    INTEGER           NOT NULL REFERENCES books,
+    patron_id  INTEGER           NOT NULL REFERENCES patrons,
+    copy_id    INTEGER REFERENCES copies,
+    status     INTEGER DEFAULT 0 NOT NULL,
+    ready_at   TEXT,
+    expires_at TEXT,
+    created_at TEXT              NOT NULL,
+    updated_at TEXT              NOT NULL
+);
+
+-- a patron can have one waiting or ready hold per book
+CREATE UNIQUE INDEX holds_book_id_patron_id_active_idx ON holds (book_id, patron_id) WHERE status IN (0, 1);
+CREATE INDEX holds_patron_id_idx ON holds (patron_id);
+
+CREATE TABLE fines
+(
+    id            INTEGER           NOT NULL PRIMARY KEY AUTOINCREMENT,
+    loan_id       INTEGER           NOT NULL REFERENCES loans,
+    patron_id     INTEGER           NOT NULL REFERENCES patrons,
+    amount        INTEGER DEFAULT 0 NOT NULL,
+    status        INTEGER DEFAULT 0 NOT NULL,
+    accrued_until TEXT              NOT NULL,
+    settled_at    TEXT,
+    created_at    TEXT              NOT NULL,
+    updated_at    TEXT              NOT NULL
+);
+
+-- a loan has at most one fine, accrual updates it
+CREATE UNIQUE INDEX fines_loan_id_idx ON fines (loan_id);
+CREATE INDEX fines_patron_id_idx ON fines (patron_id);
+
+CREATE TABLE patrons
+(
+    id                INTEGER           NOT NULL PRIMARY KEY AUTOINCREMENT,
+    membership_number TEXT              NOT NULL,
+    name              TEXT              NOT NULL,
+    name_folded       TEXT              NOT NULL,
+    email             TEXT    DEFAULT '' NOT NULL,
+    phone             TEXT    DEFAULT '' NOT NULL,
+    address           TEXT    DEFAULT '' NOT NULL,
+    status            INTEGER DEFAULT 0 NOT NULL,
+    loan_limit        INTEGER           NOT NULL,
+    created_at        TEXT              NOT NULL,
+    updated_at        TEXT              NOT NULL
+);
+
+CREATE UNIQUE INDEX patrons_membership_number_idx ON patrons (membership_number);
+
+CREATE TABLE book_audit
+(
+    id         INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    -- no foreign key, the history outlives purged books
+    book_id    INTEGER NOT NULL,
+    actor      TEXT    NOT NULL,
+    action     TEXT    NOT NULL,
+    before     TEXT,
+    after      TEXT,
+    created_at TEXT    NOT NULL
+);
+
+CREATE INDEX book_audit_book_id_idx ON book_audit (book_id, id);
+
+-- the audit log is append-only
+CREATE TRIGGER book_audit_no_update
+    BEFORE UPDATE
+    ON book_audit
+BEGIN
+    SELECT RAISE(ABORT, 'book_audit is append-only');
+END;
+
+CREATE TRIGGER book_audit_no_delete
+    BEFORE DELETE
+    ON book_audit
+BEGIN
+    SELECT RAISE(ABORT, 'book_audit is append-only');
+END;
@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+// patronColumns are the columns of a patron read by scanPatron.
+const patronColumns = "id, membership_number, name, email, phone, address, status, loan_limit, created_at, updated_at"
+
+func scanPatron(row scanner) (models.Patron, error) {
+	var p models.Patron
+	err := row.Scan(&p.ID, &p.MembershipNumber, &p.Name, &p.Email, &p.Phone, &p.Address, &p.Status, &p.LoanLimit,
+		timeColumn{&p.CreatedAt}, nullTimeColumn{&p.UpdatedAt})
+	return p, err
+}
+
+func (s *Storage) CreatePatron(ctx context.Context, p *models.Patron) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		if p.MembershipNumber == "" {
+			n, err := nextValue(ctx, tx, "patrons_membership_number")
+			if err != nil {
+				return err
+			}
+			p.MembershipNumber = fmt.Sprintf("M%09d", n)
+		}
+		t := now()
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = t
+		}
+		p.CreatedAt, p.UpdatedAt = dbTime(p.CreatedAt), &t
+		return tx.QueryRowContext(ctx, `
+INSERT INTO patrons (membership_number, name, name_folded, email, phone, address, status, loan_limit, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+			p.MembershipNumber, p.Name, textsearch.Fold(p.Name), p.Email, p.Phone, p.Address, p.Status, p.LoanLimit,
+			formatTime(p.CreatedAt), formatTime(t)).Scan(&p.ID)
+	})
+	return toServiceError(err)
+}
+
+func (s *Storage) GetPatron(ctx context.Context, id int) (*models.Patron, error) {
+	res, err := scanPatron(s.conn().QueryRowContext(ctx, "SELECT "+patronColumns+" FROM patrons WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetPatrons(ctx context.Context, ps *models.PatronSearch, limit, offset int) ([]models.Patron, error) {
+	var w where
+	if ps != nil {
+		if ps.Query != "" {
+			q := strings.ToLower(ps.Query)
+			w.add("(instr(name_folded, ?) > 0 OR instr(lower(email), ?) > 0 OR instr(lower(membership_number), ?) > 0)",
+				textsearch.Fold(ps.Query), q, q)
+		}
+		if ps.MembershipNumber != "" {
+			w.add("membership_number = ?", ps.MembershipNumber)
+		}
+		if ps.Status != nil {
+			w.add("status = ?", *ps.Status)
+		}
+	}
+	res, err := queryRows(ctx, s.conn(), scanPatron,
+		"SELECT "+patronColumns+" FROM patrons"+w.String()+" ORDER BY name_folded, name, id"+limitOffset(limit, offset), w.args...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdatePatron updates the patron, the membership number is kept if not given.
+func (s *Storage) UpdatePatron(ctx context.Context, p *models.Patron) error {
+	res, err := scanPatron(s.conn().QueryRowContext(ctx, `
+UPDATE patrons
+SET membership_number = COALESCE(NULLIF(?, ''), membership_number),
+    name              = ?,
+    name_folded       = ?,
+    email             = ?,
+    phone             = ?,
+    address           = ?,
+    status            = ?,
+    loan_limit        = ?,
+    updated_at        = ?
+WHERE id = ?
+RETURNING `+patronColumns,
+		p.MembershipNumber, p.Name, textsearch.Fold(p.Name), p.Email, p.Phone, p.Address, p.Status, p.LoanLimit,
+		formatTime(now()), p.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound{Message: "patron does not exist"}
+	}
+	if err != nil {
+		return toServiceError(err)
+	}
+	*p = res
+	return nil
+}
+
+// DeletePatron deletes a patron who never borrowed or held a book, the others
+// are kept for the loan and hold history and may be suspended instead.
+func (s *Storage) DeletePatron(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		var hasLoans bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM loans WHERE borrower_id = ?)", id).Scan(&hasLoans)
+		if err != nil {
+			return err
+		}
+		if hasLoans {
+			return models.ErrConflict{Message: "patron has loans"}
+		}
+		var hasHolds bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM holds WHERE patron_id = ?)", id).Scan(&hasHolds)
+		if err != nil {
+			return err
+		}
+		if hasHolds {
+			return models.ErrConflict{Message: "patron has holds"}
+		}
+		return deleteRow(ctx, tx, "patrons", id, "patron does not exist")
+	})
+	return toServiceError(err)
+}
+
+// deleteRow deletes the row of the table with the id, it fails with notFound
+// if there is none.
+func deleteRow(ctx context.Context, q querier, table string, id int, notFound string) error {
+	res, err := q.ExecContext(ctx, "DELETE FROM "+table+" WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNotFound{Message: notFound}
+	}
+	return nil
+}
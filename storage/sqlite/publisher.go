@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func scanPublisher(row scanner) (models.Publisher, error) {
+	var p models.Publisher
+	err := scanNamed(row, &p.ID, &p.Name, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+func (s *Storage) CreatePublisher(ctx context.Context, p *models.Publisher) error {
+	err := insertNamed(ctx, s.conn(), "publishers", p.Name, &p.ID, &p.CreatedAt, &p.UpdatedAt)
+	return toServiceError(err)
+}
+
+func (s *Storage) GetPublisher(ctx context.Context, id int) (*models.Publisher, error) {
+	res, err := scanPublisher(s.conn().QueryRowContext(ctx, "SELECT "+namedColumns+" FROM publishers WHERE id = ?", id))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+func (s *Storage) GetPublishers(ctx context.Context, name string, limit, offset int) ([]models.Publisher, error) {
+	res, err := queryRows(ctx, s.conn(), scanPublisher, `
+SELECT `+namedColumns+`
+FROM publishers
+WHERE instr(name_folded, ?) > 0
+ORDER BY name_folded, name, id`+limitOffset(limit, offset), textsearch.Fold(name))
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return res, nil
+}
+
+// UpdatePublisher renames the publisher and the publisher name stored with the publisher's books.
+func (s *Storage) UpdatePublisher(ctx context.Context, p *models.Publisher) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		err := renameNamed(ctx, tx, "publishers", p.ID, p.Name, "publisher does not exist")
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE books SET publisher = ?, publisher_folded = ?, updated_at = ? WHERE publisher_id = ?",
+			p.Name, textsearch.Fold(p.Name), formatTime(now()), p.ID)
+		return err
+	})
+	return toServiceError(err)
+}
+
+// DeletePublisher deletes a publisher without books.
+func (s *Storage) DeletePublisher(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		// soft deleted books still reference the publisher
+		var hasBooks bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE publisher_id = ?)", id).Scan(&hasBooks)
+		if err != nil {
+			return err
+		}
+		if hasBooks {
+			return models.ErrConflict{Message: "publisher has books"}
+		}
+		return deleteRow(ctx, tx, "publishers", id, "publisher does not exist")
+	})
+	return toServiceError(err)
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+// resolveBookRelations loads the authors and the publisher referenced by id
+// and finds or creates the ones given by name only. The names are copied to
+// the author and publisher columns of the book.
+func resolveBookRelations(ctx context.Context, q querier, b *models.Book) error {
+	authors := make([]models.Author, 0, len(b.Authors))
+	names := make([]string, 0, len(b.Authors))
+	seen := make(map[int]bool, len(b.Authors))
+	for _, a := range b.Authors {
+		var err error
+		if a.ID != 0 {
+			err = scanNamed(q.QueryRowContext(ctx, "SELECT "+namedColumns+" FROM authors WHERE id = ?", a.ID), &a.ID, &a.Name, &a.CreatedAt, &a.UpdatedAt)
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrBadRequest{Message: fmt.Sprintf("author %d does not exist", a.ID)}
+			}
+		} else {
+			err = upsertNamed(ctx, q, "authors", a.Name, &a.ID, &a.Name, &a.CreatedAt, &a.UpdatedAt)
+		}
+		if err != nil {
+			return err
+		}
+		if seen[a.ID] {
+			continue
+		}
+		seen[a.ID] = true
+		authors = append(authors, a)
+		names = append(names, a.Name)
+	}
+	b.Authors = authors
+	b.Author = strings.Join(names, ", ")
+
+	p := models.Publisher{ID: b.PublisherID, Name: b.Publisher}
+	var err error
+	if p.ID != 0 {
+		err = scanNamed(q.QueryRowContext(ctx, "SELECT "+namedColumns+" FROM publishers WHERE id = ?", p.ID), &p.ID, &p.Name, &p.CreatedAt, &p.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ErrBadRequest{Message: fmt.Sprintf("publisher %d does not exist", p.ID)}
+		}
+	} else {
+		err = upsertNamed(ctx, q, "publishers", p.Name, &p.ID, &p.Name, &p.CreatedAt, &p.UpdatedAt)
+	}
+	if err != nil {
+		return err
+	}
+	b.PublisherID = p.ID
+	b.Publisher = p.Name
+	return nil
+}
+
+// namedColumns are the columns of authors and publishers.
+const namedColumns = "id, name, created_at, updated_at"
+
+func scanNamed(row scanner, id *int, name *string, createdAt, updatedAt **time.Time) error {
+	return row.Scan(id, name, nullTimeColumn{createdAt}, nullTimeColumn{updatedAt})
+}
+
+// insertNamed inserts an author or a publisher, the names of the table differ
+// in case only.
+func insertNamed(ctx context.Context, q querier, table, name string, id *int, createdAt, updatedAt **time.Time) error {
+	t := now()
+	err := q.QueryRowContext(ctx, `
+INSERT INTO `+table+` (name, name_lower, name_folded, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id`, name, strings.ToLower(name), textsearch.Fold(name), formatTime(t), formatTime(t)).Scan(id)
+	if err != nil {
+		return err
+	}
+	*createdAt, *updatedAt = &t, &t
+	return nil
+}
+
+// upsertNamed finds the author or publisher with the name in any case, or
+// inserts it if there is none.
+func upsertNamed(ctx context.Context, q querier, table, name string, id *int, storedName *string, createdAt, updatedAt **time.Time) error {
+	err := scanNamed(q.QueryRowContext(ctx, "SELECT "+namedColumns+" FROM "+table+" WHERE name_lower = ?", strings.ToLower(name)),
+		id, storedName, createdAt, updatedAt)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	*storedName = name
+	return insertNamed(ctx, q, table, name, id, createdAt, updatedAt)
+}
+
+// linkBookAuthors replaces the author links of the book by its authors.
+func linkBookAuthors(ctx context.Context, q querier, b *models.Book) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM book_authors WHERE book_id = ?", b.ID)
+	if err != nil {
+		return err
+	}
+	for i, a := range b.Authors {
+		_, err = q.ExecContext(ctx, "INSERT INTO book_authors (book_id, author_id, position) VALUES (?, ?, ?)", b.ID, a.ID, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBookAuthors fills the authors of the books in their order.
+func loadBookAuthors(ctx context.Context, q querier, books ...*models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	byID := make(map[int]*models.Book, len(books))
+	ids := make([]interface{}, len(books))
+	for i, b := range books {
+		byID[b.ID] = b
+		ids[i] = b.ID
+		b.Authors = []models.Author{}
+	}
+	type bookAuthor struct {
+		bookID int
+		models.Author
+	}
+	rows, err := queryRows(ctx, q, func(row scanner) (bookAuthor, error) {
+		var r bookAuthor
+		err := row.Scan(&r.bookID, &r.ID, &r.Name, nullTimeColumn{&r.CreatedAt}, nullTimeColumn{&r.UpdatedAt})
+		return r, err
+	}, `
+SELECT book_authors.book_id, authors.id, authors.name, authors.created_at, authors.updated_at
+FROM book_authors
+         JOIN authors ON authors.id = book_authors.author_id
+WHERE book_authors.book_id IN (`+placeholders(len(ids))+`)
+ORDER BY book_authors.book_id, book_authors.position`, ids...)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		byID[r.bookID].Authors = append(byID[r.bookID].Authors, r.Author)
+	}
+	return nil
+}
+
+// placeholders returns the placeholders of n values of an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
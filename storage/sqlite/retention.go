@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/libreria/models"
+)
+
+// RestoreBook undoes the soft deletion of the book.
+func (s *Storage) RestoreBook(ctx context.Context, id int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockDeletedBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return models.ErrConflict{Message: "book is not deleted"}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE books SET deleted_at = NULL, updated_at = ? WHERE id = ?", formatTime(now()), id)
+		if err != nil {
+			return err
+		}
+		after, err := lockBook(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		return auditBook(ctx, tx, models.AuditRestore, id, before, after)
+	})
+	return toServiceError(err)
+}
+
+// PurgeBook permanently deletes the book, deleted or not, with its copies,
+// loans, fines, holds, ratings and author links if it is still at version.
+// The audit log of the book is kept.
+func (s *Storage) PurgeBook(ctx context.Context, id, version int) error {
+	err := s.runInTx(ctx, func(tx *sql.Tx) error {
+		return purgeBook(ctx, tx, id, version)
+	})
+	return toServiceError(err)
+}
+
+// PurgeDeletedBooks permanently deletes the books soft-deleted before
+// deletedBefore. Books that cannot be purged yet are skipped.
+func (s *Storage) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	ids, err := queryRows(ctx, s.conn(), func(row scanner) (int, error) {
+		var id int
+		return id, row.Scan(&id)
+	}, "SELECT id FROM books WHERE deleted_at < ? ORDER BY id", formatTime(deletedBefore))
+	if err != nil {
+		return 0, toServiceError(err)
+	}
+	var n int
+	for _, id := range ids {
+		err = s.runInTx(ctx, func(tx *sql.Tx) error {
+			return purgeBook(ctx, tx, id, 0)
+		})
+		var conflict models.ErrConflict
+		if errors.As(err, &conflict) {
+			continue
+		}
+		if err != nil {
+			return n, toServiceError(err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+func purgeBook(ctx context.Context, q querier, id, version int) error {
+	before, err := lockDeletedBook(ctx, q, id)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(before, version)
+	if err != nil {
+		return err
+	}
+	var open, unsettled bool
+	err = q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM loans WHERE book_id = ? AND returned_at IS NULL)", id).Scan(&open)
+	if err != nil {
+		return err
+	}
+	if open {
+		return models.ErrConflict{Message: "book has open loans"}
+	}
+	err = q.QueryRowContext(ctx, `
+SELECT EXISTS(SELECT 1
+              FROM fines
+              WHERE loan_id IN (SELECT id FROM loans WHERE book_id = ?)
+                AND status = ?)`, id, models.FineOpen).Scan(&unsettled)
+	if err != nil {
+		return err
+	}
+	if unsettled {
+		return models.ErrConflict{Message: "book has unsettled fines"}
+	}
+	_, err = q.ExecContext(ctx, "DELETE FROM fines WHERE loan_id IN (SELECT id FROM loans WHERE book_id = ?)", id)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"holds", "loans", "copies", "book_ratings", "book_authors"} {
+		_, err = q.ExecContext(ctx, "DELETE FROM "+table+" WHERE book_id = ?", id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = q.ExecContext(ctx, "DELETE FROM books WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return auditBook(ctx, q, models.AuditPurge, id, before, nil)
+}
@@ -0,0 +1,350 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/storage/textsearch"
+)
+
+func (s *Storage) GetBooks(ctx context.Context, bs *models.BookSearch, p *models.Page) (*models.BookList, error) {
+	keys, err := bookSortKeys(bs, p.Sort)
+	if err != nil {
+		return nil, err
+	}
+	if p.After != nil && len(p.After.Values) != len(keys) {
+		return nil, models.ErrBadRequest{Message: "cursor does not match sort"}
+	}
+	var res models.BookList
+	w := bookSearch(bs)
+	if p.WithTotal {
+		err = s.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM books"+w.String(), w.args...).Scan(&res.Total)
+		if err != nil {
+			return nil, toServiceError(err)
+		}
+	}
+	rank, args := rankExpr(bs)
+	query := "SELECT " + bookColumns + ", books.rank FROM (SELECT books.*, " + rank + " AS rank FROM books" + w.String() + ") AS books"
+	args = append(args, w.args...)
+	if p.After != nil {
+		cond, condArgs := keysetCondition(keys, p.After)
+		query += " WHERE " + cond
+		args = append(args, condArgs...)
+	}
+	var order []string
+	for _, k := range keys {
+		for _, expr := range k.exprs() {
+			if k.desc {
+				expr += " DESC"
+			}
+			order = append(order, expr)
+		}
+	}
+	order = append(order, "books.id")
+	query += " ORDER BY " + strings.Join(order, ", ")
+	if p.After != nil {
+		// fetch one more row to find out whether there is a next page
+		query += limitOffset(p.Limit+1, 0)
+	} else {
+		query += limitOffset(p.Limit+1, p.Offset)
+	}
+	books, err := queryRows(ctx, s.conn(), scanRankedBook, query, args...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	if len(books) > p.Limit {
+		books = books[:p.Limit]
+		last := books[len(books)-1]
+		res.Next = &models.Cursor{ID: last.ID}
+		for _, sf := range p.Sort {
+			if sf.Field == models.SortRelevance {
+				res.Next.Values = append(res.Next.Values, last.rank)
+			} else {
+				res.Next.Values = append(res.Next.Values, last.SortValue(sf.Field))
+			}
+		}
+	}
+	res.Books = make([]models.Book, len(books))
+	ptrs := make([]*models.Book, len(books))
+	for i, b := range books {
+		res.Books[i] = b.Book
+		ptrs[i] = &res.Books[i]
+	}
+	err = loadBookAuthors(ctx, s.conn(), ptrs...)
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return &res, nil
+}
+
+// rankedBook is a book found by a search with the rank of its match of the
+// full-text query.
+type rankedBook struct {
+	models.Book
+	rank float64
+}
+
+func scanRankedBook(row scanner) (rankedBook, error) {
+	var b rankedBook
+	var err error
+	b.Book, err = scanBook(row, &b.rank)
+	return b, err
+}
+
+// fieldWeights are the weights of the title, author and publisher columns of
+// books_search in the rank of a match, the default weights of ts_rank for the
+// labels A, B and C the postgres storage uses.
+const fieldWeights = "1.0, 0.4, 0.2"
+
+// rankExpr returns the relevance of a book for the full-text query of the
+// search, higher for better matches, and its arguments.
+func rankExpr(bs *models.BookSearch) (string, []interface{}) {
+	if bs == nil || bs.Query == "" {
+		return "0", nil
+	}
+	var words []string
+	for _, alt := range textsearch.ParseQuery(bs.Query) {
+		for _, t := range alt {
+			if !t.Negate {
+				words = append(words, phrase(t))
+			}
+		}
+	}
+	if len(words) == 0 {
+		return "0", nil
+	}
+	// bm25 is lower for better matches
+	return `COALESCE((SELECT -bm25(books_search, ` + fieldWeights + `)
+                  FROM books_search
+                  WHERE books_search MATCH ?
+                    AND books_search.rowid = books.id), 0)`, []interface{}{strings.Join(words, " OR ")}
+}
+
+// matchCondition selects the books matching the full-text query: books
+// matching all the terms of an alternative that are not negated and none of
+// those that are.
+func matchCondition(q textsearch.Query) (string, []interface{}) {
+	var (
+		alts []string
+		args []interface{}
+	)
+	for _, alt := range q {
+		var positive, negative []string
+		for _, t := range alt {
+			if t.Negate {
+				negative = append(negative, phrase(t))
+			} else {
+				positive = append(positive, phrase(t))
+			}
+		}
+		var conds []string
+		if len(positive) > 0 {
+			conds = append(conds, "books.id IN (SELECT rowid FROM books_search WHERE books_search MATCH ?)")
+			args = append(args, strings.Join(positive, " AND "))
+		}
+		if len(negative) > 0 {
+			conds = append(conds, "books.id NOT IN (SELECT rowid FROM books_search WHERE books_search MATCH ?)")
+			args = append(args, strings.Join(negative, " OR "))
+		}
+		if len(conds) > 0 {
+			alts = append(alts, "("+strings.Join(conds, " AND ")+")")
+		}
+	}
+	if len(alts) == 0 {
+		// a query without words matches nothing like an empty tsquery
+		return "0", nil
+	}
+	return "(" + strings.Join(alts, " OR ") + ")", args
+}
+
+// phrase quotes the words of a term as an FTS5 phrase, the words are letters
+// and digits only.
+func phrase(t textsearch.Term) string {
+	return `"` + strings.Join(t.Words, " ") + `"`
+}
+
+// bookSearch returns the conditions selecting the books of the search.
+func bookSearch(bs *models.BookSearch) *where {
+	w := &where{}
+	deleted := models.DeletedExclude
+	if bs != nil {
+		deleted = bs.Deleted
+	}
+	switch deleted {
+	case models.DeletedExclude:
+		w.add("books.deleted_at IS NULL")
+	case models.DeletedOnly:
+		w.add("books.deleted_at IS NOT NULL")
+	}
+	if bs == nil {
+		return w
+	}
+	if bs.Query != "" {
+		cond, args := matchCondition(textsearch.ParseQuery(bs.Query))
+		w.add(cond, args...)
+	}
+	// field filters ignore case and accents
+	if bs.Title != "" {
+		w.add("instr(books.title_folded, ?) > 0", textsearch.Fold(bs.Title))
+	}
+	if bs.Author != "" {
+		w.add("instr(books.author_folded, ?) > 0", textsearch.Fold(bs.Author))
+	}
+	if bs.Publisher != "" {
+		w.add("instr(books.publisher_folded, ?) > 0", textsearch.Fold(bs.Publisher))
+	}
+	if bs.Status != nil {
+		w.add("books.status = ?", *bs.Status)
+	}
+	if pds := bs.PublishDateSearch; pds != nil {
+		d, err := time.Parse("2006-01-02", pds.PublishDate)
+		if err != nil || !isFilterOp(pds.Condition) {
+			w.add("0")
+		} else {
+			w.add("books.publish_date "+pds.Condition+" ?", formatTime(d))
+		}
+	}
+	if bs.AuthorID != nil {
+		w.add("books.id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", *bs.AuthorID)
+	}
+	if bs.PublisherID != nil {
+		w.add("books.publisher_id = ?", *bs.PublisherID)
+	}
+	return w
+}
+
+// isFilterOp tells whether op is one of the operators of models.FilterMap.
+func isFilterOp(op string) bool {
+	for _, v := range models.FilterMap {
+		if v == op {
+			return true
+		}
+	}
+	return false
+}
+
+// bookSortFields are the fields books can be sorted by besides the relevance.
+var bookSortFields = map[string]bool{
+	"id":           true,
+	"title":        true,
+	"author":       true,
+	"publisher":    true,
+	"publish_date": true,
+	"rating":       true,
+}
+
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+func bookSortKeys(bs *models.BookSearch, sort []models.SortField) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(sort))
+	for _, sf := range sort {
+		if sf.Field == models.SortRelevance {
+			if bs == nil || bs.Query == "" {
+				return nil, models.ErrBadRequest{Message: "sorting by relevance requires a search query"}
+			}
+		} else if !bookSortFields[sf.Field] {
+			return nil, models.ErrBadRequest{Message: "unknown sort field " + sf.Field}
+		}
+		keys = append(keys, sortKey{field: sf.Field, desc: sf.Desc})
+	}
+	return keys, nil
+}
+
+// exprs returns the expressions the books are ordered by for the key. Text
+// is ordered roughly like a linguistic collation: by its letters ignoring
+// case and accents first.
+func (k sortKey) exprs() []string {
+	switch k.field {
+	case "title", "author", "publisher":
+		return []string{"books." + k.field + "_folded", "books." + k.field}
+	case "publish_date":
+		return []string{"COALESCE(books.publish_date, '')"}
+	case models.SortRelevance:
+		return []string{"books.rank"}
+	default:
+		return []string{"books." + k.field}
+	}
+}
+
+// values returns the values of the expressions of the key for a value of a
+// cursor, which may have been through JSON.
+func (k sortKey) values(v interface{}) []interface{} {
+	switch k.field {
+	case "title", "author", "publisher":
+		s, _ := v.(string)
+		return []interface{}{textsearch.Fold(s), s}
+	case "publish_date":
+		t, ok := v.(time.Time)
+		if s, isString := v.(string); isString {
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, s)
+			ok = err == nil
+		}
+		if !ok || t.IsZero() {
+			return []interface{}{""}
+		}
+		return []interface{}{formatTime(t)}
+	case "id":
+		f, _ := number(v)
+		return []interface{}{int(f)}
+	default:
+		f, _ := number(v)
+		return []interface{}{f}
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// keysetCondition selects the rows following the cursor in the sort order:
+// (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z) for "a,-b".
+func keysetCondition(keys []sortKey, after *models.Cursor) (string, []interface{}) {
+	type column struct {
+		expr  string
+		value interface{}
+		desc  bool
+	}
+	var columns []column
+	for i, k := range keys {
+		values := k.values(after.Values[i])
+		for j, expr := range k.exprs() {
+			columns = append(columns, column{expr: expr, value: values[j], desc: k.desc})
+		}
+	}
+	columns = append(columns, column{expr: "books.id", value: after.ID})
+	var (
+		groups []string
+		args   []interface{}
+	)
+	for i, c := range columns {
+		var conds []string
+		for _, prev := range columns[:i] {
+			conds = append(conds, prev.expr+" = ?")
+			args = append(args, prev.value)
+		}
+		op := ">"
+		if c.desc {
+			op = "<"
+		}
+		conds = append(conds, c.expr+" "+op+" ?")
+		args = append(args, c.value)
+		groups = append(groups, "("+strings.Join(conds, " AND ")+")")
+	}
+	return "(" + strings.Join(groups, " OR ") + ")", args
+}
@@ -0,0 +1,33 @@
+//go:build unit && sqlite
+// +build unit,sqlite
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/libreria/service/book"
+	"github.com/libreria/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorage runs the storage conformance suite on a new database file for
+// every test.
+func TestStorage(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) book.StorageManager {
+		path := filepath.Join(t.TempDir(), "libreria.db")
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		s, err := New(ctx, wg, Config{Path: path})
+		require.NoError(t, err)
+		// the database is closed before its directory is removed
+		t.Cleanup(func() {
+			cancel()
+			wg.Wait()
+		})
+		return s
+	})
+}
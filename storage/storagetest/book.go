@@ -0,0 +1,217 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBooks(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	b := newBook("The Left Hand of Darkness", "9780441478125")
+	req.NoError(s.CreateBook(ctx, b))
+	a.NotZero(b.ID)
+	a.Equal(1, b.TotalCopies)
+	a.Equal(1, b.AvailableCopies)
+	a.Equal(models.StatusCheckedIn, b.Status)
+	a.NotZero(b.Version)
+
+	stored := getBook(t, s, b.ID)
+	a.Equal("The Left Hand of Darkness", stored.Title)
+	a.Equal("Ursula K. Le Guin", stored.Author)
+	req.Len(stored.Authors, 1)
+	a.Equal("Ursula K. Le Guin", stored.Authors[0].Name)
+	a.Equal("Ace Books", stored.Publisher)
+	a.NotZero(stored.PublisherID)
+	a.True(stored.PublishDate.Equal(date(1969, time.March, 1)))
+	a.Equal(b.Version, stored.Version)
+
+	byISBN, err := s.GetBookByISBN(ctx, "9780441478125")
+	req.NoError(err)
+	a.Equal(b.ID, byISBN.ID)
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := s.GetBook(ctx, b.ID+1000)
+		assert.IsType(t, models.ErrNotFound{}, err)
+		_, err = s.GetBookByISBN(ctx, "0000000000")
+		assert.IsType(t, models.ErrNotFound{}, err)
+		err = s.UpdateBook(ctx, &models.Book{ID: b.ID + 1000, Title: "missing"})
+		assert.IsType(t, models.ErrNotFound{}, err)
+	})
+	t.Run("duplicate isbn", func(t *testing.T) {
+		err := s.CreateBook(ctx, newBook("Another", "9780441478125"))
+		assert.Equal(t, models.ErrConflict{Message: "book with this isbn already exists"}, err)
+	})
+	t.Run("unknown author", func(t *testing.T) {
+		nb := newBook("Unknown author", "")
+		nb.Authors = []models.Author{{ID: 100000}}
+		err := s.CreateBook(ctx, nb)
+		assert.IsType(t, models.ErrBadRequest{}, err)
+	})
+	t.Run("update", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		u := getBook(t, s, b.ID)
+		version := u.Version
+		u.Title = "The Left Hand of Darkness (Ace)"
+		u.Authors = []models.Author{{Name: "Ursula K. Le Guin"}, {Name: "Harold Bloom"}}
+		req.NoError(s.UpdateBook(ctx, u))
+		a.Equal(version+1, u.Version)
+		stored := getBook(t, s, b.ID)
+		a.Equal("The Left Hand of Darkness (Ace)", stored.Title)
+		a.Equal("Ursula K. Le Guin, Harold Bloom", stored.Author)
+		a.Len(stored.Authors, 2)
+		a.Equal(version+1, stored.Version)
+
+		// an update changing nothing keeps the version
+		req.NoError(s.UpdateBook(ctx, stored))
+		a.Equal(version+1, stored.Version)
+		a.Equal(version+1, getBook(t, s, b.ID).Version)
+
+		stale := getBook(t, s, b.ID)
+		stale.Version = version
+		stale.Title = "stale"
+		a.Equal(models.ErrPreconditionFailed{Message: "book has been modified"}, s.UpdateBook(ctx, stale))
+		a.Equal(models.ErrPreconditionFailed{Message: "book has been modified"}, s.DeleteBook(ctx, b.ID, version))
+	})
+	t.Run("history", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		req.NoError(s.DeleteBook(ctx, b.ID, 0))
+		history, err := s.GetBookHistory(ctx, b.ID, 10, 0)
+		req.NoError(err)
+		req.Len(history, 3)
+		a.Equal(models.AuditCreate, history[0].Action)
+		a.Nil(history[0].Before)
+		a.Equal(models.AuditUpdate, history[1].Action)
+		a.Contains(history[1].After, "title")
+		a.NotContains(history[1].After, "isbn")
+		a.Equal(models.AuditDelete, history[2].Action)
+		a.Nil(history[2].After)
+
+		history, err = s.GetBookHistory(ctx, b.ID, 1, 1)
+		req.NoError(err)
+		req.Len(history, 1)
+		a.Equal(models.AuditUpdate, history[0].Action)
+	})
+}
+
+func testRatings(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	b := createBook(t, s, newBook("A Wizard of Earthsea", ""))
+	req.NoError(s.RateBook(ctx, &models.Rating{BookID: b.ID, UserID: "alice", Rating: 3}))
+	req.NoError(s.RateBook(ctx, &models.Rating{BookID: b.ID, UserID: "bob", Rating: 1}))
+	rated := getBook(t, s, b.ID)
+	a.InDelta(2.0, rated.Rating, 1e-9)
+	a.Equal(2, rated.RatingCount)
+	a.Equal(b.Version+2, rated.Version)
+
+	// a user rating again replaces the rating
+	req.NoError(s.RateBook(ctx, &models.Rating{BookID: b.ID, UserID: "alice", Rating: 2}))
+	rated = getBook(t, s, b.ID)
+	a.InDelta(1.5, rated.Rating, 1e-9)
+	a.Equal(2, rated.RatingCount)
+
+	a.Error(s.RateBook(ctx, &models.Rating{BookID: b.ID, UserID: "carol", Rating: 4}))
+	a.IsType(models.ErrNotFound{}, s.RateBook(ctx, &models.Rating{BookID: b.ID + 1000, UserID: "alice", Rating: 1}))
+
+	history, err := s.GetBookHistory(ctx, b.ID, 10, 0)
+	req.NoError(err)
+	req.Len(history, 4)
+	a.Equal(models.AuditRate, history[3].Action)
+}
+
+func testRetention(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	deleted := createBook(t, s, newBook("The Dispossessed", "9780061054884"))
+	req.NoError(s.DeleteBook(ctx, deleted.ID, deleted.Version))
+	_, err := s.GetBook(ctx, deleted.ID)
+	a.IsType(models.ErrNotFound{}, err)
+	a.IsType(models.ErrNotFound{}, s.DeleteBook(ctx, deleted.ID, 0))
+
+	t.Run("restore", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		req.NoError(s.RestoreBook(ctx, deleted.ID))
+		restored := getBook(t, s, deleted.ID)
+		a.Equal(deleted.Version+2, restored.Version)
+		a.Equal(models.ErrConflict{Message: "book is not deleted"}, s.RestoreBook(ctx, deleted.ID))
+		a.IsType(models.ErrNotFound{}, s.RestoreBook(ctx, deleted.ID+1000))
+	})
+	t.Run("isbn of a deleted book", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		req.NoError(s.DeleteBook(ctx, deleted.ID, 0))
+		// the isbn is free again while the book is deleted
+		reused := createBook(t, s, newBook("The Dispossessed (reprint)", "9780061054884"))
+		a.Equal(models.ErrConflict{Message: "book with this isbn already exists"}, s.RestoreBook(ctx, deleted.ID))
+		req.NoError(s.PurgeBook(ctx, reused.ID, reused.Version))
+		req.NoError(s.RestoreBook(ctx, deleted.ID))
+	})
+	t.Run("purge", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		b := createBook(t, s, newBook("Purged", ""))
+		borrower := createPatron(t, s, "Shevek")
+		req.NoError(s.CreateLoan(ctx, &models.Loan{BookID: b.ID, BorrowerID: borrower.ID, DueAt: time.Now().UTC().Add(time.Hour)}))
+		a.Equal(models.ErrConflict{Message: "book has open loans"}, s.PurgeBook(ctx, b.ID, 0))
+		a.Equal(models.ErrPreconditionFailed{Message: "book has been modified"}, s.PurgeBook(ctx, b.ID, b.Version))
+
+		copies, err := s.GetCopies(ctx, b.ID)
+		req.NoError(err)
+		_, err = s.CloseLoan(ctx, copies[0].ID, time.Now().UTC(), time.Now().UTC())
+		req.NoError(err)
+		req.NoError(s.PurgeBook(ctx, b.ID, 0))
+		_, err = s.GetBook(ctx, b.ID)
+		a.IsType(models.ErrNotFound{}, err)
+		copies, err = s.GetCopies(ctx, b.ID)
+		req.NoError(err)
+		a.Empty(copies)
+		loans, err := s.GetLoans(ctx, &models.LoanSearch{BookID: &b.ID}, 10, 0)
+		req.NoError(err)
+		a.Empty(loans)
+		// the audit log outlives the book
+		history, err := s.GetBookHistory(ctx, b.ID, 10, 0)
+		req.NoError(err)
+		req.NotEmpty(history)
+		a.Equal(models.AuditPurge, history[len(history)-1].Action)
+	})
+	t.Run("purge deleted books", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		old := createBook(t, s, newBook("Old", ""))
+		lent := createBook(t, s, newBook("Lent", ""))
+		kept := createBook(t, s, newBook("Kept", ""))
+		borrower := createPatron(t, s, "Takver")
+		req.NoError(s.CreateLoan(ctx, &models.Loan{BookID: lent.ID, BorrowerID: borrower.ID, DueAt: time.Now().UTC().Add(time.Hour)}))
+		req.NoError(s.DeleteBook(ctx, old.ID, 0))
+		req.NoError(s.DeleteBook(ctx, lent.ID, 0))
+		req.NoError(s.DeleteBook(ctx, deleted.ID, 0))
+
+		// books with open loans are skipped
+		n, err := s.PurgeDeletedBooks(ctx, time.Now().UTC().Add(time.Hour))
+		req.NoError(err)
+		a.Equal(2, n)
+		list, err := s.GetBooks(ctx, &models.BookSearch{Deleted: models.DeletedInclude}, &models.Page{Limit: 10})
+		req.NoError(err)
+		a.ElementsMatch([]int{lent.ID, kept.ID}, bookIDs(list.Books))
+
+		n, err = s.PurgeDeletedBooks(ctx, time.Now().UTC().Add(-time.Hour))
+		req.NoError(err)
+		a.Zero(n)
+	})
+}
@@ -0,0 +1,220 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAuthors(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	author := &models.Author{Name: "Octavia E. Butler"}
+	req.NoError(s.CreateAuthor(ctx, author))
+	a.NotZero(author.ID)
+	a.Equal(models.ErrConflict{Message: "author already exists"}, s.CreateAuthor(ctx, &models.Author{Name: "octavia e. butler"}))
+	other := &models.Author{Name: "Émile Zola"}
+	req.NoError(s.CreateAuthor(ctx, other))
+
+	authors, err := s.GetAuthors(ctx, "", 10, 0)
+	req.NoError(err)
+	a.Len(authors, 2)
+	authors, err = s.GetAuthors(ctx, "emile", 10, 0)
+	req.NoError(err)
+	req.Len(authors, 1)
+	a.Equal(other.ID, authors[0].ID)
+
+	b := newBook("Kindred", "")
+	b.Authors = []models.Author{{ID: author.ID}}
+	b = createBook(t, s, b)
+	a.Equal("Octavia E. Butler", b.Author)
+	// authors given by name are found in any case
+	byName := newBook("Dawn", "")
+	byName.Authors = []models.Author{{Name: "OCTAVIA E. BUTLER"}}
+	byName = createBook(t, s, byName)
+	req.Len(byName.Authors, 1)
+	a.Equal(author.ID, byName.Authors[0].ID)
+
+	req.NoError(s.UpdateAuthor(ctx, &models.Author{ID: author.ID, Name: "Octavia Estelle Butler"}))
+	renamed := getBook(t, s, b.ID)
+	a.Equal("Octavia Estelle Butler", renamed.Author)
+	a.Equal(b.Version+1, renamed.Version)
+	stored, err := s.GetAuthor(ctx, author.ID)
+	req.NoError(err)
+	a.Equal("Octavia Estelle Butler", stored.Name)
+	a.Equal(models.ErrNotFound{Message: "author does not exist"}, s.UpdateAuthor(ctx, &models.Author{ID: author.ID + 1000, Name: "missing"}))
+	a.IsType(models.ErrConflict{}, s.UpdateAuthor(ctx, &models.Author{ID: other.ID, Name: "octavia estelle butler"}))
+
+	a.Equal(models.ErrConflict{Message: "author has books"}, s.DeleteAuthor(ctx, author.ID))
+	req.NoError(s.DeleteAuthor(ctx, other.ID))
+	_, err = s.GetAuthor(ctx, other.ID)
+	a.IsType(models.ErrNotFound{}, err)
+	a.Equal(models.ErrNotFound{Message: "author does not exist"}, s.DeleteAuthor(ctx, other.ID))
+}
+
+func testPublishers(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	publisher := &models.Publisher{Name: "Doubleday"}
+	req.NoError(s.CreatePublisher(ctx, publisher))
+	a.NotZero(publisher.ID)
+	a.Equal(models.ErrConflict{Message: "publisher already exists"}, s.CreatePublisher(ctx, &models.Publisher{Name: "DOUBLEDAY"}))
+	other := &models.Publisher{Name: "Éditions Gallimard"}
+	req.NoError(s.CreatePublisher(ctx, other))
+
+	publishers, err := s.GetPublishers(ctx, "gallimard", 10, 0)
+	req.NoError(err)
+	req.Len(publishers, 1)
+	a.Equal(other.ID, publishers[0].ID)
+	publishers, err = s.GetPublishers(ctx, "", 1, 1)
+	req.NoError(err)
+	req.Len(publishers, 1)
+	a.Equal(other.ID, publishers[0].ID)
+
+	b := newBook("Parable of the Sower", "")
+	b.Publisher, b.PublisherID = "", publisher.ID
+	b = createBook(t, s, b)
+	a.Equal("Doubleday", b.Publisher)
+	req.NoError(s.DeleteBook(ctx, b.ID, 0))
+
+	req.NoError(s.UpdatePublisher(ctx, &models.Publisher{ID: publisher.ID, Name: "Anchor Books"}))
+	renamed, err := s.GetBooks(ctx, &models.BookSearch{Deleted: models.DeletedOnly}, &models.Page{Limit: 10})
+	req.NoError(err)
+	req.Len(renamed.Books, 1)
+	a.Equal("Anchor Books", renamed.Books[0].Publisher)
+	stored, err := s.GetPublisher(ctx, publisher.ID)
+	req.NoError(err)
+	a.Equal("Anchor Books", stored.Name)
+	a.Equal(models.ErrNotFound{Message: "publisher does not exist"}, s.UpdatePublisher(ctx, &models.Publisher{ID: publisher.ID + 1000, Name: "missing"}))
+
+	// soft deleted books still keep their publisher
+	a.Equal(models.ErrConflict{Message: "publisher has books"}, s.DeletePublisher(ctx, publisher.ID))
+	req.NoError(s.DeletePublisher(ctx, other.ID))
+	_, err = s.GetPublisher(ctx, other.ID)
+	a.IsType(models.ErrNotFound{}, err)
+	a.Equal(models.ErrNotFound{Message: "publisher does not exist"}, s.DeletePublisher(ctx, other.ID))
+
+	err = s.CreateBook(ctx, &models.Book{Title: "No publisher", PublisherID: other.ID})
+	a.IsType(models.ErrBadRequest{}, err)
+}
+
+func testTransactions(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	errRollback := errors.New("rollback")
+	err := s.RunInTx(ctx, func(tx book.StorageManager) error {
+		req.NoError(tx.CreateBook(ctx, newBook("Rolled back", "9780000000001")))
+		// the transaction sees its own writes
+		_, err := tx.GetBookByISBN(ctx, "9780000000001")
+		req.NoError(err)
+		// nested transactions run in the enclosing one
+		return tx.RunInTx(ctx, func(tx book.StorageManager) error {
+			req.NoError(tx.CreatePatron(ctx, &models.Patron{Name: "Rolled back", MembershipNumber: "TX-1"}))
+			return errRollback
+		})
+	})
+	a.True(errors.Is(err, errRollback))
+	_, err = s.GetBookByISBN(ctx, "9780000000001")
+	a.IsType(models.ErrNotFound{}, err)
+	patrons, err := s.GetPatrons(ctx, &models.PatronSearch{Query: "TX-1"}, 10, 0)
+	req.NoError(err)
+	a.Empty(patrons)
+
+	var id int
+	err = s.RunInTx(ctx, func(tx book.StorageManager) error {
+		b := newBook("Committed", "9780000000002")
+		err := tx.CreateBook(ctx, b)
+		id = b.ID
+		return err
+	})
+	req.NoError(err)
+	a.Equal("Committed", getBook(t, s, id).Title)
+}
+
+func testImport(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	existing := createBook(t, s, newBook("Existing", "9780000000010"))
+	batch := func() []*models.Book {
+		rejected := newBook("Rejected", "9780000000012")
+		rejected.Authors = []models.Author{{ID: 100000}}
+		return []*models.Book{
+			newBook("Created", "9780000000011"),
+			newBook("Duplicate of a stored book", existing.ISBN),
+			newBook("Duplicate of an imported book", "9780000000011"),
+			rejected,
+		}
+	}
+	statuses := func(res []models.ImportResult) []models.ImportStatus {
+		st := make([]models.ImportStatus, len(res))
+		for i, r := range res {
+			st[i] = r.Status
+		}
+		return st
+	}
+	want := []models.ImportStatus{models.ImportCreated, models.ImportDuplicate, models.ImportDuplicate, models.ImportRejected}
+
+	res, err := s.ImportBooks(ctx, batch(), true)
+	req.NoError(err)
+	a.Equal(want, statuses(res))
+	a.Zero(res[0].BookID)
+	a.Equal("book with this isbn already exists", res[1].Message)
+	a.Equal("author 100000 does not exist", res[3].Message)
+	_, err = s.GetBookByISBN(ctx, "9780000000011")
+	a.IsType(models.ErrNotFound{}, err)
+
+	res, err = s.ImportBooks(ctx, batch(), false)
+	req.NoError(err)
+	a.Equal(want, statuses(res))
+	created, err := s.GetBookByISBN(ctx, "9780000000011")
+	req.NoError(err)
+	a.Equal(res[0].BookID, created.ID)
+	a.Equal(1, created.TotalCopies)
+	list, err := s.GetBooks(ctx, &models.BookSearch{}, &models.Page{Limit: 10, WithTotal: true})
+	req.NoError(err)
+	a.Equal(2, list.Total)
+}
+
+func testExport(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	first := createBook(t, s, newBook("The Word for World Is Forest", ""))
+	deleted := createBook(t, s, newBook("The World Deleted", ""))
+	second := createBook(t, s, newBook("Worlds of Exile and Illusion", ""))
+	createBook(t, s, newBook("Orsinian Tales", ""))
+	req.NoError(s.DeleteBook(ctx, deleted.ID, 0))
+
+	var exported []models.Book
+	err := s.ExportBooks(ctx, &models.BookSearch{Title: "world"}, func(b *models.Book) error {
+		exported = append(exported, *b)
+		return nil
+	})
+	req.NoError(err)
+	a.Equal([]int{first.ID, second.ID}, bookIDs(exported))
+	req.Len(exported[0].Authors, 1)
+	a.Equal("Ursula K. Le Guin", exported[0].Authors[0].Name)
+
+	errStop := errors.New("stop")
+	var n int
+	err = s.ExportBooks(ctx, &models.BookSearch{}, func(b *models.Book) error {
+		n++
+		return errStop
+	})
+	a.True(errors.Is(err, errStop))
+	a.Equal(1, n)
+}
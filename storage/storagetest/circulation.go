@@ -0,0 +1,350 @@
+package storagetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCopies(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+	expiresAt := time.Now().UTC().Add(48 * time.Hour)
+
+	b := createBook(t, s, newBook("The Lathe of Heaven", ""))
+	c := &models.Copy{BookID: b.ID, Location: "shelf 2"}
+	req.NoError(s.CreateCopy(ctx, c, expiresAt))
+	a.NotZero(c.ID)
+	a.True(strings.HasPrefix(c.Barcode, "LIB"), c.Barcode)
+	a.Equal("good", c.Condition)
+	a.Equal("shelf 2", c.Location)
+	a.Equal(models.StatusCheckedIn, c.Status)
+	counted := getBook(t, s, b.ID)
+	a.Equal(2, counted.TotalCopies)
+	a.Equal(2, counted.AvailableCopies)
+	a.Equal(b.Version+1, counted.Version)
+
+	stored, err := s.GetCopy(ctx, c.ID)
+	req.NoError(err)
+	a.Equal(c.Barcode, stored.Barcode)
+	_, err = s.GetCopy(ctx, c.ID+1000)
+	a.IsType(models.ErrNotFound{}, err)
+
+	err = s.CreateCopy(ctx, &models.Copy{BookID: b.ID, Barcode: c.Barcode}, expiresAt)
+	a.Equal(models.ErrConflict{Message: "copy with this barcode already exists"}, err)
+	a.Error(s.CreateCopy(ctx, &models.Copy{BookID: b.ID + 1000}, expiresAt))
+
+	retired, err := s.RetireCopy(ctx, c.ID, time.Now().UTC())
+	req.NoError(err)
+	a.NotNil(retired.RetiredAt)
+	counted = getBook(t, s, b.ID)
+	a.Equal(1, counted.TotalCopies)
+	a.Equal(1, counted.AvailableCopies)
+	_, err = s.RetireCopy(ctx, c.ID, time.Now().UTC())
+	a.Equal(models.ErrConflict{Message: "copy is checked out, on hold or already retired"}, err)
+
+	copies, err := s.GetCopies(ctx, b.ID)
+	req.NoError(err)
+	req.Len(copies, 2)
+	a.Nil(copies[0].RetiredAt)
+	a.Equal(c.ID, copies[1].ID)
+}
+
+func testLoans(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	b := createBook(t, s, newBook("Always Coming Home", ""))
+	borrower, other := createPatron(t, s, "Stone Telling"), createPatron(t, s, "Pandora")
+	l := &models.Loan{BookID: b.ID, BorrowerID: borrower.ID, DueAt: now.Add(-time.Hour)}
+	req.NoError(s.CreateLoan(ctx, l))
+	a.NotZero(l.ID)
+	a.NotZero(l.CopyID)
+	lent := getBook(t, s, b.ID)
+	a.Equal(models.StatusCheckedOut, lent.Status)
+	a.Zero(lent.AvailableCopies)
+	a.Equal(b.Version+1, lent.Version)
+
+	err := s.CreateLoan(ctx, &models.Loan{BookID: b.ID, BorrowerID: other.ID, DueAt: now})
+	a.Equal(models.ErrConflict{Message: "book has no available copy"}, err)
+	err = s.CreateLoan(ctx, &models.Loan{BookID: b.ID, CopyID: l.CopyID, BorrowerID: other.ID, DueAt: now})
+	a.IsType(models.ErrConflict{}, err)
+	err = s.CreateLoan(ctx, &models.Loan{BookID: b.ID + 1000, BorrowerID: other.ID, DueAt: now})
+	a.IsType(models.ErrNotFound{}, err)
+
+	open := true
+	loans, err := s.GetLoans(ctx, &models.LoanSearch{BorrowerID: &borrower.ID, Open: &open, Overdue: true}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{l.ID}, loanIDs(loans))
+
+	returned, err := s.CloseLoan(ctx, l.CopyID, now, now.Add(48*time.Hour))
+	req.NoError(err)
+	a.Equal(l.ID, returned.ID)
+	req.NotNil(returned.ReturnedAt)
+	a.True(returned.ReturnedAt.Equal(now))
+	back := getBook(t, s, b.ID)
+	a.Equal(models.StatusCheckedIn, back.Status)
+	a.Equal(1, back.AvailableCopies)
+	a.Equal(lent.Version+1, back.Version)
+	_, err = s.CloseLoan(ctx, l.CopyID, now, now)
+	a.Equal(models.ErrConflict{Message: "copy has no open loan"}, err)
+
+	loans, err = s.GetLoans(ctx, &models.LoanSearch{BookID: &b.ID, Open: &open}, 10, 0)
+	req.NoError(err)
+	a.Empty(loans)
+	loans, err = s.GetLoans(ctx, &models.LoanSearch{BookID: &b.ID}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{l.ID}, loanIDs(loans))
+
+	history, err := s.GetBookHistory(ctx, b.ID, 10, 0)
+	req.NoError(err)
+	req.Len(history, 3)
+	a.Equal(models.AuditCheckout, history[1].Action)
+	a.Equal(models.AuditCheckin, history[2].Action)
+}
+
+func testHolds(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(48 * time.Hour)
+
+	b := createBook(t, s, newBook("The Telling", ""))
+	patrons := make([]*models.Patron, 4)
+	for i, name := range []string{"Sutty", "Yara", "Odiedin", "Tong"} {
+		patrons[i] = createPatron(t, s, name)
+	}
+	l := &models.Loan{BookID: b.ID, BorrowerID: patrons[0].ID, DueAt: now.Add(time.Hour)}
+	req.NoError(s.CreateLoan(ctx, l))
+	first := &models.Hold{BookID: b.ID, PatronID: patrons[1].ID}
+	req.NoError(s.CreateHold(ctx, first))
+	a.NotZero(first.ID)
+	a.Equal(models.HoldWaiting, first.Status)
+	second := &models.Hold{BookID: b.ID, PatronID: patrons[2].ID}
+	req.NoError(s.CreateHold(ctx, second))
+	third := &models.Hold{BookID: b.ID, PatronID: patrons[3].ID}
+	req.NoError(s.CreateHold(ctx, third))
+	err := s.CreateHold(ctx, &models.Hold{BookID: b.ID, PatronID: patrons[1].ID})
+	a.Equal(models.ErrConflict{Message: "patron already has a hold on the book"}, err)
+	a.Error(s.CreateHold(ctx, &models.Hold{BookID: b.ID + 1000, PatronID: patrons[1].ID}))
+	a.Error(s.CreateHold(ctx, &models.Hold{BookID: b.ID, PatronID: patrons[3].ID + 1000}))
+
+	// a returned copy is kept for the first waiting hold
+	_, err = s.CloseLoan(ctx, l.CopyID, now, expiresAt)
+	req.NoError(err)
+	ready, err := s.GetHold(ctx, first.ID)
+	req.NoError(err)
+	a.Equal(models.HoldReady, ready.Status)
+	req.NotNil(ready.CopyID)
+	a.Equal(l.CopyID, *ready.CopyID)
+	req.NotNil(ready.ExpiresAt)
+	a.True(ready.ExpiresAt.Equal(expiresAt))
+	c, err := s.GetCopy(ctx, l.CopyID)
+	req.NoError(err)
+	a.Equal(models.StatusOnHold, c.Status)
+	a.Zero(getBook(t, s, b.ID).AvailableCopies)
+
+	// the copy goes on to the next hold when the ready hold is cancelled
+	cancelled, err := s.CancelHold(ctx, first.ID, expiresAt)
+	req.NoError(err)
+	a.Equal(models.HoldCancelled, cancelled.Status)
+	_, err = s.CancelHold(ctx, first.ID, expiresAt)
+	a.Equal(models.ErrConflict{Message: "hold is not active"}, err)
+
+	// the patron of the ready hold picks the copy up
+	req.NoError(s.CreateLoan(ctx, &models.Loan{BookID: b.ID, CopyID: l.CopyID, BorrowerID: patrons[2].ID, DueAt: now.Add(time.Hour)}))
+	fulfilled, err := s.GetHold(ctx, second.ID)
+	req.NoError(err)
+	a.Equal(models.HoldFulfilled, fulfilled.Status)
+
+	// ready holds not picked up expire and free the copy
+	_, err = s.CloseLoan(ctx, l.CopyID, now, now.Add(time.Hour))
+	req.NoError(err)
+	n, err := s.ExpireHolds(ctx, now.Add(2*time.Hour), expiresAt)
+	req.NoError(err)
+	a.Equal(1, n)
+	expired, err := s.GetHold(ctx, third.ID)
+	req.NoError(err)
+	a.Equal(models.HoldExpired, expired.Status)
+	a.Equal(1, getBook(t, s, b.ID).AvailableCopies)
+
+	active := true
+	holds, err := s.GetHolds(ctx, &models.HoldSearch{BookID: &b.ID, Active: &active}, 10, 0)
+	req.NoError(err)
+	a.Empty(holds)
+	holds, err = s.GetHolds(ctx, &models.HoldSearch{CopyID: &l.CopyID}, 10, 0)
+	req.NoError(err)
+	a.Len(holds, 3)
+	holds, err = s.GetHolds(ctx, &models.HoldSearch{BookID: &b.ID}, 2, 1)
+	req.NoError(err)
+	req.Len(holds, 2)
+	a.Equal(second.ID, holds[0].ID)
+}
+
+func testFines(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	policy := models.FinePolicy{DailyRate: 25, MaxAmount: 1000}
+
+	b := createBook(t, s, newBook("Lavinia", ""))
+	patron := createPatron(t, s, "Lavinia")
+	l := &models.Loan{BookID: b.ID, BorrowerID: patron.ID, DueAt: now.Add(-36 * time.Hour)}
+	req.NoError(s.CreateLoan(ctx, l))
+	onTime := createBook(t, s, newBook("Voices", ""))
+	req.NoError(s.CreateLoan(ctx, &models.Loan{BookID: onTime.ID, BorrowerID: patron.ID, DueAt: now.Add(time.Hour)}))
+
+	n, err := s.MarkOverdueLoans(ctx, now)
+	req.NoError(err)
+	a.Equal(1, n)
+	n, err = s.MarkOverdueLoans(ctx, now)
+	req.NoError(err)
+	a.Zero(n)
+
+	n, err = s.AccrueFines(ctx, now, policy)
+	req.NoError(err)
+	a.Equal(1, n)
+	// accruing again at the same time changes nothing
+	n, err = s.AccrueFines(ctx, now, policy)
+	req.NoError(err)
+	a.Zero(n)
+
+	fines, err := s.GetFines(ctx, &models.FineSearch{PatronID: &patron.ID}, 10, 0)
+	req.NoError(err)
+	req.Len(fines, 1)
+	f := fines[0]
+	a.Equal(l.ID, f.LoanID)
+	a.Equal(50, f.Amount)
+	a.Equal(models.FineOpen, f.Status)
+
+	_, err = s.SettleFine(ctx, f.ID, models.FinePaid, now)
+	a.Equal(models.ErrConflict{Message: "fine is still accruing, return the book first"}, err)
+
+	// the return stops the fine at the return time
+	returnedAt := now.Add(time.Hour)
+	_, err = s.CloseLoan(ctx, l.CopyID, returnedAt, returnedAt)
+	req.NoError(err)
+	n, err = s.AccrueFines(ctx, now.Add(72*time.Hour), policy)
+	req.NoError(err)
+	a.Equal(2, n)
+	stopped, err := s.GetFine(ctx, f.ID)
+	req.NoError(err)
+	a.Equal(50, stopped.Amount)
+	a.True(stopped.AccruedUntil.Equal(returnedAt))
+
+	paid, err := s.SettleFine(ctx, f.ID, models.FinePaid, now)
+	req.NoError(err)
+	a.Equal(models.FinePaid, paid.Status)
+	req.NotNil(paid.SettledAt)
+	_, err = s.SettleFine(ctx, f.ID, models.FineWaived, now)
+	a.Equal(models.ErrConflict{Message: "fine is already settled"}, err)
+	_, err = s.GetFine(ctx, f.ID+1000)
+	a.IsType(models.ErrNotFound{}, err)
+
+	status := models.FineOpen
+	fines, err = s.GetFines(ctx, &models.FineSearch{Status: &status}, 10, 0)
+	req.NoError(err)
+	req.Len(fines, 1)
+	// the book still lent is fined for every started day after its due date
+	a.Equal(75, fines[0].Amount)
+
+	// the fine stops growing at the maximum amount, unless there is none
+	n, err = s.AccrueFines(ctx, now.Add(30*24*time.Hour), models.FinePolicy{DailyRate: 50, MaxAmount: 1000})
+	req.NoError(err)
+	a.Equal(1, n)
+	capped, err := s.GetFine(ctx, fines[0].ID)
+	req.NoError(err)
+	a.Equal(1000, capped.Amount)
+	n, err = s.AccrueFines(ctx, now.Add(30*24*time.Hour+time.Hour), models.FinePolicy{DailyRate: 50})
+	req.NoError(err)
+	a.Equal(1, n)
+	uncapped, err := s.GetFine(ctx, fines[0].ID)
+	req.NoError(err)
+	a.Equal(1500, uncapped.Amount)
+}
+
+func testPatrons(t *testing.T, s book.StorageManager) {
+	a := assert.New(t)
+	req := require.New(t)
+	ctx := context.Background()
+
+	p := &models.Patron{Name: "Zoë Adams", Email: "zoe@example.com", LoanLimit: 5}
+	req.NoError(s.CreatePatron(ctx, p))
+	a.NotZero(p.ID)
+	a.True(strings.HasPrefix(p.MembershipNumber, "M"), p.MembershipNumber)
+	other := &models.Patron{Name: "Abel Brown", MembershipNumber: "CARD-7", LoanLimit: 5}
+	req.NoError(s.CreatePatron(ctx, other))
+	err := s.CreatePatron(ctx, &models.Patron{Name: "Copy", MembershipNumber: "CARD-7"})
+	a.Equal(models.ErrConflict{Message: "patron with this membership number already exists"}, err)
+
+	patrons, err := s.GetPatrons(ctx, &models.PatronSearch{}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{other.ID, p.ID}, patronIDs(patrons))
+	patrons, err = s.GetPatrons(ctx, &models.PatronSearch{Query: "zoe"}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{p.ID}, patronIDs(patrons))
+	patrons, err = s.GetPatrons(ctx, &models.PatronSearch{Query: "card"}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{other.ID}, patronIDs(patrons))
+	patrons, err = s.GetPatrons(ctx, &models.PatronSearch{MembershipNumber: "CARD-7"}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{other.ID}, patronIDs(patrons))
+	patrons, err = s.GetPatrons(ctx, &models.PatronSearch{MembershipNumber: "card"}, 10, 0)
+	req.NoError(err)
+	a.Empty(patrons)
+
+	u := &models.Patron{ID: p.ID, Name: "Zoë Adams", Status: models.PatronSuspended, LoanLimit: 2}
+	req.NoError(s.UpdatePatron(ctx, u))
+	a.Equal(p.MembershipNumber, u.MembershipNumber)
+	stored, err := s.GetPatron(ctx, p.ID)
+	req.NoError(err)
+	a.Equal(models.PatronSuspended, stored.Status)
+	a.Equal(2, stored.LoanLimit)
+	a.Empty(stored.Email)
+	suspended := models.PatronSuspended
+	patrons, err = s.GetPatrons(ctx, &models.PatronSearch{Status: &suspended}, 10, 0)
+	req.NoError(err)
+	a.Equal([]int{p.ID}, patronIDs(patrons))
+	err = s.UpdatePatron(ctx, &models.Patron{ID: p.ID + 1000, Name: "missing"})
+	a.Equal(models.ErrNotFound{Message: "patron does not exist"}, err)
+
+	b := createBook(t, s, newBook("Malafrena", ""))
+	req.NoError(s.CreateLoan(ctx, &models.Loan{BookID: b.ID, BorrowerID: other.ID, DueAt: time.Now().UTC()}))
+	a.Equal(models.ErrConflict{Message: "patron has loans"}, s.DeletePatron(ctx, other.ID))
+	// a patron with holds is kept, or the hold would be left to a patron that does not exist
+	waiting := createPatron(t, s, "Itale Sorde")
+	req.NoError(s.CreateHold(ctx, &models.Hold{BookID: b.ID, PatronID: waiting.ID}))
+	a.Equal(models.ErrConflict{Message: "patron has holds"}, s.DeletePatron(ctx, waiting.ID))
+	req.NoError(s.DeletePatron(ctx, p.ID))
+	_, err = s.GetPatron(ctx, p.ID)
+	a.IsType(models.ErrNotFound{}, err)
+	a.Equal(models.ErrNotFound{Message: "patron does not exist"}, s.DeletePatron(ctx, p.ID))
+	a.Error(s.CreateHold(ctx, &models.Hold{BookID: b.ID, PatronID: p.ID}))
+}
+
+func loanIDs(loans []models.Loan) []int {
+	res := make([]int, len(loans))
+	for i, l := range loans {
+		res[i] = l.ID
+	}
+	return res
+}
+
+func patronIDs(patrons []models.Patron) []int {
+	res := make([]int, len(patrons))
+	for i, p := range patrons {
+		res[i] = p.ID
+	}
+	return res
+}
@@ -0,0 +1,144 @@
+package storagetest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSearch(t *testing.T, s book.StorageManager) {
+	ctx := context.Background()
+	newSearchBook := func(title, author, publisher string, published time.Time) *models.Book {
+		return createBook(t, s, &models.Book{
+			Title:       title,
+			Authors:     []models.Author{{Name: author}},
+			Publisher:   publisher,
+			PublishDate: published,
+		})
+	}
+	alpha := newSearchBook("Alpha Café", "Zed Zimmer", "Orbit", date(2001, time.January, 1))
+	beta := newSearchBook("Beta", "Yann Young", "Tor", date(2005, time.January, 1))
+	gamma := newSearchBook("Gamma", "Xavier Alpha", "Orbit", date(2010, time.January, 1))
+	getBooks := func(t *testing.T, bs *models.BookSearch, p *models.Page) *models.BookList {
+		res, err := s.GetBooks(ctx, bs, p)
+		require.NoError(t, err)
+		return res
+	}
+	search := func(t *testing.T, bs *models.BookSearch) []int {
+		return bookIDs(getBooks(t, bs, &models.Page{Limit: 10}).Books)
+	}
+
+	t.Run("filters", func(t *testing.T) {
+		a := assert.New(t)
+		// field filters ignore case and accents
+		a.Equal([]int{alpha.ID}, search(t, &models.BookSearch{Title: "CAFE"}))
+		a.Equal([]int{gamma.ID}, search(t, &models.BookSearch{Author: "xavier"}))
+		a.Equal([]int{alpha.ID, gamma.ID}, search(t, &models.BookSearch{Publisher: "orb"}))
+		a.Equal([]int{beta.ID, gamma.ID}, search(t, &models.BookSearch{
+			PublishDateSearch: &models.PublishDateSearch{PublishDate: "2005-01-01", Condition: models.FilterMap["gte"]},
+		}))
+		a.Equal([]int{alpha.ID}, search(t, &models.BookSearch{
+			PublishDateSearch: &models.PublishDateSearch{PublishDate: "2005-01-01", Condition: models.FilterMap["lt"]},
+		}))
+		a.Equal([]int{beta.ID}, search(t, &models.BookSearch{PublisherID: &beta.PublisherID}))
+		a.Equal([]int{gamma.ID}, search(t, &models.BookSearch{AuthorID: &gamma.Authors[0].ID}))
+		status := models.StatusCheckedOut
+		a.Empty(search(t, &models.BookSearch{Status: &status}))
+	})
+	t.Run("full-text query", func(t *testing.T) {
+		a := assert.New(t)
+		a.Equal([]int{alpha.ID, gamma.ID}, search(t, &models.BookSearch{Query: "alpha"}))
+		a.Equal([]int{alpha.ID}, search(t, &models.BookSearch{Query: "alpha -gamma"}))
+		a.Equal([]int{beta.ID, gamma.ID}, search(t, &models.BookSearch{Query: "beta or gamma"}))
+		a.Equal([]int{alpha.ID}, search(t, &models.BookSearch{Query: `"alpha cafe"`}))
+		a.Empty(search(t, &models.BookSearch{Query: "delta"}))
+
+		// a match of the title ranks above a match of the author
+		list := getBooks(t, &models.BookSearch{Query: "alpha"}, &models.Page{
+			Limit: 10,
+			Sort:  []models.SortField{{Field: models.SortRelevance, Desc: true}},
+		})
+		a.Equal([]int{alpha.ID, gamma.ID}, bookIDs(list.Books))
+		_, err := s.GetBooks(ctx, &models.BookSearch{}, &models.Page{
+			Limit: 10,
+			Sort:  []models.SortField{{Field: models.SortRelevance}},
+		})
+		a.IsType(models.ErrBadRequest{}, err)
+	})
+	t.Run("sort", func(t *testing.T) {
+		a := assert.New(t)
+		list := getBooks(t, &models.BookSearch{}, &models.Page{
+			Limit: 10,
+			Sort:  []models.SortField{{Field: "title", Desc: true}},
+		})
+		a.Equal([]int{gamma.ID, beta.ID, alpha.ID}, bookIDs(list.Books))
+		list = getBooks(t, &models.BookSearch{}, &models.Page{
+			Limit: 10,
+			Sort:  []models.SortField{{Field: "publisher"}, {Field: "publish_date", Desc: true}},
+		})
+		a.Equal([]int{gamma.ID, alpha.ID, beta.ID}, bookIDs(list.Books))
+		_, err := s.GetBooks(ctx, &models.BookSearch{}, &models.Page{
+			Limit: 10,
+			Sort:  []models.SortField{{Field: "isbn"}},
+		})
+		a.IsType(models.ErrBadRequest{}, err)
+	})
+	t.Run("offset pagination", func(t *testing.T) {
+		a := assert.New(t)
+		list := getBooks(t, &models.BookSearch{}, &models.Page{Limit: 2, WithTotal: true})
+		a.Equal([]int{alpha.ID, beta.ID}, bookIDs(list.Books))
+		a.Equal(3, list.Total)
+		a.NotNil(list.Next)
+		list = getBooks(t, &models.BookSearch{}, &models.Page{Limit: 2, Offset: 2})
+		a.Equal([]int{gamma.ID}, bookIDs(list.Books))
+		a.Nil(list.Next)
+		a.Empty(getBooks(t, &models.BookSearch{}, &models.Page{Limit: 2, Offset: 5}).Books)
+	})
+	t.Run("cursor pagination", func(t *testing.T) {
+		a := assert.New(t)
+		req := require.New(t)
+		for _, sort := range [][]models.SortField{
+			{{Field: "publish_date", Desc: true}},
+			{{Field: "publisher"}, {Field: "title"}},
+			{{Field: "rating"}},
+		} {
+			var (
+				ids   []int
+				after *models.Cursor
+			)
+			for i := 0; i < 5; i++ {
+				list := getBooks(t, &models.BookSearch{}, &models.Page{Limit: 1, Sort: sort, After: after})
+				ids = append(ids, bookIDs(list.Books)...)
+				if list.Next == nil {
+					break
+				}
+				// cursors reach the storage the way clients send them back
+				data, err := json.Marshal(list.Next)
+				req.NoError(err)
+				after = new(models.Cursor)
+				req.NoError(json.Unmarshal(data, after))
+			}
+			all := getBooks(t, &models.BookSearch{}, &models.Page{Limit: 10, Sort: sort})
+			a.Equal(bookIDs(all.Books), ids, "sort %v", sort)
+		}
+		_, err := s.GetBooks(ctx, &models.BookSearch{}, &models.Page{
+			Limit: 10,
+			After: &models.Cursor{ID: 1},
+			Sort:  []models.SortField{{Field: "title"}},
+		})
+		a.IsType(models.ErrBadRequest{}, err)
+	})
+	t.Run("deleted", func(t *testing.T) {
+		a := assert.New(t)
+		require.NoError(t, s.DeleteBook(ctx, beta.ID, 0))
+		a.Equal([]int{alpha.ID, gamma.ID}, search(t, &models.BookSearch{}))
+		a.Equal([]int{beta.ID}, search(t, &models.BookSearch{Deleted: models.DeletedOnly}))
+		a.Equal([]int{alpha.ID, beta.ID, gamma.ID}, search(t, &models.BookSearch{Deleted: models.DeletedInclude}))
+	})
+}
@@ -0,0 +1,89 @@
+// Package storagetest is the conformance suite of book.StorageManager: every
+// storage runs it, so the storages behave the same way.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libreria/models"
+	"github.com/libreria/service/book"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the suite against the storages made by newStorage, which has to
+// return an empty storage for every test.
+func Run(t *testing.T, newStorage func(t *testing.T) book.StorageManager) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s book.StorageManager)
+	}{
+		{"Books", testBooks},
+		{"Ratings", testRatings},
+		{"Retention", testRetention},
+		{"Search", testSearch},
+		{"Copies", testCopies},
+		{"Loans", testLoans},
+		{"Holds", testHolds},
+		{"Fines", testFines},
+		{"Patrons", testPatrons},
+		{"Authors", testAuthors},
+		{"Publishers", testPublishers},
+		{"Transactions", testTransactions},
+		{"Import", testImport},
+		{"Export", testExport},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newStorage(t))
+		})
+	}
+}
+
+// date is midnight of the day in UTC, the way publish dates are stored.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func newBook(title, isbn string) *models.Book {
+	return &models.Book{
+		Title:       title,
+		ISBN:        isbn,
+		Authors:     []models.Author{{Name: "Ursula K. Le Guin"}},
+		Publisher:   "Ace Books",
+		PublishDate: date(1969, time.March, 1),
+	}
+}
+
+// createBook stores the book and returns it as read back from the storage.
+func createBook(t *testing.T, s book.StorageManager, b *models.Book) *models.Book {
+	req := require.New(t)
+	req.NoError(s.CreateBook(context.Background(), b))
+	res, err := s.GetBook(context.Background(), b.ID)
+	req.NoError(err)
+	return res
+}
+
+// createPatron stores an active patron with the name, whom loans and holds
+// have to refer to.
+func createPatron(t *testing.T, s book.StorageManager, name string) *models.Patron {
+	p := &models.Patron{Name: name, LoanLimit: 5}
+	require.NoError(t, s.CreatePatron(context.Background(), p))
+	return p
+}
+
+func getBook(t *testing.T, s book.StorageManager, id int) *models.Book {
+	res, err := s.GetBook(context.Background(), id)
+	require.NoError(t, err)
+	return res
+}
+
+func bookIDs(books []models.Book) []int {
+	res := make([]int, len(books))
+	for i, b := range books {
+		res[i] = b.ID
+	}
+	return res
+}
@@ -0,0 +1,137 @@
+// Package textsearch matches text the way the postgres storage searches books,
+// for the storages without the text search of postgres.
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lower-cases s and strips its accents like unaccent does.
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// Tokens splits s into folded words like the 'simple' text search configuration.
+func Tokens(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Query is a full-text query in the syntax of websearch_to_tsquery: words
+// are ANDed, "quoted words" form a phrase, 'or' separates alternatives and a
+// leading '-' negates a word or phrase. The query matches if any of its
+// alternatives does, alternatives without terms match nothing.
+type Query [][]Term
+
+// Term is a word or a phrase of a query, its words are folded.
+type Term struct {
+	Words  []string
+	Negate bool
+}
+
+func ParseQuery(s string) Query {
+	q := Query{nil}
+	for len(s) > 0 {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+		var t Term
+		if s[0] == '-' {
+			t.Negate = true
+			s = s[1:]
+		}
+		var text string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				text, s = s[1:], ""
+			} else {
+				text, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(s, unicode.IsSpace)
+			if end < 0 {
+				end = len(s)
+			}
+			text, s = s[:end], s[end:]
+			if !t.Negate && strings.EqualFold(text, "or") {
+				if len(q[len(q)-1]) > 0 {
+					q = append(q, nil)
+				}
+				continue
+			}
+		}
+		t.Words = Tokens(text)
+		if len(t.Words) > 0 {
+			q[len(q)-1] = append(q[len(q)-1], t)
+		}
+	}
+	return q
+}
+
+// Matches tells whether the words of the fields of a document match the query.
+func (q Query) Matches(fields [][]string) bool {
+	for _, alt := range q {
+		if len(alt) == 0 {
+			continue
+		}
+		ok := true
+		for _, t := range alt {
+			if t.found(fields) == t.Negate {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// found tells whether the words of the term follow each other in one of the fields.
+func (t Term) found(fields [][]string) bool {
+	for _, words := range fields {
+		for i := 0; i+len(t.Words) <= len(words); i++ {
+			match := true
+			for j, w := range t.Words {
+				if words[i+j] != w {
+					match = false
+					break
+				}
+			}
+			if match {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasWord tells whether w is a word of a term of the query that is not negated.
+func (q Query) HasWord(w string) bool {
+	for _, alt := range q {
+		for _, t := range alt {
+			if t.Negate {
+				continue
+			}
+			for _, tw := range t.Words {
+				if tw == w {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestBookHistory() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	book := testBooks[0]
+	resp := do(http.MethodPut, "/books/1", &hm.Book{
+		Title:       book.Title + " (2nd edition)",
+		Author:      book.Author,
+		Publisher:   book.Publisher,
+		PublishDate: book.PublishDate,
+	})
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	resp = do(http.MethodPatch, "/books/1/rate", &hm.RateRequest{Rating: 3})
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	resp = do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 1})
+	resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	resp = do(http.MethodPatch, "/books/1/in", nil)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	resp = do(http.MethodDelete, "/books/1", nil)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+	resp = do(http.MethodGet, "/books/1/history", nil)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var entries []hm.AuditEntryResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&entries))
+	resp.Body.Close()
+	s.Require().Len(entries, 5)
+	actions := make([]string, len(entries))
+	for i, e := range entries {
+		actions[i] = e.Action
+	}
+	s.Assert().Equal([]string{"update", "rate", "checkout", "checkin", "delete"}, actions)
+	s.Assert().Equal(book.Title, entries[0].Before["title"])
+	s.Assert().Equal(book.Title+" (2nd edition)", entries[0].After["title"])
+	s.Assert().NotContains(entries[0].After, "publisher")
+	s.Assert().Equal("admin", entries[0].Actor)
+	s.Assert().Nil(entries[4].After)
+
+	s.Run("delete_missing", func() {
+		resp := do(http.MethodDelete, "/books/1", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,97 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestAuthors() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	addAuthor := func(name string) hm.AuthorResponse {
+		resp := do(http.MethodPost, "/authors", &hm.Author{Name: name})
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		var author hm.AuthorResponse
+		err := json.NewDecoder(resp.Body).Decode(&author)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		return author
+	}
+	gaiman, pratchett := addAuthor("Neil Gaiman"), addAuthor("Terry Pratchett")
+
+	resp := do(http.MethodPost, "/books", &hm.Book{
+		Title:       "Good Omens",
+		AuthorIDs:   []int{pratchett.ID, gaiman.ID},
+		Publisher:   "Gollancz",
+		PublishDate: time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC),
+	})
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	var book hm.GetBookResponse
+	err := json.NewDecoder(resp.Body).Decode(&book)
+	resp.Body.Close()
+	s.Require().NoError(err)
+	s.Assert().Equal("Terry Pratchett, Neil Gaiman", book.Author)
+	s.Assert().Equal([]int{pratchett.ID, gaiman.ID}, book.AuthorIDs)
+	s.Assert().NotZero(book.PublisherID)
+
+	s.Run("duplicate", func() {
+		resp := do(http.MethodPost, "/authors", &hm.Author{Name: "neil gaiman"})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("author_books", func() {
+		resp := do(http.MethodGet, fmt.Sprintf("/authors/%d/books", gaiman.ID), nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var books hm.BookListResponse
+		err := json.NewDecoder(resp.Body).Decode(&books)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(books.Books, 1)
+		s.Assert().Equal(book.ID, books.Books[0].ID)
+	})
+	s.Run("publisher_books", func() {
+		resp := do(http.MethodGet, fmt.Sprintf("/publishers/%d/books", book.PublisherID), nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var books hm.BookListResponse
+		err := json.NewDecoder(resp.Body).Decode(&books)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(books.Books, 1)
+	})
+	s.Run("rename", func() {
+		resp := do(http.MethodPut, fmt.Sprintf("/authors/%d", gaiman.ID), &hm.Author{Name: "Neil Richard Gaiman"})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		resp = do(http.MethodGet, fmt.Sprintf("/books/%d", book.ID), nil)
+		var renamed hm.GetBookResponse
+		err := json.NewDecoder(resp.Body).Decode(&renamed)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Assert().Equal("Terry Pratchett, Neil Richard Gaiman", renamed.Author)
+	})
+	s.Run("delete_with_books", func() {
+		resp := do(http.MethodDelete, fmt.Sprintf("/authors/%d", gaiman.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("not_found", func() {
+		resp := do(http.MethodGet, "/authors/1000/books", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,74 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestBatchBooks() {
+	const book = `{"name":"Sapiens","author":"Yuval Noah Harari","publisher":"Harper","publish_date":"2015-02-10T00:00:00Z"}`
+	batch := func(body string) (int, hm.BatchResponse) {
+		resp, err := s.c.Post("http://localhost:8080/api/v1/books:batch", "application/json", bytes.NewBufferString(body))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var res hm.BatchResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&res))
+		return resp.StatusCode, res
+	}
+	getBook := func(id int) hm.GetBookResponse {
+		resp, err := s.c.Get(fmt.Sprintf("http://localhost:8080/api/v1/books/%d", id))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var res hm.GetBookResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&res))
+		return res
+	}
+	countBooks := func() int {
+		resp, err := s.c.Get("http://localhost:8080/api/v1/books")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var list hm.BookListResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&list))
+		return len(list.Books)
+	}
+
+	s.Run("rollback", func() {
+		before := getBook(1)
+		code, res := batch(fmt.Sprintf(`{"operations":[
+			{"op":"create","book":%s},
+			{"op":"update","id":1,"version":%d,"book":%s}
+		]}`, book, before.Version+1, book))
+		s.Require().Equal(http.StatusPreconditionFailed, code)
+		s.Assert().False(res.Committed)
+		s.Require().Len(res.Results, 2)
+		s.Assert().Equal(http.StatusFailedDependency, res.Results[0].Status)
+		s.Assert().Equal(http.StatusPreconditionFailed, res.Results[1].Status)
+		s.Assert().Equal(len(testBooks), countBooks())
+		s.Assert().Equal(before.Title, getBook(1).Title)
+	})
+	s.Run("commit", func() {
+		before := getBook(1)
+		code, res := batch(fmt.Sprintf(`{"operations":[
+			{"op":"create","book":%s},
+			{"op":"update","id":1,"version":%d,"book":%s},
+			{"op":"delete","id":2}
+		]}`, book, before.Version, book))
+		s.Require().Equal(http.StatusOK, code)
+		s.Assert().True(res.Committed)
+		s.Require().Len(res.Results, 3)
+		s.Assert().Equal(http.StatusCreated, res.Results[0].Status)
+		s.Assert().Equal("Sapiens", getBook(res.Results[0].ID).Title)
+		s.Assert().Equal(http.StatusNoContent, res.Results[1].Status)
+		s.Assert().Equal(before.Version+1, res.Results[1].Version)
+		s.Assert().Equal("Sapiens", getBook(1).Title)
+		s.Assert().Equal(http.StatusNoContent, res.Results[2].Status)
+		s.Assert().Equal(len(testBooks), countBooks())
+	})
+}
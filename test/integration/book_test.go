@@ -5,7 +5,9 @@ package integration
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	hm "github.com/libreria/server/http/models"
 )
@@ -16,56 +18,146 @@ func (s *LibreriaTestSuite) TestGetBooks() {
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var books []hm.GetBookResponse
+		var books hm.BookListResponse
 		err = json.NewDecoder(resp.Body).Decode(&books)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Assert().Len(books, 3)
+		s.Assert().Len(books.Books, 3)
+		s.Assert().Empty(books.NextCursor)
 	})
 	s.Run("limit_1", func() {
 		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?limit=1", nil)
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var books []hm.GetBookResponse
+		var books hm.BookListResponse
 		err = json.NewDecoder(resp.Body).Decode(&books)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Assert().Len(books, 1)
+		s.Assert().Len(books.Books, 1)
+		s.Assert().NotEmpty(books.NextCursor)
+	})
+	s.Run("cursor", func() {
+		var (
+			ids    []int
+			cursor string
+		)
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?limit=2&total=true&cursor="+cursor, nil)
+			resp, err := s.c.Do(req)
+			s.Require().NoError(err)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Equal("3", resp.Header.Get("X-Total-Count"))
+			var books hm.BookListResponse
+			err = json.NewDecoder(resp.Body).Decode(&books)
+			resp.Body.Close()
+			s.Require().NoError(err)
+			for _, b := range books.Books {
+				ids = append(ids, b.ID)
+			}
+			cursor = books.NextCursor
+			if cursor == "" {
+				break
+			}
+		}
+		s.Assert().Equal([]int{1, 2, 3}, ids)
+	})
+	s.Run("sort", func() {
+		var titles []string
+		cursor := ""
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?limit=1&sort=-publish_date&cursor="+cursor, nil)
+			resp, err := s.c.Do(req)
+			s.Require().NoError(err)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			var books hm.BookListResponse
+			err = json.NewDecoder(resp.Body).Decode(&books)
+			resp.Body.Close()
+			s.Require().NoError(err)
+			s.Require().Len(books.Books, 1)
+			titles = append(titles, books.Books[0].Title)
+			cursor = books.NextCursor
+		}
+		s.Assert().Equal([]string{testBooks[1].Title, testBooks[2].Title, testBooks[0].Title}, titles)
+		s.Assert().Empty(cursor)
+	})
+	s.Run("limit_too_big", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?limit=100000", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
 	})
 	s.Run("publish_date", func() {
 		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?publish_date=gt%202020-01-01", nil)
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var books []hm.GetBookResponse
+		var books hm.BookListResponse
 		err = json.NewDecoder(resp.Body).Decode(&books)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Assert().Len(books, 1)
+		s.Assert().Len(books.Books, 1)
 	})
 	s.Run("title_contains", func() {
 		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?title=Faster", nil)
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var books []hm.GetBookResponse
+		var books hm.BookListResponse
+		err = json.NewDecoder(resp.Body).Decode(&books)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(books.Books, 1)
+		s.Assert().Equal(testBooks[1].Title, books.Books[0].Title)
+	})
+	s.Run("full_text", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?q=sapolsky", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var books hm.BookListResponse
 		err = json.NewDecoder(resp.Body).Decode(&books)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Require().Len(books, 1)
-		s.Assert().Equal(testBooks[1].Title, books[0].Title)
+		s.Require().Len(books.Books, 1)
+		s.Assert().Equal(testBooks[0].Title, books.Books[0].Title)
+	})
+	s.Run("full_text_ranked", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?q=penguin%20OR%20chaos", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var books hm.BookListResponse
+		err = json.NewDecoder(resp.Body).Decode(&books)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(books.Books, 3)
+		// a title match weighs more than a publisher match
+		s.Assert().Equal(testBooks[2].Title, books.Books[0].Title)
+	})
+	s.Run("case_and_accent_insensitive", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?publisher=P%C3%89NGUIN%20press", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var books hm.BookListResponse
+		err = json.NewDecoder(resp.Body).Decode(&books)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(books.Books, 1)
+		s.Assert().Equal(testBooks[0].Title, books.Books[0].Title)
 	})
 	s.Run("no_books", func() {
 		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books?title=Hobbit", nil)
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var books []hm.GetBookResponse
+		var books hm.BookListResponse
 		err = json.NewDecoder(resp.Body).Decode(&books)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Require().Len(books, 0)
+		s.Require().Len(books.Books, 0)
 	})
 }
 
@@ -92,10 +184,11 @@ func (s *LibreriaTestSuite) TestGetBook() {
 
 func (s *LibreriaTestSuite) TestCheckInBook() {
 	s.Run("found", func() {
-		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/out", nil)
+		b, _ := json.Marshal(&hm.CheckoutRequest{BorrowerID: 1})
+		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/out", bytes.NewReader(b))
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
-		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
 		req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/1", nil)
 		resp, err = s.c.Do(req)
 		s.Require().NoError(err)
@@ -105,6 +198,79 @@ func (s *LibreriaTestSuite) TestCheckInBook() {
 		resp.Body.Close()
 		s.Require().NoError(err)
 		s.Require().Equal(hm.StatusCheckedOut, book.Status)
+
+		req, _ = http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/in", nil)
+		resp, err = s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var loan hm.LoanResponse
+		err = json.NewDecoder(resp.Body).Decode(&loan)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().NotNil(loan.ReturnedAt)
+		s.Require().Equal(1, loan.BorrowerID)
+	})
+}
+
+func (s *LibreriaTestSuite) TestInvalidStatusTransitions() {
+	s.Run("double_checkout", func() {
+		b, _ := json.Marshal(&hm.CheckoutRequest{BorrowerID: 1})
+		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/out", bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		req, _ = http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/out", bytes.NewReader(b))
+		resp, err = s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("checkin_available", func() {
+		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/2/in", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("not_found", func() {
+		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/100/in", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestGetLoans() {
+	b, _ := json.Marshal(&hm.CheckoutRequest{BorrowerID: 1})
+	req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/2/out", bytes.NewReader(b))
+	resp, err := s.c.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Run("book_loans", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/2/loans", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var loans []hm.LoanResponse
+		err = json.NewDecoder(resp.Body).Decode(&loans)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(loans, 1)
+		s.Assert().Equal(2, loans[0].BookID)
+	})
+	s.Run("no_overdue", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/loans?overdue=true", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var loans []hm.LoanResponse
+		err = json.NewDecoder(resp.Body).Decode(&loans)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Require().Len(loans, 0)
 	})
 }
 
@@ -121,13 +287,237 @@ func (s *LibreriaTestSuite) TestDeleteBook() {
 	})
 }
 
-func (s *LibreriaTestSuite) TestRateBook() {
+func (s *LibreriaTestSuite) TestRestoreBook() {
+	do := func(method, url string) *http.Response {
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	listBooks := func(url string) []hm.GetBookResponse {
+		resp := do(http.MethodGet, url)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var list hm.BookListResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&list))
+		resp.Body.Close()
+		return list.Books
+	}
+	resp := do(http.MethodDelete, "/books/1")
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+	s.Run("list_deleted", func() {
+		s.Assert().Len(listBooks("/books"), len(testBooks)-1)
+		s.Assert().Len(listBooks("/books?deleted=include"), len(testBooks))
+		deleted := listBooks("/books?deleted=only")
+		s.Require().Len(deleted, 1)
+		s.Assert().Equal(1, deleted[0].ID)
+		s.Assert().NotNil(deleted[0].DeletedAt)
+	})
+	s.Run("restore", func() {
+		resp := do(http.MethodPost, "/books/1/restore")
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp = do(http.MethodGet, "/books/1")
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Assert().Len(listBooks("/books?deleted=only"), 0)
+	})
+	s.Run("restore_not_deleted", func() {
+		resp := do(http.MethodPost, "/books/1/restore")
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestPurgeBook() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	resp := do(http.MethodPatch, "/books/2/out", &hm.CheckoutRequest{BorrowerID: 1})
+	resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	s.Run("open_loans", func() {
+		resp := do(http.MethodDelete, "/books/2?hard=true", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
 	s.Run("happy_path", func() {
-		rate := &hm.RateRequest{Rating: 3}
-		b, _ := json.Marshal(rate)
+		resp := do(http.MethodPatch, "/books/2/in", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		resp = do(http.MethodDelete, "/books/2?hard=true", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		resp = do(http.MethodPost, "/books/2/restore", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+	s.Run("history_is_kept", func() {
+		resp := do(http.MethodGet, "/books/2/history", nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var entries []hm.AuditEntryResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&entries))
+		resp.Body.Close()
+		s.Require().NotEmpty(entries)
+		s.Assert().Equal("purge", entries[len(entries)-1].Action)
+	})
+}
+
+func (s *LibreriaTestSuite) TestBookETag() {
+	do := func(method, url string, body interface{}, header, value string) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	resp := do(http.MethodGet, "/books/1", nil, "", "")
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	s.Require().NotEmpty(etag)
+
+	s.Run("not_modified", func() {
+		resp := do(http.MethodGet, "/books/1", nil, "If-None-Match", etag)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotModified, resp.StatusCode)
+	})
+	book := testBooks[0]
+	update := &hm.Book{
+		Title:       book.Title + " (revised)",
+		Author:      book.Author,
+		Publisher:   book.Publisher,
+		PublishDate: book.PublishDate,
+	}
+	resp = do(http.MethodPut, "/books/1", update, "If-Match", etag)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	newETag := resp.Header.Get("ETag")
+	s.Require().NotEqual(etag, newETag)
+
+	s.Run("lost_update", func() {
+		resp := do(http.MethodPut, "/books/1", update, "If-Match", etag)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+	})
+	s.Run("modified", func() {
+		resp := do(http.MethodGet, "/books/1", nil, "If-None-Match", etag)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Assert().Equal(newETag, resp.Header.Get("ETag"))
+	})
+	s.Run("stale_delete", func() {
+		resp := do(http.MethodDelete, "/books/1", nil, "If-Match", etag)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusPreconditionFailed, resp.StatusCode)
+		resp = do(http.MethodDelete, "/books/1", nil, "If-Match", newETag)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestPatchBook() {
+	do := func(method, url, contentType, body string) *http.Response {
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewBufferString(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	getBook := func() hm.GetBookResponse {
+		resp := do(http.MethodGet, "/books/1", "", "")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var res hm.GetBookResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&res))
+		return res
+	}
+	history := func() []hm.AuditEntryResponse {
+		resp := do(http.MethodGet, "/books/1/history", "", "")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var res []hm.AuditEntryResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&res))
+		return res
+	}
+	before := getBook()
+
+	s.Run("merge_patch", func() {
+		resp := do(http.MethodPatch, "/books/1", "application/merge-patch+json", `{"name":"Patched title"}`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		after := getBook()
+		s.Assert().Equal("Patched title", after.Title)
+		s.Assert().Equal(before.Author, after.Author)
+		s.Assert().Equal(before.Publisher, after.Publisher)
+		s.Assert().True(before.PublishDate.Equal(after.PublishDate))
+		s.Assert().Equal(before.Version+1, after.Version)
+		entries := history()
+		s.Require().NotEmpty(entries)
+		s.Assert().Equal("Patched title", entries[len(entries)-1].After["title"])
+		s.Assert().NotContains(entries[len(entries)-1].After, "author")
+	})
+	s.Run("unchanged", func() {
+		current := getBook()
+		n := len(history())
+		resp := do(http.MethodPatch, "/books/1", "application/merge-patch+json", `{"name":"Patched title"}`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		s.Assert().Equal(fmt.Sprintf(`"%d"`, current.Version), resp.Header.Get("ETag"))
+		s.Assert().Len(history(), n)
+	})
+	s.Run("json_patch", func() {
+		resp := do(http.MethodPatch, "/books/1", "application/json-patch+json",
+			`[{"op":"test","path":"/name","value":"Patched title"},{"op":"replace","path":"/author","value":"Patched Author"}]`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		after := getBook()
+		s.Assert().Equal("Patched Author", after.Author)
+		s.Assert().NotEqual(before.AuthorIDs, after.AuthorIDs)
+	})
+	s.Run("test_failed", func() {
+		resp := do(http.MethodPatch, "/books/1", "application/json-patch+json",
+			`[{"op":"test","path":"/name","value":"Other title"},{"op":"remove","path":"/name"}]`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("invalid_result", func() {
+		resp := do(http.MethodPatch, "/books/1", "application/merge-patch+json", `{"publish_date":null}`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+	s.Run("unsupported_media_type", func() {
+		resp := do(http.MethodPatch, "/books/1", "text/plain", `{"name":"Other title"}`)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusUnsupportedMediaType, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestRateBook() {
+	rateBook := func(key string, rating int) hm.GetBookResponse {
+		b, _ := json.Marshal(&hm.RateRequest{Rating: rating})
 		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/rate", bytes.NewReader(b))
+		req.Header.Set("X-API-Key", key)
 		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
+		resp.Body.Close()
 		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
 		req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/1", nil)
 		resp, err = s.c.Do(req)
@@ -137,22 +527,70 @@ func (s *LibreriaTestSuite) TestRateBook() {
 		err = json.NewDecoder(resp.Body).Decode(&book)
 		resp.Body.Close()
 		s.Require().NoError(err)
+		return book
+	}
+	s.Run("happy_path", func() {
+		book := rateBook(aliceKey, 3)
 		s.Require().Equal(3.0, book.Rating)
+		s.Require().Equal(1, book.RatingCount)
 
-		rate.Rating = 2
-		b, _ = json.Marshal(rate)
-		req, _ = http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/1/rate", bytes.NewReader(b))
-		resp, err = s.c.Do(req)
+		book = rateBook(bobKey, 2)
+		s.Require().Equal(2.5, book.Rating)
+		s.Require().Equal(2, book.RatingCount)
+	})
+	s.Run("change_rating", func() {
+		book := rateBook(aliceKey, 1)
+		s.Require().Equal(1.5, book.Rating)
+		s.Require().Equal(2, book.RatingCount)
+	})
+	s.Run("not_found", func() {
+		b, _ := json.Marshal(&hm.RateRequest{Rating: 3})
+		req, _ := http.NewRequest(http.MethodPatch, "http://localhost:8080/api/v1/books/100/rate", bytes.NewReader(b))
+		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
-		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
-		req, _ = http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/1", nil)
-		resp, err = s.c.Do(req)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestBookISBN() {
+	addBook := func(isbn string) *http.Response {
+		b, _ := json.Marshal(&hm.Book{
+			Title:       "The Go Programming Language",
+			ISBN:        isbn,
+			Author:      "Alan A. A. Donovan",
+			Publisher:   "Addison-Wesley",
+			PublishDate: time.Date(2015, time.October, 26, 0, 0, 0, 0, time.UTC),
+		})
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/books", bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		return resp
+	}
+	s.Require().Equal(http.StatusCreated, addBook("978-0-13-419044-0").StatusCode)
+	s.Run("lookup_isbn10", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/isbn/0134190440", nil)
+		resp, err := s.c.Do(req)
 		s.Require().NoError(err)
 		s.Require().Equal(http.StatusOK, resp.StatusCode)
-		var book2 hm.GetBookResponse
-		err = json.NewDecoder(resp.Body).Decode(&book2)
+		var book hm.GetBookResponse
+		err = json.NewDecoder(resp.Body).Decode(&book)
 		resp.Body.Close()
 		s.Require().NoError(err)
-		s.Require().Equal(2.5, book2.Rating)
+		s.Assert().Equal("9780134190440", book.ISBN)
+	})
+	s.Run("duplicate", func() {
+		s.Require().Equal(http.StatusConflict, addBook("0-13-419044-0").StatusCode)
+	})
+	s.Run("invalid", func() {
+		s.Require().Equal(http.StatusBadRequest, addBook("0-13-419044-1").StatusCode)
+	})
+	s.Run("not_found", func() {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1/books/isbn/9780306406157", nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
 	})
 }
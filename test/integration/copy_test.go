@@ -0,0 +1,97 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestCopies() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	getBook := func() hm.GetBookResponse {
+		resp := do(http.MethodGet, "/books/1", nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var book hm.GetBookResponse
+		err := json.NewDecoder(resp.Body).Decode(&book)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		return book
+	}
+	resp := do(http.MethodPost, "/books/1/copies", &hm.CopyRequest{Barcode: "B-0002", Condition: "new", Location: "shelf 3"})
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	var second hm.CopyResponse
+	err := json.NewDecoder(resp.Body).Decode(&second)
+	resp.Body.Close()
+	s.Require().NoError(err)
+	s.Assert().Equal("B-0002", second.Barcode)
+	book := getBook()
+	s.Assert().Equal(2, book.TotalCopies)
+	s.Assert().Equal(2, book.AvailableCopies)
+
+	s.Run("duplicate_barcode", func() {
+		resp := do(http.MethodPost, "/books/2/copies", &hm.CopyRequest{Barcode: "B-0002"})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("checkout_all", func() {
+		resp := do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 1, CopyID: second.ID})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		resp = do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 2})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		book := getBook()
+		s.Assert().Equal(0, book.AvailableCopies)
+		s.Assert().Equal(hm.StatusCheckedOut, book.Status)
+
+		resp = do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 3})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("checkin_ambiguous", func() {
+		resp := do(http.MethodPatch, "/books/1/in", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("retire_checked_out", func() {
+		resp := do(http.MethodDelete, fmt.Sprintf("/copies/%d", second.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("checkin_copy", func() {
+		resp := do(http.MethodPatch, fmt.Sprintf("/books/1/in?copy_id=%d", second.ID), nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var loan hm.LoanResponse
+		err := json.NewDecoder(resp.Body).Decode(&loan)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		s.Assert().Equal(second.ID, loan.CopyID)
+		s.Assert().Equal(1, getBook().AvailableCopies)
+	})
+	s.Run("retire", func() {
+		resp := do(http.MethodDelete, fmt.Sprintf("/copies/%d", second.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		book := getBook()
+		s.Assert().Equal(1, book.TotalCopies)
+		s.Assert().Equal(0, book.AvailableCopies)
+
+		resp = do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 1, CopyID: second.ID})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+}
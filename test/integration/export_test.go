@@ -0,0 +1,58 @@
+// +build integration
+
+package integration
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestExportBooks() {
+	get := func(url string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/api/v1"+url, nil)
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost:8080/api/v1/books/3", nil)
+	resp, err := s.c.Do(req)
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+	s.Run("csv", func() {
+		resp := get("/books:export?format=csv")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		s.Require().NoError(err)
+		// the header and the books that are not deleted
+		s.Require().Len(records, 3)
+		s.Assert().Equal("name", records[0][1])
+		s.Assert().Equal(testBooks[0].Title, records[1][1])
+		s.Assert().Equal(testBooks[1].Title, records[2][1])
+	})
+	s.Run("ndjson", func() {
+		resp := get("/books:export?format=ndjson&deleted=include&publisher=penguin")
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var ids []int
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			var b hm.GetBookResponse
+			s.Require().NoError(json.Unmarshal(sc.Bytes(), &b))
+			ids = append(ids, b.ID)
+		}
+		s.Require().NoError(sc.Err())
+		s.Assert().Equal([]int{1, 2, 3}, ids)
+	})
+	s.Run("invalid_format", func() {
+		resp := get("/books:export?format=xml")
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
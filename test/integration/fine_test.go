@@ -0,0 +1,78 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestFines() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	listFines := func(url string) []hm.FineResponse {
+		resp := do(http.MethodGet, url, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var fines []hm.FineResponse
+		err := json.NewDecoder(resp.Body).Decode(&fines)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		return fines
+	}
+	for _, bookID := range []int{1, 2} {
+		resp := do(http.MethodPatch, fmt.Sprintf("/books/%d/out", bookID), &hm.CheckoutRequest{BorrowerID: 7})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	}
+	// the worker runs hourly, seed what a run three days after the due date would have produced
+	_, err := s.db.Exec("UPDATE loans SET due_at = now() - interval '3 days', overdue_at = now() - interval '3 days'")
+	s.Require().NoError(err)
+	_, err = s.db.Exec(`INSERT INTO fines (loan_id, patron_id, amount, status, accrued_until)
+		SELECT id, borrower_id, 75, 0, now() FROM loans ORDER BY id`)
+	s.Require().NoError(err)
+
+	s.Run("list", func() {
+		fines := listFines("/patrons/7/fines")
+		s.Require().Len(fines, 2)
+		s.Assert().Equal(75, fines[0].Amount)
+		s.Assert().Equal(hm.FineOpen, fines[0].Status)
+		s.Assert().Len(listFines("/patrons/8/fines"), 0)
+	})
+	s.Run("invalid_status", func() {
+		resp := do(http.MethodGet, "/patrons/7/fines?status=unknown", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+	s.Run("pay_unreturned", func() {
+		resp := do(http.MethodPatch, "/fines/1/pay", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("waive", func() {
+		resp := do(http.MethodPatch, "/fines/2/waive", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Assert().Len(listFines("/patrons/7/fines?status=waived"), 1)
+
+		resp = do(http.MethodPatch, "/fines/2/pay", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("not_found", func() {
+		resp := do(http.MethodPatch, "/fines/99/pay", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
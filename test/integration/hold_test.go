@@ -0,0 +1,97 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestHolds() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	placeHold := func(bookID, patronID int) (*http.Response, hm.HoldResponse) {
+		resp := do(http.MethodPost, fmt.Sprintf("/books/%d/holds", bookID), &hm.HoldRequest{PatronID: patronID})
+		var hold hm.HoldResponse
+		_ = json.NewDecoder(resp.Body).Decode(&hold)
+		resp.Body.Close()
+		return resp, hold
+	}
+	listHolds := func(url string) []hm.HoldResponse {
+		resp := do(http.MethodGet, url, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var holds []hm.HoldResponse
+		err := json.NewDecoder(resp.Body).Decode(&holds)
+		resp.Body.Close()
+		s.Require().NoError(err)
+		return holds
+	}
+	resp := do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 1})
+	resp.Body.Close()
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+	s.Run("available_book", func() {
+		resp, _ := placeHold(2, 2)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	resp, first := placeHold(1, 2)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	resp, second := placeHold(1, 3)
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	s.Run("duplicate", func() {
+		resp, _ := placeHold(1, 2)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("queue", func() {
+		holds := listHolds("/books/1/holds?active=true")
+		s.Require().Len(holds, 2)
+		s.Assert().Equal(first.ID, holds[0].ID)
+		s.Assert().Equal(second.ID, holds[1].ID)
+		s.Assert().Equal(hm.HoldWaiting, holds[0].Status)
+	})
+	s.Run("return_assigns_first_hold", func() {
+		resp := do(http.MethodPatch, "/books/1/in", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		holds := listHolds("/holds?patron_id=2")
+		s.Require().Len(holds, 1)
+		s.Assert().Equal(hm.HoldReady, holds[0].Status)
+		s.Assert().NotNil(holds[0].CopyID)
+		s.Assert().NotNil(holds[0].ExpiresAt)
+	})
+	s.Run("other_patron_checkout", func() {
+		resp := do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 3})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("hold_patron_checkout", func() {
+		resp := do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: 2})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		holds := listHolds("/holds?patron_id=2")
+		s.Require().Len(holds, 1)
+		s.Assert().Equal(hm.HoldFulfilled, holds[0].Status)
+	})
+	s.Run("cancel", func() {
+		resp := do(http.MethodDelete, fmt.Sprintf("/holds/%d", second.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Assert().Len(listHolds("/books/1/holds?active=true"), 0)
+
+		resp = do(http.MethodDelete, fmt.Sprintf("/holds/%d", second.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+}
@@ -0,0 +1,71 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestImportBooks() {
+	const books = `name,isbn,author,publisher,publish_date
+The Selfish Gene,9780198788607,Richard Dawkins,Oxford University Press,2016-09-01
+Sapiens,0062316095,Yuval Noah Harari,Harper,2015-02-10
+Sapiens (copy),9780062316097,Yuval Noah Harari,Harper,2015-02-10
+Untitled,,Nobody,Nowhere,
+`
+	importBooks := func(query string) hm.ImportReport {
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/books:import"+query, bytes.NewBufferString(books))
+		req.Header.Set("Content-Type", "text/csv")
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var report hm.ImportReport
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&report))
+		return report
+	}
+	countBooks := func() int {
+		resp, err := s.c.Get("http://localhost:8080/api/v1/books")
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var list hm.BookListResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&list))
+		return len(list.Books)
+	}
+
+	s.Run("dry_run", func() {
+		report := importBooks("?dry_run=true")
+		s.Assert().True(report.DryRun)
+		s.Assert().Equal(2, report.Created)
+		s.Assert().Equal(1, report.Duplicates)
+		s.Assert().Equal(1, report.Rejected)
+		for _, row := range report.Rows {
+			s.Assert().Zero(row.BookID)
+		}
+		s.Assert().Equal(len(testBooks), countBooks())
+	})
+	s.Run("import", func() {
+		report := importBooks("")
+		s.Assert().Equal(2, report.Created)
+		s.Assert().Equal(1, report.Duplicates)
+		s.Assert().Equal(1, report.Rejected)
+		s.Require().Len(report.Rows, 4)
+		s.Assert().Equal(hm.ImportCreated, report.Rows[0].Status)
+		s.Assert().NotZero(report.Rows[0].BookID)
+		// the ISBN-10 and the ISBN-13 of the same book
+		s.Assert().Equal(hm.ImportDuplicate, report.Rows[2].Status)
+		s.Assert().Equal(5, report.Rows[3].Row)
+		s.Assert().Equal(hm.ImportRejected, report.Rows[3].Status)
+		s.Assert().Equal(len(testBooks)+2, countBooks())
+	})
+	s.Run("reimport", func() {
+		report := importBooks("")
+		s.Assert().Zero(report.Created)
+		s.Assert().Equal(3, report.Duplicates)
+		s.Assert().Equal(len(testBooks)+2, countBooks())
+	})
+}
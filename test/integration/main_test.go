@@ -3,11 +3,8 @@
 package integration
 
 import (
-	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path"
-	"strings"
 	"testing"
 	"time"
 
@@ -35,21 +32,12 @@ func (s *LibreriaTestSuite) SetupSuite() {
 	if err != nil {
 		s.FailNow("error reading configuration", err)
 	}
-	dir, _ := os.Getwd()
-	var migD string
-	if path.Base(dir) == "libreria" {
-		migD = path.Join(dir, "storage", "postgres")
-	} else {
-		s := strings.Split(dir, "libreria")
-		migD = path.Join(s[0], "libreria", "storage", "postgres")
-	}
-	connString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.PostgresTest.User, cfg.PostgresTest.Password, cfg.PostgresTest.Host, cfg.PostgresTest.Port, cfg.PostgresTest.Name)
-	err = migrations.Migrate(connString, "down", migD)
+	connString := cfg.PostgresTest.ConnString()
+	err = migrations.Migrate(connString, io.Discard, "down")
 	if err != nil {
 		s.FailNow("failed to run migrations down", err)
 	}
-	err = migrations.Migrate(connString, "up", migD)
+	err = migrations.Migrate(connString, io.Discard, "up")
 	if err != nil {
 		s.FailNow("failed to run migrations up", err)
 	}
@@ -62,7 +50,27 @@ func (s *LibreriaTestSuite) SetupSuite() {
 		ReadTimeout:  cfg.PostgresTest.ReadTimeout,
 		MaxRetries:   cfg.PostgresTest.MaxRetries,
 	})
-	s.c = &http.Client{Timeout: time.Second * 60}
+	s.c = &http.Client{Timeout: time.Second * 60, Transport: apiKeyTransport{key: adminKey}}
+}
+
+// The API keys of the service under test, see docker-compose-test.yml.
+const (
+	adminKey = "test-admin-key"
+	aliceKey = "test-alice-key"
+	bobKey   = "test-bob-key"
+)
+
+// apiKeyTransport authenticates the requests without credentials with the key.
+type apiKeyTransport struct {
+	key string
+}
+
+func (t apiKeyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get("X-API-Key") == "" && r.Header.Get("Authorization") == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set("X-API-Key", t.key)
+	}
+	return http.DefaultTransport.RoundTrip(r)
 }
 
 func (s *LibreriaTestSuite) TearDownSuite() {
@@ -91,14 +99,28 @@ var testBooks = []*models.Book{
 }
 
 func (s *LibreriaTestSuite) SetupTest() {
-	_, err := s.db.Exec("TRUNCATE books RESTART IDENTITY")
+	_, err := s.db.Exec("TRUNCATE books, copies, loans, holds, fines, patrons, book_audit, book_ratings, book_authors, authors, publishers RESTART IDENTITY")
 	if err != nil {
-		s.Fail("failed to truncate books table", err)
+		s.Fail("failed to truncate tables", err)
 	}
 	_, err = s.db.Model(&testBooks).Insert()
 	if err != nil {
 		s.Fail("failed to insert test values", err)
 	}
+	// each test book has a single copy
+	_, err = s.db.Exec("INSERT INTO copies (book_id) SELECT id FROM books ORDER BY id")
+	if err != nil {
+		s.Fail("failed to insert test values", err)
+	}
+	_, err = s.db.Exec("UPDATE books SET total_copies = 1, available_copies = 1")
+	if err != nil {
+		s.Fail("failed to insert test values", err)
+	}
+	// patrons 1 to 10 borrow the test books
+	_, err = s.db.Exec("INSERT INTO patrons (name, loan_limit) SELECT 'Patron ' || n, 5 FROM generate_series(1, 10) n")
+	if err != nil {
+		s.Fail("failed to insert test values", err)
+	}
 }
 
 func TestLibreriaTestSuite(t *testing.T) {
@@ -0,0 +1,121 @@
+// +build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	hm "github.com/libreria/server/http/models"
+)
+
+func (s *LibreriaTestSuite) TestPatrons() {
+	do := func(method, url string, body interface{}) *http.Response {
+		var b []byte
+		if body != nil {
+			b, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, "http://localhost:8080/api/v1"+url, bytes.NewReader(b))
+		resp, err := s.c.Do(req)
+		s.Require().NoError(err)
+		return resp
+	}
+	resp := do(http.MethodPost, "/patrons", &hm.Patron{Name: "Ada Lovelace", Email: "ada@example.com", LoanLimit: 1})
+	s.Require().Equal(http.StatusCreated, resp.StatusCode)
+	var patron hm.PatronResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&patron))
+	resp.Body.Close()
+	s.Assert().NotEmpty(patron.MembershipNumber)
+	s.Assert().Equal(hm.PatronActive, patron.Status)
+
+	s.Run("validation", func() {
+		resp := do(http.MethodPost, "/patrons", &hm.Patron{Name: "Bad Email", Email: "nope"})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+	s.Run("duplicate_membership_number", func() {
+		resp := do(http.MethodPost, "/patrons", &hm.Patron{Name: "Copycat", MembershipNumber: patron.MembershipNumber})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("search", func() {
+		resp := do(http.MethodGet, "/patrons?q=lovelace", nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		var patrons []hm.PatronResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&patrons))
+		resp.Body.Close()
+		s.Require().Len(patrons, 1)
+		s.Assert().Equal(patron.ID, patrons[0].ID)
+	})
+	s.Run("loan_limit", func() {
+		resp := do(http.MethodPatch, "/books/1/out", &hm.CheckoutRequest{BorrowerID: patron.ID})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		resp = do(http.MethodPatch, "/books/2/out", &hm.CheckoutRequest{BorrowerID: patron.ID})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("suspended", func() {
+		resp := do(http.MethodPut, fmt.Sprintf("/patrons/%d", patron.ID),
+			&hm.Patron{Name: patron.Name, Status: hm.PatronSuspended, LoanLimit: 3})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		resp = do(http.MethodPatch, "/books/2/out", &hm.CheckoutRequest{BorrowerID: patron.ID})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+	s.Run("unknown_borrower", func() {
+		resp := do(http.MethodPatch, "/books/2/out", &hm.CheckoutRequest{BorrowerID: 999})
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+	s.Run("delete", func() {
+		resp := do(http.MethodDelete, fmt.Sprintf("/patrons/%d", patron.ID), nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		resp = do(http.MethodDelete, "/patrons/10", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+		resp = do(http.MethodGet, "/patrons/10", nil)
+		resp.Body.Close()
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *LibreriaTestSuite) TestConcurrentCheckouts() {
+	_, err := s.db.Exec("UPDATE patrons SET loan_limit = 1 WHERE id = 1")
+	s.Require().NoError(err)
+	// every test book is checked out at once by the patron allowed a single loan
+	codes := make([]int, len(testBooks))
+	errs := make([]error, len(testBooks))
+	var wg sync.WaitGroup
+	for i := range testBooks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b, _ := json.Marshal(&hm.CheckoutRequest{BorrowerID: 1})
+			req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("http://localhost:8080/api/v1/books/%d/out", i+1), bytes.NewReader(b))
+			resp, err := s.c.Do(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+	created := 0
+	for i := range codes {
+		s.Require().NoError(errs[i])
+		if codes[i] == http.StatusCreated {
+			created++
+			continue
+		}
+		s.Assert().Equal(http.StatusConflict, codes[i])
+	}
+	s.Assert().Equal(1, created)
+}
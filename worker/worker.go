@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is scheduled work run every Interval, a job without a positive
+// Interval is disabled. Run returns the number of processed items, which is
+// logged if not zero.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int, error)
+}
+
+// Worker runs jobs in the background until the global context is done.
+type Worker struct {
+	jobs []Job
+}
+
+func New(jobs ...Job) *Worker {
+	return &Worker{jobs: jobs}
+}
+
+// Run starts every enabled job in its own goroutine registered with the wait
+// group.
+func (w *Worker) Run(globalCtx context.Context, wg *sync.WaitGroup) {
+	for _, j := range w.jobs {
+		if j.Interval <= 0 {
+			log.WithField("job", j.Name).Warn("job is disabled, its interval is not positive")
+			continue
+		}
+		wg.Add(1)
+		go func(j Job) {
+			defer wg.Done()
+			w.run(globalCtx, j)
+		}(j)
+	}
+}
+
+func (w *Worker) run(globalCtx context.Context, j Job) {
+	logger := log.WithField("job", j.Name)
+	logger.Debugf("job started with interval %s", j.Interval)
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-globalCtx.Done():
+			logger.Info("job has stopped")
+			return
+		case <-ticker.C:
+			n, err := j.Run(globalCtx)
+			if err != nil {
+				logger.WithError(err).Error("job error")
+				continue
+			}
+			if n > 0 {
+				logger.Infof("job processed %d items", n)
+			}
+		}
+	}
+}
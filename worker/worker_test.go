@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Run(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	a := assert.New(t) // assertion object for comparing values
+
+	var ok, failing int32
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	New(
+		Job{Name: "ok", Interval: time.Millisecond, Run: func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&ok, 1)
+			return 1, nil
+		}},
+		Job{Name: "failing", Interval: time.Millisecond, Run: func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&failing, 1)
+			return 0, errors.New("failed")
+		}},
+	).Run(ctx, wg)
+
+	a.Eventually(func() bool {
+		return atomic.LoadInt32(&ok) > 2 && atomic.LoadInt32(&failing) > 2
+	}, time.Second, time.Millisecond) // failing runs do not stop the job
+	cancel()
+	wg.Wait() // jobs stop with the context
+}
+
+func TestWorker_RunDisabled(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := &sync.WaitGroup{}
+	New(
+		Job{Name: "zero", Run: func(ctx context.Context) (int, error) {
+			t.Error("disabled job has run")
+			return 0, nil
+		}},
+		Job{Name: "negative", Interval: -time.Second, Run: func(ctx context.Context) (int, error) {
+			t.Error("disabled job has run")
+			return 0, nil
+		}},
+	).Run(ctx, wg)
+	wg.Wait() // disabled jobs are not started, so nothing is waited for
+}